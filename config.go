@@ -0,0 +1,463 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	netmail "net/mail"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"staffperformance/internal/mail"
+	"staffperformance/internal/scoring"
+	"staffperformance/internal/store"
+	"staffperformance/internal/web"
+)
+
+// Environment names accepted by the -env flag and SP_ENV variable
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// defaultSessionSecret is the development session key. Production refuses to
+// start with it because anyone can forge cookies signed with a public key.
+const defaultSessionSecret = "your-secret-key-change-this-in-production"
+
+// Config holds the runtime settings of the server. Values are resolved from,
+// in increasing order of precedence: defaults, an optional JSON config file,
+// SP_* environment variables, then command-line flags.
+type Config struct {
+	Env        string `json:"env"`
+	ListenAddr string `json:"listen_addr"`
+
+	// DBDriver selects the database: sqlite (the file at DBPath) or
+	// postgres (the server at DBURL)
+	DBDriver string `json:"db_driver"`
+	DBPath   string `json:"db_path"`
+	DBURL    string `json:"db_url"`
+
+	// SessionKeys are securecookie key pairs written as "hashKey" or
+	// "hashKey:blockKey". The first pair signs new cookies; the remaining
+	// pairs are still accepted so keys can be rotated without logging
+	// everyone out. Block keys enable encryption and must be 16, 24 or 32 bytes.
+	SessionKeys []string `json:"session_keys"`
+
+	SessionMaxAge  int    `json:"session_max_age"` // Seconds
+	CookieSecure   bool   `json:"cookie_secure"`
+	CookieSameSite string `json:"cookie_same_site"` // lax, strict or none
+
+	// LoginMaxFailures failed sign-ins in a row lock an account for
+	// LoginLockout seconds, or until an administrator unlocks it
+	LoginMaxFailures int `json:"login_max_failures"`
+	LoginLockout     int `json:"login_lockout"` // Seconds
+
+	// TrustProxy takes the client address of sign-in throttling and history
+	// from X-Forwarded-For; only set it behind a reverse proxy that sets it
+	TrustProxy bool `json:"trust_proxy"`
+
+	// ScoringStrategy is the formula used for objective performance across
+	// the organisation; see the scoring package for the choices
+	ScoringStrategy string `json:"scoring_strategy"`
+
+	// RatingScale labels the points of the appraisal rating scale, lowest first
+	RatingScale []string `json:"rating_scale"`
+
+	// BaseURL is the address users reach the server at, used for links in email
+	BaseURL string `json:"base_url"`
+
+	// MailTransport is how email is delivered: smtp, file (one .eml file per
+	// message in MailDir) or log (written to the server log)
+	MailTransport string `json:"mail_transport"`
+	MailFrom      string `json:"mail_from"`
+	MailDir       string `json:"mail_dir"`
+	SMTPHost      string `json:"smtp_host"`
+	SMTPPort      int    `json:"smtp_port"`
+	SMTPUsername  string `json:"smtp_username"`
+	SMTPPassword  string `json:"smtp_password"`
+}
+
+// DefaultConfig returns the settings used when nothing is configured
+func DefaultConfig() Config {
+	return Config{
+		Env:            EnvDevelopment,
+		ListenAddr:     ":8080",
+		DBDriver:       string(store.DialectSQLite),
+		DBPath:         "./staffperformance.db",
+		SessionKeys:    []string{defaultSessionSecret},
+		SessionMaxAge:  86400 * 7, // 7 days
+		CookieSecure:   false,
+		CookieSameSite: "lax",
+
+		LoginMaxFailures: web.DefaultLoginPolicy.MaxFailures,
+		LoginLockout:     int(web.DefaultLoginPolicy.Lockout / time.Second),
+
+		ScoringStrategy: scoring.Default,
+		RatingScale:     web.DefaultRatingScale,
+
+		BaseURL:       "http://localhost:8080",
+		MailTransport: mail.TransportLog,
+		MailFrom:      "Staff Performance System <noreply@localhost>",
+		MailDir:       "./outbox",
+		SMTPPort:      587,
+	}
+}
+
+// LoadConfig builds the configuration from the command-line arguments (without
+// the program name), the environment and the config file named by -config or
+// SP_CONFIG. It also returns the arguments left after the flags.
+func LoadConfig(args []string) (*Config, []string, error) {
+	cfg := DefaultConfig()
+
+	// Flags are parsed first only to find the config file; they are applied
+	// last so they override the file and environment.
+	var flagCfg Config
+	var sessionKeys, ratingScale, configPath string
+	fs := flag.NewFlagSet("staffperformance", flag.ContinueOnError)
+	fs.StringVar(&configPath, "config", "", "path to a JSON config file (env SP_CONFIG)")
+	fs.StringVar(&flagCfg.Env, "env", cfg.Env, "environment: development or production (env SP_ENV)")
+	fs.StringVar(&flagCfg.ListenAddr, "addr", cfg.ListenAddr, "HTTP listen address (env SP_LISTEN_ADDR)")
+	fs.StringVar(&flagCfg.DBDriver, "db-driver", cfg.DBDriver, "database: "+strings.Join(store.Dialects, " or ")+" (env SP_DB_DRIVER)")
+	fs.StringVar(&flagCfg.DBPath, "db", cfg.DBPath, "SQLite database path (env SP_DB_PATH)")
+	fs.StringVar(&sessionKeys, "session-keys", "", "comma-separated session key pairs, newest first (env SP_SESSION_KEYS)")
+	fs.IntVar(&flagCfg.SessionMaxAge, "session-max-age", cfg.SessionMaxAge, "session lifetime in seconds (env SP_SESSION_MAX_AGE)")
+	fs.BoolVar(&flagCfg.CookieSecure, "cookie-secure", cfg.CookieSecure, "only send the session cookie over HTTPS (env SP_COOKIE_SECURE)")
+	fs.StringVar(&flagCfg.CookieSameSite, "cookie-samesite", cfg.CookieSameSite, "session cookie SameSite mode: lax, strict or none (env SP_COOKIE_SAMESITE)")
+	fs.IntVar(&flagCfg.LoginMaxFailures, "login-max-failures", cfg.LoginMaxFailures, "failed sign-ins in a row that lock an account (env SP_LOGIN_MAX_FAILURES)")
+	fs.IntVar(&flagCfg.LoginLockout, "login-lockout", cfg.LoginLockout, "account lockout in seconds (env SP_LOGIN_LOCKOUT)")
+	fs.BoolVar(&flagCfg.TrustProxy, "trust-proxy", cfg.TrustProxy, "take client addresses from X-Forwarded-For (env SP_TRUST_PROXY)")
+	fs.StringVar(&flagCfg.ScoringStrategy, "scoring-strategy", cfg.ScoringStrategy, "objective scoring: "+strings.Join(scoring.Names(), ", ")+" (env SP_SCORING_STRATEGY)")
+	fs.StringVar(&ratingScale, "rating-scale", "", "comma-separated appraisal rating labels, lowest first (env SP_RATING_SCALE)")
+	fs.StringVar(&flagCfg.BaseURL, "base-url", cfg.BaseURL, "address users reach the server at, for links in email (env SP_BASE_URL)")
+	fs.StringVar(&flagCfg.MailTransport, "mail-transport", cfg.MailTransport, "email delivery: "+strings.Join(mail.Transports, ", ")+" (env SP_MAIL_TRANSPORT)")
+	fs.StringVar(&flagCfg.MailFrom, "mail-from", cfg.MailFrom, "sender address of outgoing email (env SP_MAIL_FROM)")
+	fs.StringVar(&flagCfg.MailDir, "mail-dir", cfg.MailDir, "directory the file mail transport writes to (env SP_MAIL_DIR)")
+	fs.StringVar(&flagCfg.SMTPHost, "smtp-host", cfg.SMTPHost, "SMTP server host (env SP_SMTP_HOST)")
+	fs.IntVar(&flagCfg.SMTPPort, "smtp-port", cfg.SMTPPort, "SMTP server port (env SP_SMTP_PORT)")
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if configPath == "" {
+		configPath = os.Getenv("SP_CONFIG")
+	}
+	if configPath != "" {
+		if err := cfg.loadFile(configPath); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := cfg.loadEnv(); err != nil {
+		return nil, nil, err
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "env":
+			cfg.Env = flagCfg.Env
+		case "addr":
+			cfg.ListenAddr = flagCfg.ListenAddr
+		case "db-driver":
+			cfg.DBDriver = flagCfg.DBDriver
+		case "db":
+			cfg.DBPath = flagCfg.DBPath
+		case "session-keys":
+			cfg.SessionKeys = splitList(sessionKeys)
+		case "session-max-age":
+			cfg.SessionMaxAge = flagCfg.SessionMaxAge
+		case "cookie-secure":
+			cfg.CookieSecure = flagCfg.CookieSecure
+		case "cookie-samesite":
+			cfg.CookieSameSite = flagCfg.CookieSameSite
+		case "login-max-failures":
+			cfg.LoginMaxFailures = flagCfg.LoginMaxFailures
+		case "login-lockout":
+			cfg.LoginLockout = flagCfg.LoginLockout
+		case "trust-proxy":
+			cfg.TrustProxy = flagCfg.TrustProxy
+		case "scoring-strategy":
+			cfg.ScoringStrategy = flagCfg.ScoringStrategy
+		case "rating-scale":
+			cfg.RatingScale = splitList(ratingScale)
+		case "base-url":
+			cfg.BaseURL = flagCfg.BaseURL
+		case "mail-transport":
+			cfg.MailTransport = flagCfg.MailTransport
+		case "mail-from":
+			cfg.MailFrom = flagCfg.MailFrom
+		case "mail-dir":
+			cfg.MailDir = flagCfg.MailDir
+		case "smtp-host":
+			cfg.SMTPHost = flagCfg.SMTPHost
+		case "smtp-port":
+			cfg.SMTPPort = flagCfg.SMTPPort
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return &cfg, fs.Args(), nil
+}
+
+// loadFile overlays the values present in a JSON config file
+func (c *Config) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil && err != io.EOF {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// loadEnv overlays the SP_* environment variables that are set
+func (c *Config) loadEnv() error {
+	if v, ok := os.LookupEnv("SP_ENV"); ok {
+		c.Env = v
+	}
+	if v, ok := os.LookupEnv("SP_LISTEN_ADDR"); ok {
+		c.ListenAddr = v
+	}
+	if v, ok := os.LookupEnv("SP_DB_DRIVER"); ok {
+		c.DBDriver = v
+	}
+	if v, ok := os.LookupEnv("SP_DB_PATH"); ok {
+		c.DBPath = v
+	}
+	if v, ok := os.LookupEnv("SP_SESSION_KEYS"); ok {
+		c.SessionKeys = splitList(v)
+	}
+	if v, ok := os.LookupEnv("SP_SESSION_MAX_AGE"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("SP_SESSION_MAX_AGE: %w", err)
+		}
+		c.SessionMaxAge = n
+	}
+	if v, ok := os.LookupEnv("SP_COOKIE_SECURE"); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("SP_COOKIE_SECURE: %w", err)
+		}
+		c.CookieSecure = b
+	}
+	if v, ok := os.LookupEnv("SP_COOKIE_SAMESITE"); ok {
+		c.CookieSameSite = v
+	}
+	if v, ok := os.LookupEnv("SP_LOGIN_MAX_FAILURES"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("SP_LOGIN_MAX_FAILURES: %w", err)
+		}
+		c.LoginMaxFailures = n
+	}
+	if v, ok := os.LookupEnv("SP_LOGIN_LOCKOUT"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("SP_LOGIN_LOCKOUT: %w", err)
+		}
+		c.LoginLockout = n
+	}
+	if v, ok := os.LookupEnv("SP_TRUST_PROXY"); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("SP_TRUST_PROXY: %w", err)
+		}
+		c.TrustProxy = b
+	}
+	if v, ok := os.LookupEnv("SP_SCORING_STRATEGY"); ok {
+		c.ScoringStrategy = v
+	}
+	if v, ok := os.LookupEnv("SP_RATING_SCALE"); ok {
+		c.RatingScale = splitList(v)
+	}
+	if v, ok := os.LookupEnv("SP_BASE_URL"); ok {
+		c.BaseURL = v
+	}
+	if v, ok := os.LookupEnv("SP_MAIL_TRANSPORT"); ok {
+		c.MailTransport = v
+	}
+	if v, ok := os.LookupEnv("SP_MAIL_FROM"); ok {
+		c.MailFrom = v
+	}
+	if v, ok := os.LookupEnv("SP_MAIL_DIR"); ok {
+		c.MailDir = v
+	}
+	if v, ok := os.LookupEnv("SP_SMTP_HOST"); ok {
+		c.SMTPHost = v
+	}
+	if v, ok := os.LookupEnv("SP_SMTP_PORT"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("SP_SMTP_PORT: %w", err)
+		}
+		c.SMTPPort = n
+	}
+	// Credentials are only read from the environment or the config file, so
+	// they do not show up in the process list
+	if v, ok := os.LookupEnv("SP_DB_URL"); ok {
+		c.DBURL = v
+	}
+	if v, ok := os.LookupEnv("SP_SMTP_USERNAME"); ok {
+		c.SMTPUsername = v
+	}
+	if v, ok := os.LookupEnv("SP_SMTP_PASSWORD"); ok {
+		c.SMTPPassword = v
+	}
+	return nil
+}
+
+// Validate checks the configuration for values the server cannot run with
+func (c *Config) Validate() error {
+	if c.Env != EnvDevelopment && c.Env != EnvProduction {
+		return fmt.Errorf("unknown environment %q", c.Env)
+	}
+	if c.ListenAddr == "" {
+		return errors.New("listen address is required")
+	}
+	switch store.Dialect(c.DBDriver) {
+	case store.DialectSQLite:
+		if c.DBPath == "" {
+			return errors.New("database path is required")
+		}
+	case store.DialectPostgres:
+		if c.DBURL == "" {
+			return errors.New("the postgres database driver requires db_url")
+		}
+	default:
+		return fmt.Errorf("unknown database driver %q (want one of %v)", c.DBDriver, store.Dialects)
+	}
+	if c.SessionMaxAge <= 0 {
+		return errors.New("session max age must be positive")
+	}
+	if c.LoginMaxFailures <= 0 {
+		return errors.New("login max failures must be positive")
+	}
+	if c.LoginLockout <= 0 {
+		return errors.New("login lockout must be positive")
+	}
+	if _, err := c.SameSite(); err != nil {
+		return err
+	}
+	if _, err := c.SessionKeyPairs(); err != nil {
+		return err
+	}
+	if _, err := scoring.Lookup(c.ScoringStrategy); err != nil {
+		return err
+	}
+	if len(c.RatingScale) < 2 || len(c.RatingScale) > 10 {
+		return errors.New("the rating scale must have between 2 and 10 labels")
+	}
+	if _, err := url.ParseRequestURI(c.BaseURL); err != nil {
+		return fmt.Errorf("invalid base URL %q", c.BaseURL)
+	}
+	if _, err := c.MailSender(); err != nil {
+		return err
+	}
+
+	if c.IsProduction() {
+		for _, key := range c.SessionKeys {
+			if strings.HasPrefix(key, defaultSessionSecret) {
+				return errors.New("refusing to start in production with the default session secret; set SP_SESSION_KEYS")
+			}
+		}
+		if c.CookieSameSite == "none" && !c.CookieSecure {
+			return errors.New("SameSite=None cookies require cookie_secure in production")
+		}
+	}
+	return nil
+}
+
+// IsProduction reports whether the server runs in production mode
+func (c *Config) IsProduction() bool {
+	return c.Env == EnvProduction
+}
+
+// SameSite converts the configured SameSite mode for http.Cookie
+func (c *Config) SameSite() (http.SameSite, error) {
+	switch strings.ToLower(c.CookieSameSite) {
+	case "", "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	}
+	return 0, fmt.Errorf("unknown cookie SameSite mode %q", c.CookieSameSite)
+}
+
+// SessionKeyPairs returns the session keys as alternating hash and block keys,
+// the form expected by sessions.NewCookieStore. A nil block key disables
+// encryption for that pair.
+func (c *Config) SessionKeyPairs() ([][]byte, error) {
+	if len(c.SessionKeys) == 0 {
+		return nil, errors.New("at least one session key is required")
+	}
+
+	var pairs [][]byte
+	for i, key := range c.SessionKeys {
+		hashKey, blockKey, _ := strings.Cut(key, ":")
+		if hashKey == "" {
+			return nil, fmt.Errorf("session key %d has an empty hash key", i+1)
+		}
+		pairs = append(pairs, []byte(hashKey))
+
+		switch len(blockKey) {
+		case 0:
+			pairs = append(pairs, nil)
+		case 16, 24, 32:
+			pairs = append(pairs, []byte(blockKey))
+		default:
+			return nil, fmt.Errorf("session key %d: block key must be 16, 24 or 32 bytes", i+1)
+		}
+	}
+	return pairs, nil
+}
+
+// MailSender creates the sender for the configured mail transport
+func (c *Config) MailSender() (mail.Sender, error) {
+	if _, err := netmail.ParseAddress(c.MailFrom); err != nil {
+		return nil, fmt.Errorf("invalid mail sender address %q", c.MailFrom)
+	}
+	switch c.MailTransport {
+	case mail.TransportSMTP:
+		if c.SMTPHost == "" {
+			return nil, errors.New("the smtp mail transport requires smtp_host")
+		}
+		if c.SMTPPort <= 0 || c.SMTPPort > 65535 {
+			return nil, fmt.Errorf("invalid SMTP port %d", c.SMTPPort)
+		}
+		return &mail.SMTPSender{Host: c.SMTPHost, Port: c.SMTPPort, Username: c.SMTPUsername, Password: c.SMTPPassword, From: c.MailFrom}, nil
+	case mail.TransportFile:
+		if c.MailDir == "" {
+			return nil, errors.New("the file mail transport requires mail_dir")
+		}
+		return &mail.FileSender{Dir: c.MailDir, From: c.MailFrom}, nil
+	case mail.TransportLog:
+		return &mail.LogSender{From: c.MailFrom}, nil
+	}
+	return nil, fmt.Errorf("unknown mail transport %q (want one of %v)", c.MailTransport, mail.Transports)
+}
+
+// splitList splits a comma-separated value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
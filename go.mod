@@ -4,6 +4,8 @@ go 1.24.0
 
 require (
 	github.com/gorilla/sessions v1.2.2
+	github.com/lib/pq v1.10.9
+	golang.org/x/crypto v0.43.0
 	modernc.org/sqlite v1.44.3
 )
 
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"log"
+	"net/http"
+)
+
+// A browser session's CSRF token is sent back in the CSRFField form field, or
+// in the CSRFHeader header by scripts
+const (
+	CSRFField  = "csrf_token"
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// csrfSessionKey is the session value holding the token
+const csrfSessionKey = "csrfToken"
+
+// newCSRFToken returns a random token for a session
+func newCSRFToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// ProtectCSRF middleware gives every browser session a CSRF token and rejects
+// requests other than GET, HEAD and OPTIONS that do not send it back. Pages
+// read the token with CSRFToken. Requests with a bearer token send no cookies,
+// so cannot be forged this way, and are not checked.
+func (a *Authenticator) ProtectCSRF(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := BearerToken(r); ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// A cookie that no longer decodes, e.g. after a key rotation, gives
+		// a new session
+		session, _ := a.cookies.Get(r, "session")
+		token, _ := session.Values[csrfSessionKey].(string)
+		if token == "" {
+			var err error
+			if token, err = newCSRFToken(); err != nil {
+				log.Println("Error creating CSRF token:", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			session.Values[csrfSessionKey] = token
+			if err := session.Save(r, w); err != nil {
+				log.Println("Error saving session:", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			sent := r.Header.Get(CSRFHeader)
+			if sent == "" {
+				sent = r.PostFormValue(CSRFField)
+			}
+			if subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+				http.Error(w, "Invalid or missing CSRF token; reload the page and try again", http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), csrfContextKey, token)))
+	})
+}
+
+// CSRFToken returns the token ProtectCSRF expects back from the request's
+// session, or "" outside ProtectCSRF
+func CSRFToken(r *http.Request) string {
+	token, _ := r.Context().Value(csrfContextKey).(string)
+	return token
+}
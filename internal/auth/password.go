@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"staffperformance/internal/store"
+)
+
+// MinPasswordLength is the shortest password accepted when setting a password
+const MinPasswordLength = 8
+
+// defaultAdminPassword is the well-known password of the seeded admin account
+const defaultAdminPassword = "admin123"
+
+var errPasswordTooShort = fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+
+// HashPassword returns a bcrypt hash of a plaintext password
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// isPasswordHash reports whether a stored password is already a bcrypt hash.
+// Accounts created before hashing was introduced still hold plaintext.
+func isPasswordHash(stored string) bool {
+	return strings.HasPrefix(stored, "$2a$") || strings.HasPrefix(stored, "$2b$") || strings.HasPrefix(stored, "$2y$")
+}
+
+// CheckPassword compares a plaintext password with the stored value. The
+// second result is true when the stored value is legacy plaintext, or a hash
+// with an outdated cost, and should be rehashed now that the password is known.
+func CheckPassword(stored, password string) (ok bool, needsRehash bool) {
+	if !isPasswordHash(stored) {
+		ok = subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+		return ok, ok
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) != nil {
+		return false, false
+	}
+	cost, err := bcrypt.Cost([]byte(stored))
+	return true, err == nil && cost < bcrypt.DefaultCost
+}
+
+// dummyHash is a hash of no account's password, made once when first needed
+var dummyHash = sync.OnceValue(func() string {
+	hash, err := HashPassword("no account has this password")
+	if err != nil {
+		panic(err)
+	}
+	return hash
+})
+
+// CheckDummyPassword does the work of CheckPassword against a real hash and
+// discards the result. Sign-ins with an unknown username call it so they take
+// as long as those with a wrong password, and timing does not tell which
+// usernames exist.
+func CheckDummyPassword(password string) {
+	CheckPassword(dummyHash(), password)
+}
+
+// CreateDefaultAdmin adds the admin account with the default password when
+// there is no user called admin. The password must be changed on first login.
+func CreateDefaultAdmin(users store.UserStore) error {
+	_, err := users.GetUserByUsername("admin")
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	hash, err := HashPassword(defaultAdminPassword)
+	if err != nil {
+		return err
+	}
+	return users.CreateUser(&store.User{Username: "admin", Password: hash, FullName: "Administrator", Email: "admin@example.com",
+		Role: store.RoleAdmin, Department: "Management", Position: "System Administrator", MustChangePassword: true})
+}
+
+// ValidateNewPassword checks a password chosen by a user
+func ValidateNewPassword(password string) error {
+	if len(password) < MinPasswordLength {
+		return errPasswordTooShort
+	}
+	return nil
+}
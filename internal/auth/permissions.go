@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"net/http"
+
+	"staffperformance/internal/store"
+)
+
+// Resource is a kind of record covered by the permission matrix
+type Resource string
+
+const (
+	ResourceObjectives  Resource = "objectives" // Objectives with their outcomes and activities
+	ResourceTasks       Resource = "tasks"
+	ResourceComments    Resource = "comments" // Supervisor feedback on objectives
+	ResourceStaff       Resource = "staff"    // User accounts and staff reports
+	ResourceDepartments Resource = "departments"
+	ResourceTokens      Resource = "tokens" // Personal access tokens for the API
+	ResourceCycles      Resource = "cycles" // Review cycles; not owned by anyone
+	ResourceAppraisals  Resource = "appraisals"
+	ResourceRatings     Resource = "ratings" // Manual ratings of objectives
+)
+
+// Action is what a user wants to do with a resource
+type Action string
+
+const (
+	ActionRead  Action = "read"
+	ActionWrite Action = "write" // Create, update and delete
+)
+
+// Scope limits which records a grant applies to, based on the record's owner
+type Scope int
+
+const (
+	ScopeNone       Scope = iota
+	ScopeOwn              // Records owned by the user
+	ScopeSupervised       // Own records and those of the user's direct reports
+	ScopeAll
+)
+
+// permissions is the permission matrix. The owner of an objective, task,
+// comment, appraisal or API token is the staff member the record belongs to; for staff
+// records it is the account itself; for ratings it is the owner of the rated
+// objective. Roles and resources missing from the matrix get ScopeNone.
+var permissions = map[store.UserRole]map[Resource]map[Action]Scope{
+	store.RoleAdmin: {
+		ResourceObjectives:  {ActionRead: ScopeAll, ActionWrite: ScopeOwn},
+		ResourceTasks:       {ActionRead: ScopeAll, ActionWrite: ScopeOwn},
+		ResourceComments:    {ActionRead: ScopeAll, ActionWrite: ScopeAll},
+		ResourceStaff:       {ActionRead: ScopeAll, ActionWrite: ScopeAll},
+		ResourceDepartments: {ActionRead: ScopeAll, ActionWrite: ScopeAll},
+		ResourceTokens:      {ActionRead: ScopeAll, ActionWrite: ScopeAll},
+		ResourceCycles:      {ActionRead: ScopeAll, ActionWrite: ScopeAll},
+		ResourceAppraisals:  {ActionRead: ScopeAll, ActionWrite: ScopeAll},
+		ResourceRatings:     {ActionWrite: ScopeAll},
+	},
+	store.RoleSupervisor: {
+		ResourceObjectives: {ActionRead: ScopeSupervised, ActionWrite: ScopeOwn},
+		ResourceTasks:      {ActionRead: ScopeSupervised, ActionWrite: ScopeOwn},
+		ResourceComments:   {ActionRead: ScopeSupervised, ActionWrite: ScopeSupervised},
+		ResourceStaff:      {ActionRead: ScopeSupervised},
+		ResourceTokens:     {ActionRead: ScopeOwn, ActionWrite: ScopeOwn},
+		ResourceCycles:     {ActionRead: ScopeAll},
+		ResourceAppraisals: {ActionRead: ScopeSupervised, ActionWrite: ScopeSupervised},
+		ResourceRatings:    {ActionWrite: ScopeSupervised},
+	},
+	store.RoleStaff: {
+		ResourceObjectives: {ActionRead: ScopeOwn, ActionWrite: ScopeOwn},
+		ResourceTasks:      {ActionRead: ScopeOwn, ActionWrite: ScopeOwn},
+		ResourceComments:   {ActionRead: ScopeOwn},
+		ResourceTokens:     {ActionRead: ScopeOwn, ActionWrite: ScopeOwn},
+		ResourceCycles:     {ActionRead: ScopeAll},
+		ResourceAppraisals: {ActionRead: ScopeOwn, ActionWrite: ScopeOwn},
+	},
+}
+
+// PermissionScope returns the scope the role is granted for an action on a resource
+func PermissionScope(role store.UserRole, resource Resource, action Action) Scope {
+	return permissions[role][resource][action]
+}
+
+// Can reports whether the user may perform the action on at least some records
+// of the resource
+func Can(user *store.User, resource Resource, action Action) bool {
+	return user != nil && PermissionScope(user.Role, resource, action) != ScopeNone
+}
+
+// CanAccess reports whether the user may perform the action on a record owned
+// by ownerID
+func (a *Authenticator) CanAccess(user *store.User, resource Resource, action Action, ownerID int) bool {
+	if user == nil {
+		return false
+	}
+
+	switch PermissionScope(user.Role, resource, action) {
+	case ScopeAll:
+		return true
+	case ScopeSupervised:
+		if ownerID == user.ID {
+			return true
+		}
+		owner, err := a.store.GetUserByID(ownerID)
+		return err == nil && owner.SupervisorID != nil && *owner.SupervisorID == user.ID
+	case ScopeOwn:
+		return ownerID == user.ID
+	}
+	return false
+}
+
+// Authorize checks CanAccess and writes a 403 response when access is denied.
+// Handlers return immediately when it reports false.
+func (a *Authenticator) Authorize(w http.ResponseWriter, user *store.User, resource Resource, action Action, ownerID int) bool {
+	if !a.CanAccess(user, resource, action, ownerID) {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return false
+	}
+	return true
+}
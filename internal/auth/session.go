@@ -0,0 +1,208 @@
+// Package auth identifies users: password hashing, browser sessions, API
+// tokens and the permission matrix of what each role may do.
+package auth
+
+import (
+	"context"
+	"encoding/gob"
+	"net/http"
+
+	"github.com/gorilla/sessions"
+
+	"staffperformance/internal/store"
+)
+
+// Authenticator identifies the user making a request, by session cookie or
+// API token
+type Authenticator struct {
+	cookies *sessions.CookieStore
+	store   Store
+}
+
+// Store is the data an Authenticator reads
+type Store interface {
+	store.UserStore
+	store.APITokenStore
+}
+
+// NewAuthenticator creates an Authenticator that signs and encrypts session
+// cookies with keyPairs and sets them with options
+func NewAuthenticator(s Store, keyPairs [][]byte, options sessions.Options) *Authenticator {
+	cookies := sessions.NewCookieStore(keyPairs...)
+	cookies.Options = &options
+	return &Authenticator{cookies: cookies, store: s}
+}
+
+// contextKey is the type of request context keys set by this package
+type contextKey string
+
+// Request context keys set by RequireAuth and ProtectCSRF
+const (
+	userContextKey  contextKey = "user"  // *User
+	tokenContextKey contextKey = "token" // *APIToken, when authenticated with a bearer token
+	csrfContextKey  contextKey = "csrf"  // string, the session's CSRF token
+)
+
+func init() {
+	gob.Register(store.User{})
+}
+
+// SetSession sets user session. The session gets a new CSRF token, so one
+// issued before signing in cannot be used after.
+func (a *Authenticator) SetSession(w http.ResponseWriter, r *http.Request, user *store.User) error {
+	session, err := a.cookies.Get(r, "session")
+	if err != nil {
+		return err
+	}
+	token, err := newCSRFToken()
+	if err != nil {
+		return err
+	}
+	session.Values["userID"] = user.ID
+	session.Values["username"] = user.Username
+	session.Values[csrfSessionKey] = token
+	return session.Save(r, w)
+}
+
+// GetSession gets current user from session
+func (a *Authenticator) GetSession(r *http.Request) (*store.User, error) {
+	session, err := a.cookies.Get(r, "session")
+	if err != nil {
+		return nil, err
+	}
+
+	userID, ok := session.Values["userID"].(int)
+	if !ok {
+		return nil, http.ErrNoCookie
+	}
+
+	return a.store.GetUserByID(userID)
+}
+
+// GetSessionValues gets session values as a map
+func (a *Authenticator) GetSessionValues(r *http.Request) (map[string]interface{}, error) {
+	session, err := a.cookies.Get(r, "session")
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{})
+	for key, value := range session.Values {
+		if keyStr, ok := key.(string); ok {
+			values[keyStr] = value
+		}
+	}
+
+	return values, nil
+}
+
+// ClearSession clears user session
+func (a *Authenticator) ClearSession(w http.ResponseWriter, r *http.Request) error {
+	session, err := a.cookies.Get(r, "session")
+	if err != nil {
+		return err
+	}
+	session.Options.MaxAge = -1
+	return session.Save(r, w)
+}
+
+// Authenticate identifies the user making a request. Requests with an
+// Authorization header are authenticated by their bearer token only; all
+// others by the session cookie. The token is nil for cookie sessions.
+func (a *Authenticator) Authenticate(r *http.Request) (*store.User, *store.APIToken, error) {
+	if plaintext, ok := BearerToken(r); ok {
+		return a.AuthenticateToken(plaintext)
+	}
+	user, err := a.GetSession(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, nil, nil
+}
+
+// WithAuth returns the request with the authenticated user and token stored
+// in its context
+func WithAuth(r *http.Request, user *store.User, token *store.APIToken) *http.Request {
+	ctx := context.WithValue(r.Context(), userContextKey, user)
+	if token != nil {
+		ctx = context.WithValue(ctx, tokenContextKey, token)
+	}
+	return r.WithContext(ctx)
+}
+
+// RequireAuth middleware to protect routes. The logged-in user is stored in
+// the request context; handlers read it with CurrentUser. Users who must
+// change their password are sent to the password page until they have done so.
+// Bearer tokens are accepted too; read-only tokens may only make GET requests.
+func (a *Authenticator) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, token, err := a.Authenticate(r)
+		if err != nil || user == nil {
+			if _, ok := BearerToken(r); ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="staffperformance"`)
+				http.Error(w, "Invalid or expired API token", http.StatusUnauthorized)
+				return
+			}
+			http.Redirect(w, r, "/", http.StatusSeeOther)
+			return
+		}
+		if token != nil && !token.Allows(r.Method) {
+			http.Error(w, "API token does not allow this request", http.StatusForbidden)
+			return
+		}
+		if user.MustChangePassword && r.URL.Path != "/account/password" {
+			if token != nil {
+				http.Error(w, "Password change required", http.StatusForbidden)
+				return
+			}
+			http.Redirect(w, r, "/account/password", http.StatusSeeOther)
+			return
+		}
+		next(w, WithAuth(r, user, token))
+	}
+}
+
+// RequireRole middleware restricts a route to users with one of the given roles.
+// It includes RequireAuth.
+func (a *Authenticator) RequireRole(roles ...store.UserRole) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return a.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+			user := CurrentUser(r)
+			for _, role := range roles {
+				if user.Role == role {
+					next(w, r)
+					return
+				}
+			}
+			http.Error(w, "Access denied", http.StatusForbidden)
+		})
+	}
+}
+
+// RequirePermission middleware restricts a route to roles the permission
+// matrix grants the action on the resource. Handlers still check ownership of
+// individual records with Authorize. It includes RequireAuth.
+func (a *Authenticator) RequirePermission(resource Resource, action Action) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return a.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+			if !Can(CurrentUser(r), resource, action) {
+				http.Error(w, "Access denied", http.StatusForbidden)
+				return
+			}
+			next(w, r)
+		})
+	}
+}
+
+// CurrentUser returns the user stored by RequireAuth, or nil outside protected routes
+func CurrentUser(r *http.Request) *store.User {
+	user, _ := r.Context().Value(userContextKey).(*store.User)
+	return user
+}
+
+// CurrentToken returns the API token a request was authenticated with, or nil
+// for browser sessions
+func CurrentToken(r *http.Request) *store.APIToken {
+	token, _ := r.Context().Value(tokenContextKey).(*store.APIToken)
+	return token
+}
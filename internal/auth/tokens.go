@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"staffperformance/internal/store"
+)
+
+// apiTokenPrefix starts every personal access token so leaked tokens are easy
+// to recognise, e.g. by secret scanners
+const apiTokenPrefix = "sp_"
+
+// APITokenDisplayLength is how much of a token is kept in clear for display
+const APITokenDisplayLength = len(apiTokenPrefix) + 6
+
+var errInvalidToken = errors.New("invalid or expired API token")
+
+// GenerateAPIToken returns a new random token and the hash to store for it.
+// The plaintext is shown to the user once and never stored.
+func GenerateAPIToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = apiTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return token, hashAPIToken(token), nil
+}
+
+// hashAPIToken hashes a token for storage and lookup. Tokens are random and
+// long, so a fast hash is enough; bcrypt is only needed for passwords.
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// BearerToken returns the token of an "Authorization: Bearer" header and
+// whether the request carried one
+func BearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return "", false
+	}
+	scheme, token, _ := strings.Cut(header, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", true
+	}
+	return strings.TrimSpace(token), true
+}
+
+// AuthenticateToken looks up the user a token belongs to and records its use
+func (a *Authenticator) AuthenticateToken(plaintext string) (*store.User, *store.APIToken, error) {
+	if !strings.HasPrefix(plaintext, apiTokenPrefix) {
+		return nil, nil, errInvalidToken
+	}
+	token, err := a.store.GetAPITokenByHash(hashAPIToken(plaintext))
+	if err != nil || token.Expired() {
+		return nil, nil, errInvalidToken
+	}
+
+	user, err := a.store.GetUserByID(token.UserID)
+	if err != nil {
+		return nil, nil, errInvalidToken
+	}
+
+	if err := a.store.TouchAPIToken(token.ID, time.Now()); err != nil {
+		log.Println("Error recording API token use:", err)
+	}
+	return user, token, nil
+}
@@ -0,0 +1,130 @@
+// Package export writes tabular report data as CSV or as an Excel (XLSX)
+// workbook. A workbook holds one sheet per table; CSV holds a single table.
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format names accepted by report download links
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// Formats lists the format names
+var Formats = []string{FormatCSV, FormatXLSX}
+
+// ContentType returns the MIME type of a format
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Sheet is a table with a header row. Cells may be strings, integers,
+// floats, times (written as dates), nil pointers (blank) or anything else,
+// which is formatted with fmt.
+type Sheet struct {
+	Name    string
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// AddRow appends a row of cells
+func (s *Sheet) AddRow(cells ...interface{}) {
+	s.Rows = append(s.Rows, cells)
+}
+
+// Find returns the sheet with the given name, ignoring case, or the first
+// sheet when none matches
+func Find(sheets []Sheet, name string) *Sheet {
+	for i := range sheets {
+		if strings.EqualFold(sheets[i].Name, name) {
+			return &sheets[i]
+		}
+	}
+	if len(sheets) == 0 {
+		return nil
+	}
+	return &sheets[0]
+}
+
+// WriteCSV writes a sheet as CSV. Dates are written as YYYY-MM-DD and
+// numbers without rounding.
+func WriteCSV(w io.Writer, sheet Sheet) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(sheet.Columns); err != nil {
+		return err
+	}
+	for _, row := range sheet.Rows {
+		record := make([]string, len(row))
+		for i, cell := range row {
+			record[i] = csvValue(cell)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvValue(cell interface{}) string {
+	switch v := deref(cell).(type) {
+	case nil:
+		return ""
+	case string:
+		return safeText(v)
+	case time.Time:
+		return v.Format("2006-01-02")
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	default:
+		return safeText(fmt.Sprint(v))
+	}
+}
+
+// safeText keeps text that starts like a formula from being run as one when
+// the file is opened in a spreadsheet
+func safeText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// deref turns pointers to cell values into the value, or nil
+func deref(cell interface{}) interface{} {
+	switch v := cell.(type) {
+	case *string:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case *int:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case *float64:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		return *v
+	}
+	return cell
+}
@@ -0,0 +1,142 @@
+// Package mail delivers outbound email. A Sender sends one message; SMTP
+// delivers it for real while the file and log senders stand in for it in
+// development and tests.
+package mail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Transport names accepted in the configuration
+const (
+	TransportSMTP = "smtp"
+	TransportFile = "file"
+	TransportLog  = "log"
+)
+
+// Transports lists the transport names
+var Transports = []string{TransportSMTP, TransportFile, TransportLog}
+
+// Message is a plain-text email to one recipient
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers messages
+type Sender interface {
+	Send(msg Message) error
+}
+
+// Validate checks that a message has a usable recipient and subject
+func (m Message) Validate() error {
+	if _, err := mail.ParseAddress(m.To); err != nil {
+		return fmt.Errorf("invalid recipient %q: %w", m.To, err)
+	}
+	if strings.TrimSpace(m.Subject) == "" {
+		return errors.New("message has no subject")
+	}
+	return nil
+}
+
+// Bytes renders the message in RFC 5322 format
+func (m Message) Bytes(from string, date time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", m.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(m.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return b.Bytes()
+}
+
+// SMTPSender delivers messages through an SMTP server. Username and password
+// are optional; when set, PLAIN authentication is used, which net/smtp only
+// allows over TLS or to localhost.
+type SMTPSender struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func (s *SMTPSender) Send(msg Message) error {
+	if err := msg.Validate(); err != nil {
+		return err
+	}
+	from, err := mail.ParseAddress(s.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender %q: %w", s.From, err)
+	}
+	to, _ := mail.ParseAddress(msg.To)
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	return smtp.SendMail(addr, auth, from.Address, []string{to.Address}, msg.Bytes(s.From, time.Now()))
+}
+
+// FileSender writes each message to its own .eml file in Dir
+type FileSender struct {
+	Dir  string
+	From string
+
+	mu    sync.Mutex
+	count int
+}
+
+func (s *FileSender) Send(msg Message) error {
+	if err := msg.Validate(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.count++
+	now := time.Now()
+	name := fmt.Sprintf("%s-%03d.eml", now.UTC().Format("20060102T150405.000000"), s.count)
+	s.mu.Unlock()
+
+	return os.WriteFile(filepath.Join(s.Dir, name), msg.Bytes(s.From, now), 0o644)
+}
+
+// LogSender writes messages to a log instead of sending them
+type LogSender struct {
+	Out  io.Writer // Defaults to the standard logger's output
+	From string
+}
+
+func (s *LogSender) Send(msg Message) error {
+	if err := msg.Validate(); err != nil {
+		return err
+	}
+	out := s.Out
+	if out == nil {
+		out = log.Writer()
+	}
+	_, err := fmt.Fprintf(out, "--- mail ---\n%s\n--- end mail ---\n", msg.Bytes(s.From, time.Now()))
+	return err
+}
@@ -0,0 +1,317 @@
+// Package pdf lays out simple text documents - headings, wrapped paragraphs,
+// tables and rules - on A4 pages and writes them as PDF. It uses the
+// standard Helvetica fonts, so text is limited to the Windows-1252
+// character set; other characters are written as "?".
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Page geometry in points (1/72 inch)
+const (
+	PageWidth  = 595.28 // A4
+	PageHeight = 841.89
+	Margin     = 50.0
+
+	// ContentWidth is the width between the margins
+	ContentWidth = PageWidth - 2*Margin
+
+	footerSize = 8.0
+)
+
+// Font selects one of the built-in fonts
+type Font int
+
+const (
+	Regular Font = iota
+	Bold
+)
+
+func (f Font) resource() string {
+	if f == Bold {
+		return "F2"
+	}
+	return "F1"
+}
+
+// Document is a PDF being laid out. Content flows down from the top margin
+// and continues on a new page when it reaches the bottom margin.
+type Document struct {
+	title  string
+	footer string
+	pages  []*bytes.Buffer
+	y      float64 // Baseline position on the current page, from the bottom
+}
+
+// New starts a document. The title is stored in the document information and
+// the footer is printed at the bottom of every page with the page number.
+func New(title, footer string) *Document {
+	d := &Document{title: title, footer: footer}
+	d.NewPage()
+	return d
+}
+
+// NewPage continues on a new page
+func (d *Document) NewPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = PageHeight - Margin
+}
+
+func (d *Document) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+// Keep starts a new page unless height points fit above the bottom margin,
+// so content of that height is not split across pages
+func (d *Document) Keep(height float64) {
+	if d.y-height < Margin+footerSize*2 {
+		d.NewPage()
+	}
+}
+
+// Space moves down by height points
+func (d *Document) Space(height float64) {
+	d.y -= height
+}
+
+// Heading writes a bold line of the given size, keeping it on the same page
+// as at least the first lines that follow it
+func (d *Document) Heading(text string, size float64) {
+	d.Space(size * 0.4)
+	d.Keep(size*1.3 + 40)
+	d.Paragraph(Bold, size, text)
+	d.Space(size * 0.2)
+}
+
+// Paragraph writes text wrapped to the content width
+func (d *Document) Paragraph(font Font, size float64, text string) {
+	d.ParagraphAt(font, size, Margin, ContentWidth, text)
+}
+
+// ParagraphAt writes text wrapped to width, starting x points from the left edge
+func (d *Document) ParagraphAt(font Font, size, x, width float64, text string) {
+	leading := size * 1.3
+	for _, line := range Wrap(font, size, width, text) {
+		d.Keep(leading)
+		d.y -= leading
+		d.text(font, size, x, d.y+size*0.25, line)
+	}
+}
+
+// Label writes a bold label followed by a regular value on one wrapped line
+func (d *Document) Label(size float64, label, value string) {
+	labelWidth := TextWidth(Bold, size, label+" ")
+	leading := size * 1.3
+	lines := Wrap(Regular, size, ContentWidth-labelWidth, value)
+	for i, line := range lines {
+		d.Keep(leading)
+		d.y -= leading
+		if i == 0 {
+			d.text(Bold, size, Margin, d.y+size*0.25, label)
+		}
+		d.text(Regular, size, Margin+labelWidth, d.y+size*0.25, line)
+	}
+}
+
+// Rule draws a horizontal line across the content width
+func (d *Document) Rule() {
+	d.Keep(6)
+	d.y -= 3
+	d.line(Margin, d.y, PageWidth-Margin, d.y, 0.5)
+	d.y -= 3
+}
+
+// Table writes rows of cells under a bold header row. widths are fractions
+// of the content width, one per column. Cells wrap, and the header is
+// repeated when the table continues on a new page.
+func (d *Document) Table(size float64, widths []float64, header []string, rows [][]string) {
+	const padding = 3.0
+	leading := size * 1.3
+
+	columns := make([]float64, len(widths))
+	for i, w := range widths {
+		columns[i] = w * ContentWidth
+	}
+
+	drawRow := func(font Font, cells []string, shade bool) {
+		wrapped := make([][]string, len(columns))
+		lines := 1
+		for i := range columns {
+			if i < len(cells) {
+				wrapped[i] = Wrap(font, size, columns[i]-2*padding, cells[i])
+			}
+			lines = max(lines, len(wrapped[i]))
+		}
+		height := float64(lines)*leading + 2*padding
+
+		top := d.y
+		if shade {
+			fmt.Fprintf(d.page(), "0.92 g %.2f %.2f %.2f %.2f re f 0 g\n", Margin, top-height, ContentWidth, height)
+		}
+		x := Margin
+		for i, cellLines := range wrapped {
+			for j, line := range cellLines {
+				d.text(font, size, x+padding, top-padding-float64(j+1)*leading+size*0.25, line)
+			}
+			x += columns[i]
+		}
+		d.y = top - height
+		d.line(Margin, d.y, PageWidth-Margin, d.y, 0.25)
+	}
+
+	rowHeight := func(cells []string) float64 {
+		lines := 1
+		for i := range columns {
+			if i < len(cells) {
+				lines = max(lines, len(Wrap(Regular, size, columns[i]-2*padding, cells[i])))
+			}
+		}
+		return float64(lines)*leading + 2*padding
+	}
+
+	headerHeight := rowHeight(header)
+	first := headerHeight
+	if len(rows) > 0 {
+		first += rowHeight(rows[0])
+	}
+	d.Keep(first)
+	drawRow(Bold, header, true)
+	for _, row := range rows {
+		if h := rowHeight(row); d.y-h < Margin+footerSize*2 {
+			d.NewPage()
+			drawRow(Bold, header, true)
+		}
+		drawRow(Regular, row, false)
+	}
+}
+
+// SignatureLine draws a line to sign on with a caption under it, at x
+// points from the left edge
+func (d *Document) SignatureLine(x, width float64, caption string) {
+	d.line(x, d.y, x+width, d.y, 0.5)
+	d.text(Regular, 9, x, d.y-11, caption)
+}
+
+func (d *Document) text(font Font, size, x, y float64, s string) {
+	fmt.Fprintf(d.page(), "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font.resource(), size, x, y, escape(encode(s)))
+}
+
+func (d *Document) line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(d.page(), "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, y1, x2, y2)
+}
+
+// Wrap splits text into lines no wider than width, breaking at spaces and at
+// newlines. Words longer than a line are broken where they overflow.
+func Wrap(font Font, size, width float64, text string) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if TextWidth(font, size, candidate) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			for TextWidth(font, size, word) > width {
+				cut := fitRunes(font, size, width, word)
+				lines = append(lines, word[:cut])
+				word = word[cut:]
+			}
+			line = word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// fitRunes returns how many bytes of word fit in width, at least one rune
+func fitRunes(font Font, size, width float64, word string) int {
+	cut := 0
+	for i, r := range word {
+		if i > 0 && TextWidth(font, size, word[:i+len(string(r))]) > width {
+			break
+		}
+		cut = i + len(string(r))
+	}
+	return cut
+}
+
+// TextWidth measures text in points
+func TextWidth(font Font, size float64, text string) float64 {
+	widths := &helvetica
+	if font == Bold {
+		widths = &helveticaBold
+	}
+	total := 0
+	for _, b := range encode(text) {
+		if b >= 32 && b <= 126 {
+			total += widths[b-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// Write writes the document as PDF
+func (d *Document) Write(w io.Writer) error {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-4 are the catalog, page tree, fonts and information; each
+	// page then takes two objects, the page and its content
+	n := len(d.pages)
+	kids := make([]string, n)
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), n))
+	object("<< /F1 << /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >> " +
+		"/F2 << /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >> >>")
+	object(fmt.Sprintf("<< /Title (%s) /Producer (Staff Performance System) >>", escape(encode(d.title))))
+
+	for i, content := range d.pages {
+		var stream bytes.Buffer
+		stream.Write(content.Bytes())
+		footer := fmt.Sprintf("Page %d of %d", i+1, n)
+		fmt.Fprintf(&stream, "0.4 g BT /F1 %.1f Tf %.2f %.2f Td (%s) Tj ET\n", footerSize, Margin, Margin-footerSize, escape(encode(d.footer)))
+		fmt.Fprintf(&stream, "BT /F1 %.1f Tf %.2f %.2f Td (%s) Tj ET 0 g\n", footerSize, PageWidth-Margin-TextWidth(Regular, footerSize, footer), Margin-footerSize, footer)
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font 3 0 R >> /Contents %d 0 R >>",
+			PageWidth, PageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", stream.Len(), stream.Bytes()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 4 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// escape escapes a string for a PDF literal string
+func escape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`, "\r", `\r`, "\n", `\n`)
+	return r.Replace(s)
+}
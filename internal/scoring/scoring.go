@@ -0,0 +1,172 @@
+// Package scoring turns the progress recorded against an objective into a
+// single performance percentage. The formula is a Strategy chosen once per
+// organisation, so every page that shows a score computes it the same way.
+package scoring
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Strategy names accepted in the configuration
+const (
+	NameActivityMean     = "activity_mean"
+	NameTaskActivityMean = "task_activity_mean"
+	NameOutcomeWeighted  = "outcome_weighted"
+	NameManualRating     = "manual_rating"
+)
+
+// Default is the strategy used when none is configured
+const Default = NameTaskActivityMean
+
+// Objective is the progress recorded against one objective. Percentages are
+// 0-100; a completed task should be passed as 100 whatever its recorded
+// completion percentage.
+type Objective struct {
+	Outcomes     []Outcome
+	ManualRating *float64 // Rating entered by hand, nil when not rated
+}
+
+// Outcome is the progress recorded against one expected outcome
+type Outcome struct {
+	Activities []float64
+	Tasks      []float64
+}
+
+// Strategy computes an objective's performance percentage
+type Strategy interface {
+	Name() string
+	Score(obj Objective) float64
+}
+
+// ActivityMean is the mean progress of the objective's activities. Tasks are
+// not counted.
+type ActivityMean struct{}
+
+func (ActivityMean) Name() string { return NameActivityMean }
+
+func (ActivityMean) Score(obj Objective) float64 {
+	var values []float64
+	for _, o := range obj.Outcomes {
+		values = append(values, o.Activities...)
+	}
+	return mean(values)
+}
+
+// TaskActivityMean is the mean over every task and activity of the
+// objective, each counting equally
+type TaskActivityMean struct{}
+
+func (TaskActivityMean) Name() string { return NameTaskActivityMean }
+
+func (TaskActivityMean) Score(obj Objective) float64 {
+	var values []float64
+	for _, o := range obj.Outcomes {
+		values = append(values, o.Activities...)
+		values = append(values, o.Tasks...)
+	}
+	return mean(values)
+}
+
+// OutcomeWeighted scores each expected outcome as the mean of its tasks and
+// activities, then averages the outcomes, so an outcome with many small
+// items does not outweigh one with a few large ones. Outcomes with nothing
+// recorded against them are skipped.
+type OutcomeWeighted struct{}
+
+func (OutcomeWeighted) Name() string { return NameOutcomeWeighted }
+
+func (OutcomeWeighted) Score(obj Objective) float64 {
+	var scores []float64
+	for _, o := range obj.Outcomes {
+		items := append(append([]float64{}, o.Activities...), o.Tasks...)
+		if len(items) > 0 {
+			scores = append(scores, mean(items))
+		}
+	}
+	return mean(scores)
+}
+
+// ManualRating uses the rating entered on the objective. Objectives that have
+// not been rated yet are scored by Fallback, or 0 when it is nil.
+type ManualRating struct {
+	Fallback Strategy
+}
+
+func (ManualRating) Name() string { return NameManualRating }
+
+func (m ManualRating) Score(obj Objective) float64 {
+	if obj.ManualRating != nil {
+		return *obj.ManualRating
+	}
+	if m.Fallback == nil {
+		return 0
+	}
+	return m.Fallback.Score(obj)
+}
+
+// strategies maps configuration names to strategies
+var strategies = map[string]Strategy{
+	NameActivityMean:     ActivityMean{},
+	NameTaskActivityMean: TaskActivityMean{},
+	NameOutcomeWeighted:  OutcomeWeighted{},
+	NameManualRating:     ManualRating{Fallback: TaskActivityMean{}},
+}
+
+// Lookup returns the strategy registered under name
+func Lookup(name string) (Strategy, error) {
+	s, ok := strategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown scoring strategy %q (want one of %v)", name, Names())
+	}
+	return s, nil
+}
+
+// Names lists the registered strategy names in sorted order
+func Names() []string {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Weighted is a score together with the weight it carries in an overall score
+type Weighted struct {
+	Weight float64
+	Score  float64
+}
+
+// Overall is the weight-normalised mean of scores: the sum of weight × score
+// divided by the sum of weights. Items without weight do not count, unless
+// none has a weight, in which case the plain mean is used so unweighted
+// items still get a score.
+func Overall(items []Weighted) float64 {
+	if len(items) == 0 {
+		return 0
+	}
+
+	var weighted, totalWeight, sum float64
+	for _, it := range items {
+		weighted += it.Weight * it.Score
+		totalWeight += it.Weight
+		sum += it.Score
+	}
+
+	if totalWeight == 0 {
+		return sum / float64(len(items))
+	}
+	return weighted / totalWeight
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
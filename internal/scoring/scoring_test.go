@@ -0,0 +1,84 @@
+package scoring
+
+import (
+	"math"
+	"testing"
+)
+
+func rating(v float64) *float64 { return &v }
+
+// example is the objective from the README: two expected outcomes, the
+// first with activities at 75% and 90% and a task at 60%, the second with
+// one activity at 80%
+var example = Objective{Outcomes: []Outcome{
+	{Activities: []float64{75, 90}, Tasks: []float64{60}},
+	{Activities: []float64{80}},
+}}
+
+func TestStrategies(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy Strategy
+		obj      Objective
+		want     float64
+	}{
+		{"activity mean", ActivityMean{}, example, (75 + 90 + 80) / 3.0},
+		{"activity mean ignores tasks", ActivityMean{}, Objective{Outcomes: []Outcome{{Tasks: []float64{100}}}}, 0},
+		{"activity mean of nothing", ActivityMean{}, Objective{}, 0},
+
+		{"task activity mean", TaskActivityMean{}, example, (75 + 90 + 60 + 80) / 4.0},
+		{"task activity mean of tasks only", TaskActivityMean{}, Objective{Outcomes: []Outcome{{Tasks: []float64{100, 50}}}}, 75},
+		{"task activity mean of nothing", TaskActivityMean{}, Objective{}, 0},
+
+		{"outcome weighted", OutcomeWeighted{}, example, (75 + 80) / 2.0},
+		{"outcome weighted skips empty outcomes", OutcomeWeighted{}, Objective{Outcomes: []Outcome{{}, {Tasks: []float64{40}}}}, 40},
+		{"outcome weighted of nothing", OutcomeWeighted{}, Objective{}, 0},
+
+		{"manual rating", ManualRating{Fallback: TaskActivityMean{}}, Objective{Outcomes: example.Outcomes, ManualRating: rating(55)}, 55},
+		{"manual rating of 0", ManualRating{Fallback: TaskActivityMean{}}, Objective{Outcomes: example.Outcomes, ManualRating: rating(0)}, 0},
+		{"unrated uses fallback", ManualRating{Fallback: TaskActivityMean{}}, example, (75 + 90 + 60 + 80) / 4.0},
+		{"unrated uses other fallback", ManualRating{Fallback: ActivityMean{}}, example, (75 + 90 + 80) / 3.0},
+		{"unrated without fallback", ManualRating{}, example, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.strategy.Score(tt.obj); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Score() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOverall(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []Weighted
+		want  float64
+	}{
+		{"no objectives", nil, 0},
+		{"equal weights", []Weighted{{Weight: 50, Score: 80}, {Weight: 50, Score: 40}}, 60},
+		{"unequal weights", []Weighted{{Weight: 75, Score: 80}, {Weight: 25, Score: 40}}, 70},
+		{"weights not adding up to 100", []Weighted{{Weight: 30, Score: 90}, {Weight: 10, Score: 50}}, 80},
+		{"zero weight does not count", []Weighted{{Weight: 100, Score: 60}, {Weight: 0, Score: 0}}, 60},
+		{"all zero weights use the plain mean", []Weighted{{Score: 90}, {Score: 30}, {Score: 60}}, 60},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Overall(tt.items); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Overall() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	for _, name := range Names() {
+		s, err := Lookup(name)
+		if err != nil || s.Name() != name {
+			t.Errorf("Lookup(%q) = %v, %v", name, s, err)
+		}
+	}
+	if _, err := Lookup("median"); err == nil {
+		t.Error("Lookup of an unknown strategy succeeded")
+	}
+}
@@ -0,0 +1,147 @@
+package store
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AuditEntity is a kind of record whose changes are kept in the audit log
+type AuditEntity string
+
+const (
+	AuditObjective AuditEntity = "objective"
+	AuditOutcome   AuditEntity = "outcome"
+	AuditActivity  AuditEntity = "activity"
+	AuditTask      AuditEntity = "task"
+)
+
+// AuditAction is what happened to the record
+type AuditAction string
+
+const (
+	AuditCreate AuditAction = "create"
+	AuditUpdate AuditAction = "update"
+	AuditDelete AuditAction = "delete"
+)
+
+// AuditEvent is one create, update or delete of an audited record. OwnerID is
+// the staff member the record belongs to, kept so the history can still be
+// shown to the right people after the record is deleted.
+type AuditEvent struct {
+	ID        int
+	ActorID   *int // Nil for changes made by the system
+	Entity    AuditEntity
+	EntityID  int
+	OwnerID   int
+	Label     string // Title of the record at the time of the change
+	Action    AuditAction
+	CreatedAt time.Time
+	Changes   []AuditChange
+	ActorName string // For display purposes
+}
+
+// AuditChange is the before and after value of one field. Values are stored
+// as text; an empty string means the field was empty or unset.
+type AuditChange struct {
+	Field  string
+	Before string
+	After  string
+}
+
+// AuditFilter selects events on the audit log page. Zero values match everything.
+type AuditFilter struct {
+	Entity   AuditEntity
+	EntityID int
+	ActorID  int
+	OwnerID  int
+	From     time.Time
+	To       time.Time // Exclusive
+	Limit    int
+	Offset   int
+}
+
+// auditIgnoredFields are derived or bookkeeping fields that are not compared
+var auditIgnoredFields = map[string]bool{
+	"id":          true,
+	"created_at":  true,
+	"updated_at":  true,
+	"performance": true,
+	"locked":      true,
+}
+
+// diffFields compares two records of the same struct type and returns the
+// fields whose values differ, named by their JSON names. A nil before lists
+// every set field of after as created; a nil after lists every set field of
+// before as deleted.
+func diffFields(before, after interface{}) []AuditChange {
+	var b, a reflect.Value
+	if before != nil {
+		b = reflect.Indirect(reflect.ValueOf(before))
+	}
+	if after != nil {
+		a = reflect.Indirect(reflect.ValueOf(after))
+	}
+	var t reflect.Type
+	switch {
+	case a.IsValid():
+		t = a.Type()
+	case b.IsValid():
+		t = b.Type()
+	default:
+		return nil
+	}
+
+	var changes []AuditChange
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" || auditIgnoredFields[name] {
+			continue
+		}
+
+		var oldValue, newValue string
+		if b.IsValid() {
+			oldValue = auditValue(b.Field(i))
+		}
+		if a.IsValid() {
+			newValue = auditValue(a.Field(i))
+		}
+		if oldValue != newValue {
+			changes = append(changes, AuditChange{Field: name, Before: oldValue, After: newValue})
+		}
+	}
+	return changes
+}
+
+// auditValue formats a field value for the audit log
+func auditValue(v reflect.Value) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	if t, ok := v.Interface().(time.Time); ok {
+		switch {
+		case t.IsZero():
+			return ""
+		case t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0:
+			return t.Format("2006-01-02")
+		}
+		return t.Format("2006-01-02 15:04:05")
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	}
+	return ""
+}
@@ -0,0 +1,2621 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"staffperformance/internal/scoring"
+)
+
+// SQLStore is the Store on a SQL database. Its queries are written once, with
+// ? placeholders, for both SQLite and PostgreSQL; the dialects differ only in
+// how the connection is opened and in their schema migrations.
+type SQLStore struct {
+	db      *sql.DB
+	dialect Dialect
+	scoring scoring.Strategy // How objective performance is calculated
+}
+
+// sqliteOptions are applied to every SQLite connection. Request handlers, the
+// mailer and the scheduler write through the same pool, so a writer waits up
+// to five seconds for another to finish instead of failing with SQLITE_BUSY.
+// Transactions take the write lock when they begin, since a read lock cannot
+// wait to be upgraded, and WAL mode lets reads go on during a write.
+const sqliteOptions = "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+
+// OpenSQLiteStore opens the SQLite database at path without changing its
+// schema. Objectives read from it are scored with strategy.
+func OpenSQLiteStore(path string, strategy scoring.Strategy) (*SQLStore, error) {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	db, err := sql.Open("sqlite", path+sep+sqliteOptions)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLStore{db: db, dialect: DialectSQLite, scoring: strategy}, nil
+}
+
+// Init applies pending migrations
+func (s *SQLStore) Init() error {
+	if _, err := s.MigrateUp(); err != nil {
+		return err
+	}
+
+	log.Println("Database initialized successfully")
+	return nil
+}
+
+// Close closes the database
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLStore) GetUserByUsername(username string) (*User, error) {
+	user := &User{}
+	var supervisorID sql.NullInt64
+	var departmentID sql.NullInt64
+	var lockedUntil sql.NullTime
+	query := `SELECT u.id, u.username, u.password, u.full_name, u.email, u.role, u.supervisor_id, u.department_id, u.department, u.position, u.created_at, COALESCE(d.name, ''), u.must_change_password, u.locked_until FROM users u LEFT JOIN departments d ON u.department_id = d.id WHERE u.username = ?`
+	err := s.db.QueryRow(query, username).Scan(&user.ID, &user.Username, &user.Password, &user.FullName, &user.Email, &user.Role, &supervisorID, &departmentID, &user.Department, &user.Position, &user.CreatedAt, &user.DepartmentName, &user.MustChangePassword, &lockedUntil)
+	if err != nil {
+		return nil, err
+	}
+	if supervisorID.Valid {
+		supervisorIDInt := int(supervisorID.Int64)
+		user.SupervisorID = &supervisorIDInt
+	}
+	if departmentID.Valid {
+		deptIDInt := int(departmentID.Int64)
+		user.DepartmentID = &deptIDInt
+	}
+	if lockedUntil.Valid {
+		user.LockedUntil = &lockedUntil.Time
+	}
+	return user, nil
+}
+
+func (s *SQLStore) GetUserByID(id int) (*User, error) {
+	user := &User{}
+	var supervisorID sql.NullInt64
+	var departmentID sql.NullInt64
+	var lockedUntil sql.NullTime
+	query := `SELECT u.id, u.username, u.password, u.full_name, u.email, u.role, u.supervisor_id, u.department_id, u.department, u.position, u.created_at, COALESCE(d.name, ''), u.must_change_password, u.locked_until FROM users u LEFT JOIN departments d ON u.department_id = d.id WHERE u.id = ?`
+	err := s.db.QueryRow(query, id).Scan(&user.ID, &user.Username, &user.Password, &user.FullName, &user.Email, &user.Role, &supervisorID, &departmentID, &user.Department, &user.Position, &user.CreatedAt, &user.DepartmentName, &user.MustChangePassword, &lockedUntil)
+	if err != nil {
+		return nil, err
+	}
+	if supervisorID.Valid {
+		supervisorIDInt := int(supervisorID.Int64)
+		user.SupervisorID = &supervisorIDInt
+	}
+	if departmentID.Valid {
+		deptIDInt := int(departmentID.Int64)
+		user.DepartmentID = &deptIDInt
+	}
+	if lockedUntil.Valid {
+		user.LockedUntil = &lockedUntil.Time
+	}
+	return user, nil
+}
+
+// Staff management functions
+
+// CreateUser inserts a new user. The password on user must already be hashed.
+func (s *SQLStore) CreateUser(user *User) error {
+	query := `INSERT INTO users (username, password, full_name, email, role, supervisor_id, department_id, department, position, must_change_password) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`
+	return s.db.QueryRow(query, user.Username, user.Password, user.FullName, user.Email, user.Role, user.SupervisorID, user.DepartmentID, user.Department, user.Position, user.MustChangePassword).Scan(&user.ID)
+}
+
+// UpdateUser saves a user's details, and their password when user holds a
+// new, already hashed one
+func (s *SQLStore) UpdateUser(user *User) error {
+	// If password is empty, don't update it
+	if user.Password == "" {
+		query := `UPDATE users SET username = ?, role = ?, supervisor_id = ?, department_id = ?, department = ?, position = ? WHERE id = ?`
+		_, err := s.db.Exec(query, user.Username, user.Role, user.SupervisorID, user.DepartmentID, user.Department, user.Position, user.ID)
+		return err
+	}
+
+	query := `UPDATE users SET username = ?, password = ?, role = ?, supervisor_id = ?, department_id = ?, department = ?, position = ? WHERE id = ?`
+	_, err := s.db.Exec(query, user.Username, user.Password, user.Role, user.SupervisorID, user.DepartmentID, user.Department, user.Position, user.ID)
+	return err
+}
+
+// UpdateUserPassword stores an already hashed password and sets whether the
+// user must choose a new one at their next login
+func (s *SQLStore) UpdateUserPassword(userID int, hash string, mustChange bool) error {
+	query := `UPDATE users SET password = ?, must_change_password = ? WHERE id = ?`
+	_, err := s.db.Exec(query, hash, mustChange, userID)
+	return err
+}
+
+func (s *SQLStore) DeleteUser(id int) error {
+	query := `DELETE FROM users WHERE id = ?`
+	_, err := s.db.Exec(query, id)
+	return err
+}
+
+func (s *SQLStore) GetAllUsers() ([]User, error) {
+	query := `SELECT u.id, u.username, u.password, u.full_name, u.email, u.role, u.supervisor_id, u.department_id, u.department, u.position, u.created_at, COALESCE(d.name, ''), u.must_change_password, u.locked_until FROM users u LEFT JOIN departments d ON u.department_id = d.id ORDER BY u.full_name ASC`
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var user User
+		var supervisorID sql.NullInt64
+		var departmentID sql.NullInt64
+		var lockedUntil sql.NullTime
+		err := rows.Scan(&user.ID, &user.Username, &user.Password, &user.FullName, &user.Email, &user.Role, &supervisorID, &departmentID, &user.Department, &user.Position, &user.CreatedAt, &user.DepartmentName, &user.MustChangePassword, &lockedUntil)
+		if err != nil {
+			return nil, err
+		}
+		if supervisorID.Valid {
+			supervisorIDInt := int(supervisorID.Int64)
+			user.SupervisorID = &supervisorIDInt
+		}
+		if departmentID.Valid {
+			deptIDInt := int(departmentID.Int64)
+			user.DepartmentID = &deptIDInt
+		}
+		if lockedUntil.Valid {
+			user.LockedUntil = &lockedUntil.Time
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (s *SQLStore) GetUsersByRole(role UserRole) ([]User, error) {
+	query := `SELECT u.id, u.username, u.password, u.full_name, u.email, u.role, u.supervisor_id, u.department_id, u.department, u.position, u.created_at, COALESCE(d.name, ''), u.must_change_password, u.locked_until FROM users u LEFT JOIN departments d ON u.department_id = d.id WHERE u.role = ? ORDER BY u.full_name ASC`
+	rows, err := s.db.Query(query, role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var user User
+		var supervisorID sql.NullInt64
+		var departmentID sql.NullInt64
+		var lockedUntil sql.NullTime
+		err := rows.Scan(&user.ID, &user.Username, &user.Password, &user.FullName, &user.Email, &user.Role, &supervisorID, &departmentID, &user.Department, &user.Position, &user.CreatedAt, &user.DepartmentName, &user.MustChangePassword, &lockedUntil)
+		if err != nil {
+			return nil, err
+		}
+		if supervisorID.Valid {
+			supervisorIDInt := int(supervisorID.Int64)
+			user.SupervisorID = &supervisorIDInt
+		}
+		if departmentID.Valid {
+			deptIDInt := int(departmentID.Int64)
+			user.DepartmentID = &deptIDInt
+		}
+		if lockedUntil.Valid {
+			user.LockedUntil = &lockedUntil.Time
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (s *SQLStore) GetStaffBySupervisor(supervisorID int) ([]User, error) {
+	query := `SELECT u.id, u.username, u.password, u.full_name, u.email, u.role, u.supervisor_id, u.department_id, u.department, u.position, u.created_at, COALESCE(d.name, ''), u.must_change_password, u.locked_until FROM users u LEFT JOIN departments d ON u.department_id = d.id WHERE u.supervisor_id = ? ORDER BY u.full_name ASC`
+	rows, err := s.db.Query(query, supervisorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var user User
+		var supID sql.NullInt64
+		var departmentID sql.NullInt64
+		var lockedUntil sql.NullTime
+		err := rows.Scan(&user.ID, &user.Username, &user.Password, &user.FullName, &user.Email, &user.Role, &supID, &departmentID, &user.Department, &user.Position, &user.CreatedAt, &user.DepartmentName, &user.MustChangePassword, &lockedUntil)
+		if err != nil {
+			return nil, err
+		}
+		if supID.Valid {
+			supervisorIDInt := int(supID.Int64)
+			user.SupervisorID = &supervisorIDInt
+		}
+		if departmentID.Valid {
+			deptIDInt := int(departmentID.Int64)
+			user.DepartmentID = &deptIDInt
+		}
+		if lockedUntil.Valid {
+			user.LockedUntil = &lockedUntil.Time
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// Objective CRUD operations
+func (s *SQLStore) CreateObjective(obj *Objective, actorID int) error {
+	return s.runInTx(func(tx *sql.Tx) error {
+		query := `INSERT INTO objectives (user_id, title, description, start_date, end_date, visibility, status, category, category_other, weight, project_id, review_cycle_id, manual_rating) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`
+		if err := tx.QueryRow(query, obj.UserID, obj.Title, obj.Description, obj.StartDate, obj.EndDate, obj.Visibility, obj.Status, obj.Category, obj.CategoryOther, obj.Weight, obj.ProjectID, obj.ReviewCycleID, obj.ManualRating).Scan(&obj.ID); err != nil {
+			return err
+		}
+		return recordAudit(tx, actorID, AuditObjective, obj.ID, obj.UserID, obj.Title, nil, obj)
+	})
+}
+
+// objectiveColumns selects an objective with the state of its review cycle.
+// Queries using it must join review_cycles as rc.
+const objectiveColumns = `o.id, o.user_id, o.title, o.description, o.start_date, o.end_date, o.visibility, o.status, o.category, o.category_other, o.weight, o.project_id, o.created_at, o.manual_rating,
+	o.review_cycle_id, COALESCE(rc.name, ''), COALESCE(rc.status = 'Closed', FALSE)`
+
+func (s *SQLStore) GetObjectivesByUserID(userID int) ([]Objective, error) {
+	return s.queryUserObjectives(`WHERE o.user_id = ?`, userID)
+}
+
+// GetObjectivesByUserIDInCycle returns a user's objectives in one review cycle
+func (s *SQLStore) GetObjectivesByUserIDInCycle(userID, cycleID int) ([]Objective, error) {
+	return s.queryUserObjectives(`WHERE o.user_id = ? AND o.review_cycle_id = ?`, userID, cycleID)
+}
+
+// queryUserObjectives reads the objectives matching where and scores them
+// from their activities and tasks, read in two more queries
+func (s *SQLStore) queryUserObjectives(where string, args ...interface{}) ([]Objective, error) {
+	objectives, err := s.queryObjectives(where, args...)
+	if err != nil || len(objectives) == 0 {
+		return objectives, err
+	}
+	data, err := s.queryObjectiveData(where, args...)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.scoreObjectives(data, objectives); err != nil {
+		return nil, err
+	}
+	return objectives, nil
+}
+
+func (s *SQLStore) GetObjectiveByID(id int) (*Objective, error) {
+	obj := &Objective{}
+	var categoryOther sql.NullString
+	var projectID, cycleID sql.NullInt64
+	var manualRating sql.NullFloat64
+	query := `SELECT ` + objectiveColumns + ` FROM objectives o LEFT JOIN review_cycles rc ON o.review_cycle_id = rc.id WHERE o.id = ?`
+	err := s.db.QueryRow(query, id).Scan(&obj.ID, &obj.UserID, &obj.Title, &obj.Description, &obj.StartDate, &obj.EndDate, &obj.Visibility, &obj.Status, &obj.Category, &categoryOther, &obj.Weight, &projectID, &obj.CreatedAt, &manualRating, &cycleID, &obj.CycleName, &obj.Locked)
+	if err != nil {
+		return nil, err
+	}
+	if categoryOther.Valid {
+		obj.CategoryOther = categoryOther.String
+	}
+	if projectID.Valid {
+		pid := int(projectID.Int64)
+		obj.ProjectID = &pid
+	}
+	if cycleID.Valid {
+		cid := int(cycleID.Int64)
+		obj.ReviewCycleID = &cid
+	}
+	if manualRating.Valid {
+		obj.ManualRating = &manualRating.Float64
+	}
+	obj.Performance, err = s.objectivePerformance(s, obj)
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (s *SQLStore) UpdateObjective(obj *Objective, actorID int) error {
+	before, err := s.GetObjectiveByID(obj.ID)
+	if err != nil {
+		return err
+	}
+	return s.runInTx(func(tx *sql.Tx) error {
+		query := `UPDATE objectives SET title = ?, description = ?, start_date = ?, end_date = ?, visibility = ?, status = ?, category = ?, category_other = ?, weight = ?, project_id = ?, review_cycle_id = ?, manual_rating = ? WHERE id = ?`
+		_, err := tx.Exec(query, obj.Title, obj.Description, obj.StartDate, obj.EndDate, obj.Visibility, obj.Status, obj.Category, obj.CategoryOther, obj.Weight, obj.ProjectID, obj.ReviewCycleID, obj.ManualRating, obj.ID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, actorID, AuditObjective, obj.ID, obj.UserID, obj.Title, before, obj)
+	})
+}
+
+func (s *SQLStore) DeleteObjective(id int, actorID int) error {
+	before, err := s.GetObjectiveByID(id)
+	if err != nil {
+		return err
+	}
+	return s.runInTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM objectives WHERE id = ?`, id); err != nil {
+			return err
+		}
+		return recordAudit(tx, actorID, AuditObjective, id, before.UserID, before.Title, before, nil)
+	})
+}
+
+// ExpectedOutcome CRUD operations
+func (s *SQLStore) CreateExpectedOutcome(outcome *ExpectedOutcome, actorID int) error {
+	return s.runInTx(func(tx *sql.Tx) error {
+		query := `INSERT INTO expected_outcomes (objective_id, title, description) VALUES (?, ?, ?) RETURNING id`
+		if err := tx.QueryRow(query, outcome.ObjectiveID, outcome.Title, outcome.Description).Scan(&outcome.ID); err != nil {
+			return err
+		}
+		ownerID, err := auditOwner(tx, AuditOutcome, outcome.ID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, actorID, AuditOutcome, outcome.ID, ownerID, outcome.Title, nil, outcome)
+	})
+}
+
+func (s *SQLStore) GetExpectedOutcomesByObjectiveID(objectiveID int) ([]ExpectedOutcome, error) {
+	query := `SELECT id, objective_id, title, description, created_at FROM expected_outcomes WHERE objective_id = ? ORDER BY created_at ASC`
+	rows, err := s.db.Query(query, objectiveID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var outcomes []ExpectedOutcome
+	for rows.Next() {
+		var outcome ExpectedOutcome
+		err := rows.Scan(&outcome.ID, &outcome.ObjectiveID, &outcome.Title, &outcome.Description, &outcome.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		outcomes = append(outcomes, outcome)
+	}
+	return outcomes, nil
+}
+
+func (s *SQLStore) GetExpectedOutcomeByID(id int) (*ExpectedOutcome, error) {
+	outcome := &ExpectedOutcome{}
+	query := `SELECT id, objective_id, title, description, created_at FROM expected_outcomes WHERE id = ?`
+	err := s.db.QueryRow(query, id).Scan(&outcome.ID, &outcome.ObjectiveID, &outcome.Title, &outcome.Description, &outcome.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return outcome, nil
+}
+
+func (s *SQLStore) UpdateExpectedOutcome(outcome *ExpectedOutcome, actorID int) error {
+	before, err := s.GetExpectedOutcomeByID(outcome.ID)
+	if err != nil {
+		return err
+	}
+	return s.runInTx(func(tx *sql.Tx) error {
+		query := `UPDATE expected_outcomes SET title = ?, description = ? WHERE id = ?`
+		if _, err := tx.Exec(query, outcome.Title, outcome.Description, outcome.ID); err != nil {
+			return err
+		}
+		ownerID, err := auditOwner(tx, AuditOutcome, outcome.ID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, actorID, AuditOutcome, outcome.ID, ownerID, outcome.Title, before, outcome)
+	})
+}
+
+func (s *SQLStore) DeleteExpectedOutcome(id int, actorID int) error {
+	before, err := s.GetExpectedOutcomeByID(id)
+	if err != nil {
+		return err
+	}
+	return s.runInTx(func(tx *sql.Tx) error {
+		ownerID, err := auditOwner(tx, AuditOutcome, id)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM expected_outcomes WHERE id = ?`, id); err != nil {
+			return err
+		}
+		return recordAudit(tx, actorID, AuditOutcome, id, ownerID, before.Title, before, nil)
+	})
+}
+
+// Activity CRUD operations
+func (s *SQLStore) CreateActivity(activity *Activity, actorID int) error {
+	return s.runInTx(func(tx *sql.Tx) error {
+		query := `INSERT INTO activities (expected_outcome_id, title, description, category, progress_percentage, implementation_level) VALUES (?, ?, ?, ?, ?, ?) RETURNING id`
+		if err := tx.QueryRow(query, activity.ExpectedOutcomeID, activity.Title, activity.Description, activity.Category, activity.ProgressPercentage, activity.ImplementationLevel).Scan(&activity.ID); err != nil {
+			return err
+		}
+		ownerID, err := auditOwner(tx, AuditActivity, activity.ID)
+		if err != nil {
+			return err
+		}
+		if err := recordProgress(tx, AuditActivity, activity.ID, ownerID, activity.ProgressPercentage); err != nil {
+			return err
+		}
+		return recordAudit(tx, actorID, AuditActivity, activity.ID, ownerID, activity.Title, nil, activity)
+	})
+}
+
+func (s *SQLStore) GetActivitiesByExpectedOutcomeID(outcomeID int) ([]Activity, error) {
+	query := `SELECT id, expected_outcome_id, title, description, category, progress_percentage, implementation_level, created_at, updated_at FROM activities WHERE expected_outcome_id = ? ORDER BY created_at ASC`
+	rows, err := s.db.Query(query, outcomeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var activities []Activity
+	for rows.Next() {
+		var activity Activity
+		err := rows.Scan(&activity.ID, &activity.ExpectedOutcomeID, &activity.Title, &activity.Description, &activity.Category, &activity.ProgressPercentage, &activity.ImplementationLevel, &activity.CreatedAt, &activity.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		activities = append(activities, activity)
+	}
+	return activities, nil
+}
+
+func (s *SQLStore) GetActivityByID(id int) (*Activity, error) {
+	activity := &Activity{}
+	query := `SELECT id, expected_outcome_id, title, description, category, progress_percentage, implementation_level, created_at, updated_at FROM activities WHERE id = ?`
+	err := s.db.QueryRow(query, id).Scan(&activity.ID, &activity.ExpectedOutcomeID, &activity.Title, &activity.Description, &activity.Category, &activity.ProgressPercentage, &activity.ImplementationLevel, &activity.CreatedAt, &activity.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
+
+func (s *SQLStore) UpdateActivity(activity *Activity, actorID int) error {
+	before, err := s.GetActivityByID(activity.ID)
+	if err != nil {
+		return err
+	}
+	return s.runInTx(func(tx *sql.Tx) error {
+		query := `UPDATE activities SET title = ?, description = ?, category = ?, progress_percentage = ?, implementation_level = ?, updated_at = ? WHERE id = ?`
+		_, err := tx.Exec(query, activity.Title, activity.Description, activity.Category, activity.ProgressPercentage, activity.ImplementationLevel, time.Now(), activity.ID)
+		if err != nil {
+			return err
+		}
+		ownerID, err := auditOwner(tx, AuditActivity, activity.ID)
+		if err != nil {
+			return err
+		}
+		if activity.ProgressPercentage != before.ProgressPercentage {
+			if err := recordProgress(tx, AuditActivity, activity.ID, ownerID, activity.ProgressPercentage); err != nil {
+				return err
+			}
+		}
+		return recordAudit(tx, actorID, AuditActivity, activity.ID, ownerID, activity.Title, before, activity)
+	})
+}
+
+func (s *SQLStore) DeleteActivity(id int, actorID int) error {
+	before, err := s.GetActivityByID(id)
+	if err != nil {
+		return err
+	}
+	return s.runInTx(func(tx *sql.Tx) error {
+		ownerID, err := auditOwner(tx, AuditActivity, id)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM activities WHERE id = ?`, id); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM recurring_tasks WHERE activity_id = ?`, id); err != nil {
+			return err
+		}
+		return recordAudit(tx, actorID, AuditActivity, id, ownerID, before.Title, before, nil)
+	})
+}
+
+// Get activities by objective ID (for performance calculation)
+func (s *SQLStore) GetActivitiesByObjectiveID(objectiveID int) ([]Activity, error) {
+	query := `
+		SELECT a.id, a.expected_outcome_id, a.title, a.description, a.category, a.progress_percentage, a.implementation_level, a.created_at, a.updated_at
+		FROM activities a
+		INNER JOIN expected_outcomes eo ON a.expected_outcome_id = eo.id
+		WHERE eo.objective_id = ?
+		ORDER BY a.created_at ASC
+	`
+	rows, err := s.db.Query(query, objectiveID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var activities []Activity
+	for rows.Next() {
+		var activity Activity
+		err := rows.Scan(&activity.ID, &activity.ExpectedOutcomeID, &activity.Title, &activity.Description, &activity.Category, &activity.ProgressPercentage, &activity.ImplementationLevel, &activity.CreatedAt, &activity.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		activities = append(activities, activity)
+	}
+	return activities, nil
+}
+
+// Task CRUD operations
+func (s *SQLStore) CreateTask(task *Task, actorID int) error {
+	return s.runInTx(func(tx *sql.Tx) error {
+		query := `INSERT INTO tasks (expected_outcome_id, user_id, title, description, priority, status, due_date, assigned_to_id, task_type, requested_by, completion_percentage, completed_at, project_id, assignment_status, assignment_note) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`
+		if err := tx.QueryRow(query, task.ExpectedOutcomeID, task.UserID, task.Title, task.Description, task.Priority, task.Status, task.DueDate, task.AssignedToID, task.TaskType, task.RequestedBy, task.CompletionPercentage, task.CompletedAt, task.ProjectID, task.AssignmentStatus, task.AssignmentNote).Scan(&task.ID); err != nil {
+			return err
+		}
+		if err := recordProgress(tx, AuditTask, task.ID, task.WorkerID(), TaskProgress(*task)); err != nil {
+			return err
+		}
+		return recordAudit(tx, actorID, AuditTask, task.ID, task.UserID, task.Title, nil, task)
+	})
+}
+
+func (s *SQLStore) GetTasksByUserID(userID int) ([]Task, error) {
+	query := `SELECT id, expected_outcome_id, user_id, title, description, priority, status, due_date, created_at, completed_at, assigned_to_id, task_type, requested_by, completion_percentage, project_id, assignment_status, assignment_note FROM tasks WHERE user_id = ? ORDER BY due_date ASC, created_at DESC`
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []Task
+	for rows.Next() {
+		var task Task
+		var completedAt sql.NullTime
+		var assignedToID sql.NullInt64
+		var expectedOutcomeID sql.NullInt64
+		var projectID sql.NullInt64
+		err := rows.Scan(&task.ID, &expectedOutcomeID, &task.UserID, &task.Title, &task.Description, &task.Priority, &task.Status, &task.DueDate, &task.CreatedAt, &completedAt, &assignedToID, &task.TaskType, &task.RequestedBy, &task.CompletionPercentage, &projectID, &task.AssignmentStatus, &task.AssignmentNote)
+		if err != nil {
+			return nil, err
+		}
+		if completedAt.Valid {
+			task.CompletedAt = &completedAt.Time
+		}
+		if assignedToID.Valid {
+			assignedID := int(assignedToID.Int64)
+			task.AssignedToID = &assignedID
+		}
+		if expectedOutcomeID.Valid {
+			outcomeID := int(expectedOutcomeID.Int64)
+			task.ExpectedOutcomeID = &outcomeID
+		}
+		if projectID.Valid {
+			pid := int(projectID.Int64)
+			task.ProjectID = &pid
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// GetTasksByUserIDs returns the tasks of several users by user ID, in one
+// query. Users without tasks are left out of the map.
+func (s *SQLStore) GetTasksByUserIDs(userIDs []int) (map[int][]Task, error) {
+	tasks := make(map[int][]Task)
+	if len(userIDs) == 0 {
+		return tasks, nil
+	}
+
+	query := `SELECT id, expected_outcome_id, user_id, title, description, priority, status, due_date, created_at, completed_at, assigned_to_id, task_type, requested_by, completion_percentage, project_id, assignment_status, assignment_note FROM tasks WHERE user_id IN (` + placeholders(len(userIDs)) + `) ORDER BY due_date ASC, created_at DESC`
+	args := make([]interface{}, len(userIDs))
+	for i, id := range userIDs {
+		args[i] = id
+	}
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var task Task
+		var completedAt sql.NullTime
+		var assignedToID sql.NullInt64
+		var expectedOutcomeID sql.NullInt64
+		var projectID sql.NullInt64
+		err := rows.Scan(&task.ID, &expectedOutcomeID, &task.UserID, &task.Title, &task.Description, &task.Priority, &task.Status, &task.DueDate, &task.CreatedAt, &completedAt, &assignedToID, &task.TaskType, &task.RequestedBy, &task.CompletionPercentage, &projectID, &task.AssignmentStatus, &task.AssignmentNote)
+		if err != nil {
+			return nil, err
+		}
+		if completedAt.Valid {
+			task.CompletedAt = &completedAt.Time
+		}
+		if assignedToID.Valid {
+			assignedID := int(assignedToID.Int64)
+			task.AssignedToID = &assignedID
+		}
+		if expectedOutcomeID.Valid {
+			outcomeID := int(expectedOutcomeID.Int64)
+			task.ExpectedOutcomeID = &outcomeID
+		}
+		if projectID.Valid {
+			pid := int(projectID.Int64)
+			task.ProjectID = &pid
+		}
+		tasks[task.UserID] = append(tasks[task.UserID], task)
+	}
+	return tasks, rows.Err()
+}
+
+func (s *SQLStore) GetTaskByID(id int) (*Task, error) {
+	task := &Task{}
+	var completedAt sql.NullTime
+	var assignedToID sql.NullInt64
+	var expectedOutcomeID sql.NullInt64
+	var projectID sql.NullInt64
+	query := `SELECT id, expected_outcome_id, user_id, title, description, priority, status, due_date, created_at, completed_at, assigned_to_id, task_type, requested_by, completion_percentage, project_id, assignment_status, assignment_note FROM tasks WHERE id = ?`
+	err := s.db.QueryRow(query, id).Scan(&task.ID, &expectedOutcomeID, &task.UserID, &task.Title, &task.Description, &task.Priority, &task.Status, &task.DueDate, &task.CreatedAt, &completedAt, &assignedToID, &task.TaskType, &task.RequestedBy, &task.CompletionPercentage, &projectID, &task.AssignmentStatus, &task.AssignmentNote)
+	if err != nil {
+		return nil, err
+	}
+	if completedAt.Valid {
+		task.CompletedAt = &completedAt.Time
+	}
+	if assignedToID.Valid {
+		assignedID := int(assignedToID.Int64)
+		task.AssignedToID = &assignedID
+	}
+	if expectedOutcomeID.Valid {
+		outcomeID := int(expectedOutcomeID.Int64)
+		task.ExpectedOutcomeID = &outcomeID
+	}
+	if projectID.Valid {
+		pid := int(projectID.Int64)
+		task.ProjectID = &pid
+	}
+	return task, nil
+}
+
+func (s *SQLStore) UpdateTask(task *Task, actorID int) error {
+	before, err := s.GetTaskByID(task.ID)
+	if err != nil {
+		return err
+	}
+	return s.runInTx(func(tx *sql.Tx) error {
+		query := `UPDATE tasks SET expected_outcome_id = ?, title = ?, description = ?, priority = ?, status = ?, due_date = ?, completed_at = ?, assigned_to_id = ?, task_type = ?, requested_by = ?, completion_percentage = ?, project_id = ?, assignment_status = ?, assignment_note = ? WHERE id = ?`
+		_, err := tx.Exec(query, task.ExpectedOutcomeID, task.Title, task.Description, task.Priority, task.Status, task.DueDate, task.CompletedAt, task.AssignedToID, task.TaskType, task.RequestedBy, task.CompletionPercentage, task.ProjectID, task.AssignmentStatus, task.AssignmentNote, task.ID)
+		if err != nil {
+			return err
+		}
+		if progress := TaskProgress(*task); progress != TaskProgress(*before) {
+			if err := recordProgress(tx, AuditTask, task.ID, task.WorkerID(), progress); err != nil {
+				return err
+			}
+		}
+		return recordAudit(tx, actorID, AuditTask, task.ID, task.UserID, task.Title, before, task)
+	})
+}
+
+func (s *SQLStore) DeleteTask(id int, actorID int) error {
+	before, err := s.GetTaskByID(id)
+	if err != nil {
+		return err
+	}
+	return s.runInTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM tasks WHERE id = ?`, id); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE recurring_tasks SET task_id = NULL WHERE task_id = ?`, id); err != nil {
+			return err
+		}
+		return recordAudit(tx, actorID, AuditTask, id, before.UserID, before.Title, before, nil)
+	})
+}
+
+func (s *SQLStore) GetTaskCountsByStatus(userID int) (completed, pending int, err error) {
+	query := `SELECT 
+		SUM(CASE WHEN status = 'Completed' THEN 1 ELSE 0 END) as completed,
+		SUM(CASE WHEN status != 'Completed' THEN 1 ELSE 0 END) as pending
+		FROM tasks WHERE user_id = ?`
+	err = s.db.QueryRow(query, userID).Scan(&completed, &pending)
+	return
+}
+
+// Get tasks assigned to a specific user
+func (s *SQLStore) GetTasksAssignedToUser(userID int) ([]Task, error) {
+	query := `SELECT id, expected_outcome_id, user_id, title, description, priority, status, due_date, created_at, completed_at, assigned_to_id, task_type, requested_by, completion_percentage, project_id, assignment_status, assignment_note FROM tasks WHERE assigned_to_id = ? ORDER BY due_date ASC, created_at DESC`
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []Task
+	for rows.Next() {
+		var task Task
+		var completedAt sql.NullTime
+		var assignedToID sql.NullInt64
+		var expectedOutcomeID sql.NullInt64
+		var projectID sql.NullInt64
+		err := rows.Scan(&task.ID, &expectedOutcomeID, &task.UserID, &task.Title, &task.Description, &task.Priority, &task.Status, &task.DueDate, &task.CreatedAt, &completedAt, &assignedToID, &task.TaskType, &task.RequestedBy, &task.CompletionPercentage, &projectID, &task.AssignmentStatus, &task.AssignmentNote)
+		if err != nil {
+			return nil, err
+		}
+		if completedAt.Valid {
+			task.CompletedAt = &completedAt.Time
+		}
+		if assignedToID.Valid {
+			assignedID := int(assignedToID.Int64)
+			task.AssignedToID = &assignedID
+		}
+		if expectedOutcomeID.Valid {
+			outcomeID := int(expectedOutcomeID.Int64)
+			task.ExpectedOutcomeID = &outcomeID
+		}
+		if projectID.Valid {
+			pid := int(projectID.Int64)
+			task.ProjectID = &pid
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// Get all tasks (created by user OR assigned to user)
+func (s *SQLStore) GetAllUserTasks(userID int) ([]Task, error) {
+	query := `SELECT id, expected_outcome_id, user_id, title, description, priority, status, due_date, created_at, completed_at, assigned_to_id, task_type, requested_by, completion_percentage, project_id, assignment_status, assignment_note FROM tasks WHERE user_id = ? OR assigned_to_id = ? ORDER BY due_date ASC, created_at DESC`
+	rows, err := s.db.Query(query, userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []Task
+	for rows.Next() {
+		var task Task
+		var completedAt sql.NullTime
+		var assignedToID sql.NullInt64
+		var expectedOutcomeID sql.NullInt64
+		var projectID sql.NullInt64
+		err := rows.Scan(&task.ID, &expectedOutcomeID, &task.UserID, &task.Title, &task.Description, &task.Priority, &task.Status, &task.DueDate, &task.CreatedAt, &completedAt, &assignedToID, &task.TaskType, &task.RequestedBy, &task.CompletionPercentage, &projectID, &task.AssignmentStatus, &task.AssignmentNote)
+		if err != nil {
+			return nil, err
+		}
+		if completedAt.Valid {
+			task.CompletedAt = &completedAt.Time
+		}
+		if assignedToID.Valid {
+			assignedID := int(assignedToID.Int64)
+			task.AssignedToID = &assignedID
+		}
+		if expectedOutcomeID.Valid {
+			outcomeID := int(expectedOutcomeID.Int64)
+			task.ExpectedOutcomeID = &outcomeID
+		}
+		if projectID.Valid {
+			pid := int(projectID.Int64)
+			task.ProjectID = &pid
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// Comment CRUD operations
+func (s *SQLStore) CreateComment(comment *Comment) error {
+	query := `INSERT INTO comments (objective_id, activity_id, user_id, comment_text) VALUES (?, ?, ?, ?) RETURNING id`
+	return s.db.QueryRow(query, comment.ObjectiveID, comment.ActivityID, comment.UserID, comment.CommentText).Scan(&comment.ID)
+}
+
+func (s *SQLStore) GetCommentsByObjective(objectiveID int) ([]Comment, error) {
+	query := `
+		SELECT c.id, c.objective_id, c.activity_id, c.user_id, c.comment_text, c.created_at,
+		       u.username, u.role
+		FROM comments c
+		INNER JOIN users u ON c.user_id = u.id
+		WHERE c.objective_id = ? AND c.activity_id IS NULL
+		ORDER BY c.created_at DESC
+	`
+	rows, err := s.db.Query(query, objectiveID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []Comment
+	for rows.Next() {
+		var comment Comment
+		var activityID sql.NullInt64
+		err := rows.Scan(&comment.ID, &comment.ObjectiveID, &activityID, &comment.UserID, &comment.CommentText, &comment.CreatedAt, &comment.Username, &comment.UserRole)
+		if err != nil {
+			return nil, err
+		}
+		if activityID.Valid {
+			actID := int(activityID.Int64)
+			comment.ActivityID = &actID
+		}
+		comments = append(comments, comment)
+	}
+	return comments, nil
+}
+
+// GetObjectiveCommentsByUserID returns the comments on a user's objectives,
+// leaving out those on activities, by objective ID in one query
+func (s *SQLStore) GetObjectiveCommentsByUserID(userID int) (map[int][]Comment, error) {
+	query := `
+		SELECT c.id, c.objective_id, c.activity_id, c.user_id, c.comment_text, c.created_at,
+		       u.username, u.role
+		FROM comments c
+		INNER JOIN users u ON c.user_id = u.id
+		INNER JOIN objectives o ON c.objective_id = o.id
+		WHERE o.user_id = ? AND c.activity_id IS NULL
+		ORDER BY c.created_at DESC
+	`
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := make(map[int][]Comment)
+	for rows.Next() {
+		var comment Comment
+		err := rows.Scan(&comment.ID, &comment.ObjectiveID, &comment.ActivityID, &comment.UserID, &comment.CommentText, &comment.CreatedAt, &comment.Username, &comment.UserRole)
+		if err != nil {
+			return nil, err
+		}
+		comments[*comment.ObjectiveID] = append(comments[*comment.ObjectiveID], comment)
+	}
+	return comments, rows.Err()
+}
+
+func (s *SQLStore) GetCommentsByActivity(activityID int) ([]Comment, error) {
+	query := `
+		SELECT c.id, c.objective_id, c.activity_id, c.user_id, c.comment_text, c.created_at,
+		       u.username, u.role
+		FROM comments c
+		INNER JOIN users u ON c.user_id = u.id
+		WHERE c.activity_id = ?
+		ORDER BY c.created_at DESC
+	`
+	rows, err := s.db.Query(query, activityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []Comment
+	for rows.Next() {
+		var comment Comment
+		var activityID sql.NullInt64
+		err := rows.Scan(&comment.ID, &comment.ObjectiveID, &activityID, &comment.UserID, &comment.CommentText, &comment.CreatedAt, &comment.Username, &comment.UserRole)
+		if err != nil {
+			return nil, err
+		}
+		if activityID.Valid {
+			actID := int(activityID.Int64)
+			comment.ActivityID = &actID
+		}
+		comments = append(comments, comment)
+	}
+	return comments, nil
+}
+
+func (s *SQLStore) GetCommentByID(id int) (*Comment, error) {
+	query := `SELECT id, objective_id, activity_id, user_id, comment_text, created_at FROM comments WHERE id = ?`
+	var comment Comment
+	var objectiveID, activityID sql.NullInt64
+	err := s.db.QueryRow(query, id).Scan(&comment.ID, &objectiveID, &activityID, &comment.UserID, &comment.CommentText, &comment.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if objectiveID.Valid {
+		objID := int(objectiveID.Int64)
+		comment.ObjectiveID = &objID
+	}
+	if activityID.Valid {
+		actID := int(activityID.Int64)
+		comment.ActivityID = &actID
+	}
+	return &comment, nil
+}
+
+func (s *SQLStore) DeleteComment(id int) error {
+	query := `DELETE FROM comments WHERE id = ?`
+	_, err := s.db.Exec(query, id)
+	return err
+}
+
+// API token operations
+func (s *SQLStore) CreateAPIToken(token *APIToken, tokenHash string) error {
+	query := `INSERT INTO api_tokens (user_id, name, token_hash, prefix, scope, expires_at) VALUES (?, ?, ?, ?, ?, ?) RETURNING id`
+	return s.db.QueryRow(query, token.UserID, token.Name, tokenHash, token.Prefix, token.Scope, token.ExpiresAt).Scan(&token.ID)
+}
+
+const apiTokenColumns = `t.id, t.user_id, t.name, t.prefix, t.scope, t.expires_at, t.last_used_at, t.created_at, u.username`
+
+func (s *SQLStore) GetAPITokenByHash(tokenHash string) (*APIToken, error) {
+	query := `SELECT ` + apiTokenColumns + ` FROM api_tokens t INNER JOIN users u ON t.user_id = u.id WHERE t.token_hash = ?`
+	return scanAPIToken(s.db.QueryRow(query, tokenHash))
+}
+
+func (s *SQLStore) GetAPITokenByID(id int) (*APIToken, error) {
+	query := `SELECT ` + apiTokenColumns + ` FROM api_tokens t INNER JOIN users u ON t.user_id = u.id WHERE t.id = ?`
+	return scanAPIToken(s.db.QueryRow(query, id))
+}
+
+func (s *SQLStore) GetAPITokensByUserID(userID int) ([]APIToken, error) {
+	return s.queryAPITokens(`SELECT `+apiTokenColumns+` FROM api_tokens t INNER JOIN users u ON t.user_id = u.id WHERE t.user_id = ? ORDER BY t.created_at DESC`, userID)
+}
+
+func (s *SQLStore) GetAllAPITokens() ([]APIToken, error) {
+	return s.queryAPITokens(`SELECT ` + apiTokenColumns + ` FROM api_tokens t INNER JOIN users u ON t.user_id = u.id ORDER BY u.username, t.created_at DESC`)
+}
+
+func (s *SQLStore) queryAPITokens(query string, args ...interface{}) ([]APIToken, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []APIToken
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *token)
+	}
+	return tokens, rows.Err()
+}
+
+func scanAPIToken(row interface{ Scan(...interface{}) error }) (*APIToken, error) {
+	var token APIToken
+	var expiresAt, lastUsedAt sql.NullTime
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.Prefix, &token.Scope, &expiresAt, &lastUsedAt, &token.CreatedAt, &token.Username)
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt.Valid {
+		token.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+	return &token, nil
+}
+
+func (s *SQLStore) TouchAPIToken(id int, usedAt time.Time) error {
+	_, err := s.db.Exec(`UPDATE api_tokens SET last_used_at = ? WHERE id = ?`, usedAt, id)
+	return err
+}
+
+func (s *SQLStore) DeleteAPIToken(id int) error {
+	_, err := s.db.Exec(`DELETE FROM api_tokens WHERE id = ?`, id)
+	return err
+}
+
+// Get tasks by expected outcome ID
+func (s *SQLStore) GetTasksByExpectedOutcome(expectedOutcomeID int) ([]Task, error) {
+	query := `SELECT id, expected_outcome_id, user_id, title, description, priority, status, due_date, created_at, completed_at, assigned_to_id, task_type, requested_by, completion_percentage, project_id, assignment_status, assignment_note FROM tasks WHERE expected_outcome_id = ? ORDER BY due_date ASC, created_at DESC`
+	rows, err := s.db.Query(query, expectedOutcomeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []Task
+	for rows.Next() {
+		var task Task
+		var completedAt sql.NullTime
+		var assignedToID sql.NullInt64
+		var expectedOutcomeID sql.NullInt64
+		var projectID sql.NullInt64
+		err := rows.Scan(&task.ID, &expectedOutcomeID, &task.UserID, &task.Title, &task.Description, &task.Priority, &task.Status, &task.DueDate, &task.CreatedAt, &completedAt, &assignedToID, &task.TaskType, &task.RequestedBy, &task.CompletionPercentage, &projectID, &task.AssignmentStatus, &task.AssignmentNote)
+		if err != nil {
+			return nil, err
+		}
+		if completedAt.Valid {
+			task.CompletedAt = &completedAt.Time
+		}
+		if assignedToID.Valid {
+			assignedID := int(assignedToID.Int64)
+			task.AssignedToID = &assignedID
+		}
+		if expectedOutcomeID.Valid {
+			outcomeID := int(expectedOutcomeID.Int64)
+			task.ExpectedOutcomeID = &outcomeID
+		}
+		if projectID.Valid {
+			pid := int(projectID.Int64)
+			task.ProjectID = &pid
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// Get tasks by objective ID (joins through expected outcomes)
+func (s *SQLStore) GetTasksByObjective(objectiveID int) ([]Task, error) {
+	query := `
+		SELECT t.id, t.expected_outcome_id, t.user_id, t.title, t.description, t.priority, t.status, 
+		       t.due_date, t.created_at, t.completed_at, t.assigned_to_id, t.task_type, t.requested_by, 
+		       t.completion_percentage, t.project_id, t.assignment_status, t.assignment_note
+		FROM tasks t
+		INNER JOIN expected_outcomes eo ON t.expected_outcome_id = eo.id
+		WHERE eo.objective_id = ?
+		ORDER BY t.due_date ASC, t.created_at DESC
+	`
+	rows, err := s.db.Query(query, objectiveID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []Task
+	for rows.Next() {
+		var task Task
+		var completedAt sql.NullTime
+		var assignedToID sql.NullInt64
+		var expectedOutcomeID sql.NullInt64
+		var projectID sql.NullInt64
+		err := rows.Scan(&task.ID, &expectedOutcomeID, &task.UserID, &task.Title, &task.Description, &task.Priority, &task.Status, &task.DueDate, &task.CreatedAt, &completedAt, &assignedToID, &task.TaskType, &task.RequestedBy, &task.CompletionPercentage, &projectID, &task.AssignmentStatus, &task.AssignmentNote)
+		if err != nil {
+			return nil, err
+		}
+		if completedAt.Valid {
+			task.CompletedAt = &completedAt.Time
+		}
+		if assignedToID.Valid {
+			assignedID := int(assignedToID.Int64)
+			task.AssignedToID = &assignedID
+		}
+		if expectedOutcomeID.Valid {
+			outcomeID := int(expectedOutcomeID.Int64)
+			task.ExpectedOutcomeID = &outcomeID
+		}
+		if projectID.Valid {
+			pid := int(projectID.Int64)
+			task.ProjectID = &pid
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// Get activities by objective (helper for performance calculation)
+func (s *SQLStore) GetActivitiesByObjective(objectiveID int) ([]Activity, error) {
+	query := `
+		SELECT a.id, a.expected_outcome_id, a.title, a.description, a.category, 
+		       a.progress_percentage, a.implementation_level, a.created_at, a.updated_at
+		FROM activities a
+		INNER JOIN expected_outcomes eo ON a.expected_outcome_id = eo.id
+		WHERE eo.objective_id = ?
+		ORDER BY a.created_at DESC
+	`
+	rows, err := s.db.Query(query, objectiveID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var activities []Activity
+	for rows.Next() {
+		var activity Activity
+		err := rows.Scan(&activity.ID, &activity.ExpectedOutcomeID, &activity.Title, &activity.Description,
+			&activity.Category, &activity.ProgressPercentage, &activity.ImplementationLevel,
+			&activity.CreatedAt, &activity.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		activities = append(activities, activity)
+	}
+	return activities, nil
+}
+
+// Department CRUD operations
+func (s *SQLStore) CreateDepartment(dept *Department) error {
+	query := `INSERT INTO departments (name, head_id, description) VALUES (?, ?, ?) RETURNING id`
+	return s.db.QueryRow(query, dept.Name, dept.HeadID, dept.Description).Scan(&dept.ID)
+}
+
+func (s *SQLStore) GetDepartmentByID(id int) (*Department, error) {
+	dept := &Department{}
+	var headID sql.NullInt64
+	var description sql.NullString
+	query := `SELECT d.id, d.name, d.head_id, d.description, d.created_at, COALESCE(NULLIF(h.full_name, ''), h.username, ''),
+		(SELECT COUNT(*) FROM users m WHERE m.department_id = d.id)
+		FROM departments d
+		LEFT JOIN users h ON d.head_id = h.id
+		WHERE d.id = ?`
+	err := s.db.QueryRow(query, id).Scan(&dept.ID, &dept.Name, &headID, &description, &dept.CreatedAt, &dept.HeadName, &dept.MemberCount)
+	if err != nil {
+		return nil, err
+	}
+	if headID.Valid {
+		hid := int(headID.Int64)
+		dept.HeadID = &hid
+	}
+	dept.Description = description.String
+	return dept, nil
+}
+
+func (s *SQLStore) GetAllDepartments() ([]Department, error) {
+	query := `SELECT d.id, d.name, d.head_id, d.description, d.created_at, COALESCE(NULLIF(h.full_name, ''), h.username, ''),
+		(SELECT COUNT(*) FROM users m WHERE m.department_id = d.id)
+		FROM departments d
+		LEFT JOIN users h ON d.head_id = h.id
+		ORDER BY d.name`
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var departments []Department
+	for rows.Next() {
+		var dept Department
+		var headID sql.NullInt64
+		var description sql.NullString
+		err := rows.Scan(&dept.ID, &dept.Name, &headID, &description, &dept.CreatedAt, &dept.HeadName, &dept.MemberCount)
+		if err != nil {
+			return nil, err
+		}
+		if headID.Valid {
+			hid := int(headID.Int64)
+			dept.HeadID = &hid
+		}
+		dept.Description = description.String
+		departments = append(departments, dept)
+	}
+	return departments, nil
+}
+
+func (s *SQLStore) UpdateDepartment(dept *Department) error {
+	query := `UPDATE departments SET name = ?, head_id = ?, description = ? WHERE id = ?`
+	_, err := s.db.Exec(query, dept.Name, dept.HeadID, dept.Description, dept.ID)
+	return err
+}
+
+// DeleteDepartment removes a department and detaches its members. Foreign keys
+// are not enforced by SQLite unless enabled, so members are cleared explicitly.
+func (s *SQLStore) DeleteDepartment(id int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE users SET department_id = NULL, department = '' WHERE department_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM departments WHERE id = ?`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetUsersByDepartment returns the members of a department
+func (s *SQLStore) GetUsersByDepartment(departmentID int) ([]User, error) {
+	query := `SELECT u.id, u.username, u.password, u.full_name, u.email, u.role, u.supervisor_id, u.department_id, u.department, u.position, u.created_at, COALESCE(d.name, ''), u.must_change_password, u.locked_until FROM users u LEFT JOIN departments d ON u.department_id = d.id WHERE u.department_id = ? ORDER BY u.full_name ASC`
+	rows, err := s.db.Query(query, departmentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var user User
+		var supervisorID sql.NullInt64
+		var deptID sql.NullInt64
+		var lockedUntil sql.NullTime
+		err := rows.Scan(&user.ID, &user.Username, &user.Password, &user.FullName, &user.Email, &user.Role, &supervisorID, &deptID, &user.Department, &user.Position, &user.CreatedAt, &user.DepartmentName, &user.MustChangePassword, &lockedUntil)
+		if err != nil {
+			return nil, err
+		}
+		if supervisorID.Valid {
+			supervisorIDInt := int(supervisorID.Int64)
+			user.SupervisorID = &supervisorIDInt
+		}
+		if deptID.Valid {
+			deptIDInt := int(deptID.Int64)
+			user.DepartmentID = &deptIDInt
+		}
+		if lockedUntil.Valid {
+			user.LockedUntil = &lockedUntil.Time
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// SetUserDepartment moves a user into a department, or out of any department
+// when departmentID is nil. The deprecated free-text column is kept in sync.
+func (s *SQLStore) SetUserDepartment(userID int, departmentID *int) error {
+	query := `UPDATE users SET department_id = ?, department = COALESCE((SELECT name FROM departments WHERE id = ?), '') WHERE id = ?`
+	_, err := s.db.Exec(query, departmentID, departmentID, userID)
+	return err
+}
+
+// Project CRUD operations
+func (s *SQLStore) CreateProject(proj *Project) error {
+	query := `INSERT INTO projects (name, description, start_date, end_date, status, manager_id) VALUES (?, ?, ?, ?, ?, ?) RETURNING id`
+	return s.db.QueryRow(query, proj.Name, proj.Description, proj.StartDate, proj.EndDate, proj.Status, proj.ManagerID).Scan(&proj.ID)
+}
+
+func (s *SQLStore) GetProjectByID(id int) (*Project, error) {
+	proj := &Project{}
+	var managerID sql.NullInt64
+	var description sql.NullString
+	query := `SELECT p.id, p.name, p.description, p.start_date, p.end_date, p.status, p.manager_id, p.created_at, COALESCE(NULLIF(m.full_name, ''), m.username, '')
+		FROM projects p
+		LEFT JOIN users m ON p.manager_id = m.id
+		WHERE p.id = ?`
+	err := s.db.QueryRow(query, id).Scan(&proj.ID, &proj.Name, &description, &proj.StartDate, &proj.EndDate, &proj.Status, &managerID, &proj.CreatedAt, &proj.ManagerName)
+	if err != nil {
+		return nil, err
+	}
+	if managerID.Valid {
+		mid := int(managerID.Int64)
+		proj.ManagerID = &mid
+	}
+	proj.Description = description.String
+	return proj, nil
+}
+
+func (s *SQLStore) GetAllProjects() ([]Project, error) {
+	query := `SELECT p.id, p.name, p.description, p.start_date, p.end_date, p.status, p.manager_id, p.created_at, COALESCE(NULLIF(m.full_name, ''), m.username, '')
+		FROM projects p
+		LEFT JOIN users m ON p.manager_id = m.id
+		ORDER BY p.name`
+	return s.queryProjects(query)
+}
+
+// GetProjectsForUser returns the projects a user manages or is assigned to
+func (s *SQLStore) GetProjectsForUser(userID int) ([]Project, error) {
+	query := `SELECT p.id, p.name, p.description, p.start_date, p.end_date, p.status, p.manager_id, p.created_at, COALESCE(NULLIF(m.full_name, ''), m.username, '')
+		FROM projects p
+		LEFT JOIN users m ON p.manager_id = m.id
+		WHERE p.manager_id = ? OR p.id IN (SELECT project_id FROM project_assignments WHERE user_id = ?)
+		ORDER BY p.name`
+	return s.queryProjects(query, userID, userID)
+}
+
+func (s *SQLStore) queryProjects(query string, args ...interface{}) ([]Project, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var projects []Project
+	for rows.Next() {
+		var proj Project
+		var managerID sql.NullInt64
+		var description sql.NullString
+		err := rows.Scan(&proj.ID, &proj.Name, &description, &proj.StartDate, &proj.EndDate, &proj.Status, &managerID, &proj.CreatedAt, &proj.ManagerName)
+		if err != nil {
+			return nil, err
+		}
+		if managerID.Valid {
+			mid := int(managerID.Int64)
+			proj.ManagerID = &mid
+		}
+		proj.Description = description.String
+		projects = append(projects, proj)
+	}
+	return projects, nil
+}
+
+func (s *SQLStore) UpdateProject(proj *Project) error {
+	query := `UPDATE projects SET name = ?, description = ?, start_date = ?, end_date = ?, status = ?, manager_id = ? WHERE id = ?`
+	_, err := s.db.Exec(query, proj.Name, proj.Description, proj.StartDate, proj.EndDate, proj.Status, proj.ManagerID, proj.ID)
+	return err
+}
+
+// DeleteProject removes a project with its assignments and unlinks any
+// objectives and tasks that referenced it
+func (s *SQLStore) DeleteProject(id int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		`UPDATE objectives SET project_id = NULL WHERE project_id = ?`,
+		`UPDATE tasks SET project_id = NULL WHERE project_id = ?`,
+		`DELETE FROM project_assignment_events WHERE project_id = ?`,
+		`DELETE FROM project_assignments WHERE project_id = ?`,
+		`DELETE FROM projects WHERE id = ?`,
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ProjectAssignment CRUD operations
+
+// AssignUserToProject adds a user to a project, or updates their role if they
+// are already assigned. The change is recorded in the assignment history.
+func (s *SQLStore) AssignUserToProject(projectID, userID int, role string, actorID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var existing int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM project_assignments WHERE project_id = ? AND user_id = ?`, projectID, userID).Scan(&existing)
+	if err != nil {
+		return err
+	}
+
+	action := AssignmentActionAssigned
+	if existing > 0 {
+		action = AssignmentActionRoleChanged
+		_, err = tx.Exec(`UPDATE project_assignments SET role = ? WHERE project_id = ? AND user_id = ?`, role, projectID, userID)
+	} else {
+		_, err = tx.Exec(`INSERT INTO project_assignments (project_id, user_id, role) VALUES (?, ?, ?)`, projectID, userID, role)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := logProjectAssignmentEvent(tx, projectID, userID, action, role, actorID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) GetProjectAssignments(projectID int) ([]ProjectAssignment, error) {
+	query := `SELECT pa.id, pa.project_id, pa.user_id, COALESCE(pa.role, ''), pa.assigned_date, COALESCE(NULLIF(u.full_name, ''), u.username)
+		FROM project_assignments pa
+		INNER JOIN users u ON pa.user_id = u.id
+		WHERE pa.project_id = ?
+		ORDER BY pa.assigned_date ASC`
+	rows, err := s.db.Query(query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assignments []ProjectAssignment
+	for rows.Next() {
+		var pa ProjectAssignment
+		err := rows.Scan(&pa.ID, &pa.ProjectID, &pa.UserID, &pa.Role, &pa.AssignedDate, &pa.UserName)
+		if err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, pa)
+	}
+	return assignments, nil
+}
+
+func (s *SQLStore) GetUserProjects(userID int) ([]ProjectAssignment, error) {
+	query := `SELECT pa.id, pa.project_id, pa.user_id, COALESCE(pa.role, ''), pa.assigned_date, p.name
+		FROM project_assignments pa
+		INNER JOIN projects p ON pa.project_id = p.id
+		WHERE pa.user_id = ?
+		ORDER BY p.name`
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assignments []ProjectAssignment
+	for rows.Next() {
+		var pa ProjectAssignment
+		err := rows.Scan(&pa.ID, &pa.ProjectID, &pa.UserID, &pa.Role, &pa.AssignedDate, &pa.ProjectName)
+		if err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, pa)
+	}
+	return assignments, nil
+}
+
+// IsUserAssignedToProject reports whether a user is currently on a project
+func (s *SQLStore) IsUserAssignedToProject(projectID, userID int) (bool, error) {
+	var count int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM project_assignments WHERE project_id = ? AND user_id = ?`, projectID, userID).Scan(&count)
+	return count > 0, err
+}
+
+// RemoveUserFromProject ends a user's assignment, keeping a history record
+func (s *SQLStore) RemoveUserFromProject(projectID, userID int, actorID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var role sql.NullString
+	err = tx.QueryRow(`SELECT role FROM project_assignments WHERE project_id = ? AND user_id = ?`, projectID, userID).Scan(&role)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM project_assignments WHERE project_id = ? AND user_id = ?`, projectID, userID); err != nil {
+		return err
+	}
+
+	if err := logProjectAssignmentEvent(tx, projectID, userID, AssignmentActionRemoved, role.String, actorID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func logProjectAssignmentEvent(tx *sql.Tx, projectID, userID int, action AssignmentAction, role string, actorID int) error {
+	query := `INSERT INTO project_assignment_events (project_id, user_id, action, role, actor_id) VALUES (?, ?, ?, ?, ?)`
+	_, err := tx.Exec(query, projectID, userID, action, role, actorID)
+	return err
+}
+
+// GetProjectAssignmentHistory returns every assignment change on a project, newest first
+func (s *SQLStore) GetProjectAssignmentHistory(projectID int) ([]ProjectAssignmentEvent, error) {
+	query := `SELECT e.id, e.project_id, e.user_id, e.action, COALESCE(e.role, ''), e.actor_id, e.created_at,
+		       COALESCE(NULLIF(u.full_name, ''), u.username, ''), COALESCE(NULLIF(a.full_name, ''), a.username, '')
+		FROM project_assignment_events e
+		LEFT JOIN users u ON e.user_id = u.id
+		LEFT JOIN users a ON e.actor_id = a.id
+		WHERE e.project_id = ?
+		ORDER BY e.created_at DESC, e.id DESC`
+	rows, err := s.db.Query(query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []ProjectAssignmentEvent
+	for rows.Next() {
+		var ev ProjectAssignmentEvent
+		var actorID sql.NullInt64
+		err := rows.Scan(&ev.ID, &ev.ProjectID, &ev.UserID, &ev.Action, &ev.Role, &actorID, &ev.CreatedAt, &ev.UserName, &ev.ActorName)
+		if err != nil {
+			return nil, err
+		}
+		if actorID.Valid {
+			aid := int(actorID.Int64)
+			ev.ActorID = &aid
+		}
+		events = append(events, ev)
+	}
+	return events, nil
+}
+
+// GetObjectivesByProject returns the objectives linked to a project
+func (s *SQLStore) GetObjectivesByProject(projectID int) ([]Objective, error) {
+	query := `SELECT ` + objectiveColumns + `,
+		       COALESCE(NULLIF(u.full_name, ''), u.username, '')
+		FROM objectives o
+		LEFT JOIN review_cycles rc ON o.review_cycle_id = rc.id
+		LEFT JOIN users u ON o.user_id = u.id
+		WHERE o.project_id = ?
+		ORDER BY o.created_at DESC`
+	rows, err := s.db.Query(query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var objectives []Objective
+	for rows.Next() {
+		var obj Objective
+		var categoryOther sql.NullString
+		var projectID, cycleID sql.NullInt64
+		var manualRating sql.NullFloat64
+		err := rows.Scan(&obj.ID, &obj.UserID, &obj.Title, &obj.Description, &obj.StartDate, &obj.EndDate, &obj.Visibility, &obj.Status, &obj.Category, &categoryOther, &obj.Weight, &projectID, &obj.CreatedAt, &manualRating, &cycleID, &obj.CycleName, &obj.Locked, &obj.OwnerName)
+		if err != nil {
+			return nil, err
+		}
+		if categoryOther.Valid {
+			obj.CategoryOther = categoryOther.String
+		}
+		if projectID.Valid {
+			pid := int(projectID.Int64)
+			obj.ProjectID = &pid
+		}
+		if cycleID.Valid {
+			cid := int(cycleID.Int64)
+			obj.ReviewCycleID = &cid
+		}
+		if manualRating.Valid {
+			obj.ManualRating = &manualRating.Float64
+		}
+		objectives = append(objectives, obj)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Performance is calculated once the rows are closed
+	data, err := s.queryObjectiveData(`WHERE o.project_id = ?`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.scoreObjectives(data, objectives); err != nil {
+		return nil, err
+	}
+	return objectives, nil
+}
+
+// GetTasksByProject returns the tasks linked to a project
+func (s *SQLStore) GetTasksByProject(projectID int) ([]Task, error) {
+	query := `SELECT id, expected_outcome_id, user_id, title, description, priority, status, due_date, created_at, completed_at, assigned_to_id, task_type, requested_by, completion_percentage, project_id, assignment_status, assignment_note FROM tasks WHERE project_id = ? ORDER BY due_date ASC, created_at DESC`
+	rows, err := s.db.Query(query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []Task
+	for rows.Next() {
+		var task Task
+		var completedAt sql.NullTime
+		var assignedToID sql.NullInt64
+		var expectedOutcomeID sql.NullInt64
+		var projectID sql.NullInt64
+		err := rows.Scan(&task.ID, &expectedOutcomeID, &task.UserID, &task.Title, &task.Description, &task.Priority, &task.Status, &task.DueDate, &task.CreatedAt, &completedAt, &assignedToID, &task.TaskType, &task.RequestedBy, &task.CompletionPercentage, &projectID, &task.AssignmentStatus, &task.AssignmentNote)
+		if err != nil {
+			return nil, err
+		}
+		if completedAt.Valid {
+			task.CompletedAt = &completedAt.Time
+		}
+		if assignedToID.Valid {
+			assignedID := int(assignedToID.Int64)
+			task.AssignedToID = &assignedID
+		}
+		if expectedOutcomeID.Valid {
+			outcomeID := int(expectedOutcomeID.Int64)
+			task.ExpectedOutcomeID = &outcomeID
+		}
+		if projectID.Valid {
+			pid := int(projectID.Int64)
+			task.ProjectID = &pid
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// CalculateProjectProgress rolls up the objectives and tasks linked to a
+// project into a single percentage, counting each linked item equally
+func CalculateProjectProgress(objectives []Objective, tasks []Task) float64 {
+	totalItems := len(objectives) + len(tasks)
+	if totalItems == 0 {
+		return 0
+	}
+
+	var total float64
+	for _, obj := range objectives {
+		total += obj.Performance
+	}
+	for _, task := range tasks {
+		total += TaskProgress(task)
+	}
+	return total / float64(totalItems)
+}
+
+// Review cycle operations
+func (s *SQLStore) CreateReviewCycle(cycle *ReviewCycle) error {
+	query := `INSERT INTO review_cycles (name, start_date, end_date, status) VALUES (?, ?, ?, ?) RETURNING id`
+	return s.db.QueryRow(query, cycle.Name, cycle.StartDate, cycle.EndDate, cycle.Status).Scan(&cycle.ID)
+}
+
+const reviewCycleColumns = `c.id, c.name, c.start_date, c.end_date, c.status, c.closed_at, c.created_at,
+	(SELECT COUNT(*) FROM objectives o WHERE o.review_cycle_id = c.id)`
+
+func (s *SQLStore) GetReviewCycleByID(id int) (*ReviewCycle, error) {
+	query := `SELECT ` + reviewCycleColumns + ` FROM review_cycles c WHERE c.id = ?`
+	return scanReviewCycle(s.db.QueryRow(query, id))
+}
+
+// GetAllReviewCycles returns every review cycle, most recent first
+func (s *SQLStore) GetAllReviewCycles() ([]ReviewCycle, error) {
+	rows, err := s.db.Query(`SELECT ` + reviewCycleColumns + ` FROM review_cycles c ORDER BY c.start_date DESC, c.id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cycles []ReviewCycle
+	for rows.Next() {
+		cycle, err := scanReviewCycle(rows)
+		if err != nil {
+			return nil, err
+		}
+		cycles = append(cycles, *cycle)
+	}
+	return cycles, rows.Err()
+}
+
+func scanReviewCycle(row interface{ Scan(...interface{}) error }) (*ReviewCycle, error) {
+	var cycle ReviewCycle
+	var closedAt sql.NullTime
+	err := row.Scan(&cycle.ID, &cycle.Name, &cycle.StartDate, &cycle.EndDate, &cycle.Status, &closedAt, &cycle.CreatedAt, &cycle.ObjectiveCount)
+	if err != nil {
+		return nil, err
+	}
+	if closedAt.Valid {
+		cycle.ClosedAt = &closedAt.Time
+	}
+	return &cycle, nil
+}
+
+func (s *SQLStore) UpdateReviewCycle(cycle *ReviewCycle) error {
+	query := `UPDATE review_cycles SET name = ?, start_date = ?, end_date = ? WHERE id = ?`
+	_, err := s.db.Exec(query, cycle.Name, cycle.StartDate, cycle.EndDate, cycle.ID)
+	return err
+}
+
+// SetReviewCycleStatus opens or closes a review cycle, recording when it was closed
+func (s *SQLStore) SetReviewCycleStatus(id int, status ReviewCycleStatus) error {
+	var closedAt *time.Time
+	if status == CycleStatusClosed {
+		now := time.Now()
+		closedAt = &now
+	}
+	_, err := s.db.Exec(`UPDATE review_cycles SET status = ?, closed_at = ? WHERE id = ?`, status, closedAt, id)
+	return err
+}
+
+// DeleteReviewCycle removes a review cycle and its appraisals; its objectives
+// are kept but unlinked
+func (s *SQLStore) DeleteReviewCycle(id int) error {
+	return s.runInTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`UPDATE objectives SET review_cycle_id = NULL WHERE review_cycle_id = ?`, id); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM appraisal_ratings WHERE appraisal_id IN (SELECT id FROM appraisals WHERE review_cycle_id = ?)`, id); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM appraisals WHERE review_cycle_id = ?`, id); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`DELETE FROM review_cycles WHERE id = ?`, id)
+		return err
+	})
+}
+
+// Appraisal functions
+
+// CreateAppraisal starts the appraisal of a staff member for a review cycle
+func (s *SQLStore) CreateAppraisal(a *Appraisal) error {
+	return s.db.QueryRow(`INSERT INTO appraisals (user_id, review_cycle_id) VALUES (?, ?) RETURNING id`, a.UserID, a.ReviewCycleID).Scan(&a.ID)
+}
+
+// appraisalSelect selects an appraisal with the names shown alongside it
+const appraisalSelect = `SELECT a.id, a.user_id, a.review_cycle_id, a.supervisor_id, a.self_assessment, a.supervisor_summary, a.final_rating,
+	       a.staff_signed_at, a.supervisor_signed_at, a.created_at, a.updated_at,
+	       COALESCE(NULLIF(u.full_name, ''), u.username, ''), COALESCE(NULLIF(s.full_name, ''), s.username, ''), COALESCE(rc.name, '')
+	FROM appraisals a
+	LEFT JOIN users u ON a.user_id = u.id
+	LEFT JOIN users s ON a.supervisor_id = s.id
+	LEFT JOIN review_cycles rc ON a.review_cycle_id = rc.id `
+
+// GetAppraisal returns the appraisal of a staff member for a review cycle, or
+// sql.ErrNoRows if it has not been started
+func (s *SQLStore) GetAppraisal(userID, cycleID int) (*Appraisal, error) {
+	return scanAppraisal(s.db.QueryRow(appraisalSelect+`WHERE a.user_id = ? AND a.review_cycle_id = ?`, userID, cycleID))
+}
+
+// GetAppraisalsByUserID returns a staff member's appraisals, most recent cycle first
+func (s *SQLStore) GetAppraisalsByUserID(userID int) ([]Appraisal, error) {
+	return s.queryAppraisals(`WHERE a.user_id = ?`, userID)
+}
+
+// GetAppraisalsBySupervisor returns the appraisals of a supervisor's direct reports
+func (s *SQLStore) GetAppraisalsBySupervisor(supervisorID int) ([]Appraisal, error) {
+	return s.queryAppraisals(`WHERE u.supervisor_id = ?`, supervisorID)
+}
+
+// GetAllAppraisals returns every appraisal except those of excludeUserID
+func (s *SQLStore) GetAllAppraisals(excludeUserID int) ([]Appraisal, error) {
+	return s.queryAppraisals(`WHERE a.user_id != ?`, excludeUserID)
+}
+
+func (s *SQLStore) queryAppraisals(where string, args ...interface{}) ([]Appraisal, error) {
+	rows, err := s.db.Query(appraisalSelect+where+` ORDER BY rc.start_date DESC, u.full_name ASC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var appraisals []Appraisal
+	for rows.Next() {
+		a, err := scanAppraisal(rows)
+		if err != nil {
+			return nil, err
+		}
+		appraisals = append(appraisals, *a)
+	}
+	return appraisals, rows.Err()
+}
+
+func scanAppraisal(row interface{ Scan(...interface{}) error }) (*Appraisal, error) {
+	var a Appraisal
+	var supervisorID, finalRating sql.NullInt64
+	var staffSignedAt, supervisorSignedAt sql.NullTime
+	err := row.Scan(&a.ID, &a.UserID, &a.ReviewCycleID, &supervisorID, &a.SelfAssessment, &a.SupervisorSummary, &finalRating,
+		&staffSignedAt, &supervisorSignedAt, &a.CreatedAt, &a.UpdatedAt, &a.StaffName, &a.SupervisorName, &a.CycleName)
+	if err != nil {
+		return nil, err
+	}
+	if supervisorID.Valid {
+		id := int(supervisorID.Int64)
+		a.SupervisorID = &id
+	}
+	if finalRating.Valid {
+		rating := int(finalRating.Int64)
+		a.FinalRating = &rating
+	}
+	if staffSignedAt.Valid {
+		a.StaffSignedAt = &staffSignedAt.Time
+	}
+	if supervisorSignedAt.Valid {
+		a.SupervisorSignedAt = &supervisorSignedAt.Time
+	}
+	return &a, nil
+}
+
+// GetAppraisalRatings returns the objective ratings recorded in an appraisal
+func (s *SQLStore) GetAppraisalRatings(appraisalID int) ([]AppraisalRating, error) {
+	query := `SELECT appraisal_id, objective_id, self_rating, self_comment, supervisor_rating, supervisor_comment
+		FROM appraisal_ratings WHERE appraisal_id = ?`
+	rows, err := s.db.Query(query, appraisalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ratings []AppraisalRating
+	for rows.Next() {
+		var r AppraisalRating
+		var selfRating, supervisorRating sql.NullInt64
+		if err := rows.Scan(&r.AppraisalID, &r.ObjectiveID, &selfRating, &r.SelfComment, &supervisorRating, &r.SupervisorComment); err != nil {
+			return nil, err
+		}
+		if selfRating.Valid {
+			v := int(selfRating.Int64)
+			r.SelfRating = &v
+		}
+		if supervisorRating.Valid {
+			v := int(supervisorRating.Int64)
+			r.SupervisorRating = &v
+		}
+		ratings = append(ratings, r)
+	}
+	return ratings, rows.Err()
+}
+
+// SaveSelfAssessment stores the staff member's part of an appraisal
+func (s *SQLStore) SaveSelfAssessment(a *Appraisal, ratings []AppraisalRating) error {
+	return s.runInTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`UPDATE appraisals SET self_assessment = ?, updated_at = ? WHERE id = ?`, a.SelfAssessment, time.Now(), a.ID)
+		if err != nil {
+			return err
+		}
+		for _, r := range ratings {
+			_, err := tx.Exec(`INSERT INTO appraisal_ratings (appraisal_id, objective_id, self_rating, self_comment) VALUES (?, ?, ?, ?)
+				ON CONFLICT (appraisal_id, objective_id) DO UPDATE SET self_rating = excluded.self_rating, self_comment = excluded.self_comment`,
+				a.ID, r.ObjectiveID, r.SelfRating, r.SelfComment)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SaveSupervisorReview stores the supervisor's part of an appraisal
+func (s *SQLStore) SaveSupervisorReview(a *Appraisal, ratings []AppraisalRating) error {
+	return s.runInTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`UPDATE appraisals SET supervisor_id = ?, supervisor_summary = ?, final_rating = ?, updated_at = ? WHERE id = ?`,
+			a.SupervisorID, a.SupervisorSummary, a.FinalRating, time.Now(), a.ID)
+		if err != nil {
+			return err
+		}
+		for _, r := range ratings {
+			_, err := tx.Exec(`INSERT INTO appraisal_ratings (appraisal_id, objective_id, supervisor_rating, supervisor_comment) VALUES (?, ?, ?, ?)
+				ON CONFLICT (appraisal_id, objective_id) DO UPDATE SET supervisor_rating = excluded.supervisor_rating, supervisor_comment = excluded.supervisor_comment`,
+				a.ID, r.ObjectiveID, r.SupervisorRating, r.SupervisorComment)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SignAppraisalAsSupervisor records the supervisor's sign-off
+func (s *SQLStore) SignAppraisalAsSupervisor(id, supervisorID int) error {
+	_, err := s.db.Exec(`UPDATE appraisals SET supervisor_id = ?, supervisor_signed_at = ? WHERE id = ? AND supervisor_signed_at IS NULL`,
+		supervisorID, time.Now(), id)
+	return err
+}
+
+// SignAppraisalAsStaff records the staff member's sign-off, which completes the appraisal
+func (s *SQLStore) SignAppraisalAsStaff(id int) error {
+	_, err := s.db.Exec(`UPDATE appraisals SET staff_signed_at = ? WHERE id = ? AND staff_signed_at IS NULL AND supervisor_signed_at IS NOT NULL`,
+		time.Now(), id)
+	return err
+}
+
+// Audit log functions
+
+// recordAudit writes an audit event for a change to a record, with the field
+// values that changed. before is nil for a new record and after is nil for a
+// deleted one. Updates that change no field are not recorded. An actorID of 0
+// records a change made by the system.
+func recordAudit(tx *sql.Tx, actorID int, entity AuditEntity, entityID, ownerID int, label string, before, after interface{}) error {
+	action := AuditUpdate
+	switch {
+	case before == nil:
+		action = AuditCreate
+	case after == nil:
+		action = AuditDelete
+	}
+
+	changes := diffFields(before, after)
+	if action == AuditUpdate && len(changes) == 0 {
+		return nil
+	}
+
+	var actor *int
+	if actorID != 0 {
+		actor = &actorID
+	}
+	var eventID int
+	err := tx.QueryRow(`INSERT INTO audit_events (actor_id, entity_type, entity_id, owner_id, label, action, created_at) VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id`,
+		actor, entity, entityID, ownerID, label, action, time.Now().UTC()).Scan(&eventID)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range changes {
+		_, err := tx.Exec(`INSERT INTO audit_changes (event_id, field, old_value, new_value) VALUES (?, ?, ?, ?)`, eventID, c.Field, c.Before, c.After)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// auditOwner finds the staff member an expected outcome or activity belongs
+// to through its objective
+func auditOwner(tx *sql.Tx, entity AuditEntity, id int) (int, error) {
+	var query string
+	switch entity {
+	case AuditOutcome:
+		query = `SELECT o.user_id FROM expected_outcomes eo INNER JOIN objectives o ON eo.objective_id = o.id WHERE eo.id = ?`
+	case AuditActivity:
+		query = `SELECT o.user_id FROM activities a
+			INNER JOIN expected_outcomes eo ON a.expected_outcome_id = eo.id
+			INNER JOIN objectives o ON eo.objective_id = o.id
+			WHERE a.id = ?`
+	default:
+		return 0, fmt.Errorf("no owner lookup for %s", entity)
+	}
+
+	var ownerID int
+	err := tx.QueryRow(query, id).Scan(&ownerID)
+	if err == sql.ErrNoRows {
+		return 0, nil // Orphaned record
+	}
+	return ownerID, err
+}
+
+// auditWhere builds the WHERE clause for an audit filter
+func auditWhere(f AuditFilter) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if f.Entity != "" {
+		conds = append(conds, "e.entity_type = ?")
+		args = append(args, f.Entity)
+	}
+	if f.EntityID != 0 {
+		conds = append(conds, "e.entity_id = ?")
+		args = append(args, f.EntityID)
+	}
+	if f.ActorID != 0 {
+		conds = append(conds, "e.actor_id = ?")
+		args = append(args, f.ActorID)
+	}
+	if f.OwnerID != 0 {
+		conds = append(conds, "e.owner_id = ?")
+		args = append(args, f.OwnerID)
+	}
+	if !f.From.IsZero() {
+		conds = append(conds, "e.created_at >= ?")
+		args = append(args, f.From.UTC())
+	}
+	if !f.To.IsZero() {
+		conds = append(conds, "e.created_at < ?")
+		args = append(args, f.To.UTC())
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
+
+// CountAuditEvents counts the events matching a filter, ignoring its limit and offset
+func (s *SQLStore) CountAuditEvents(f AuditFilter) (int, error) {
+	where, args := auditWhere(f)
+	var count int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM audit_events e `+where, args...).Scan(&count)
+	return count, err
+}
+
+// GetAuditEvents returns the events matching a filter with their field
+// changes, newest first
+func (s *SQLStore) GetAuditEvents(f AuditFilter) ([]AuditEvent, error) {
+	where, args := auditWhere(f)
+	query := `SELECT e.id, e.actor_id, e.entity_type, e.entity_id, e.owner_id, e.label, e.action, e.created_at,
+		       COALESCE(NULLIF(u.full_name, ''), u.username, '')
+		FROM audit_events e
+		LEFT JOIN users u ON e.actor_id = u.id
+		` + where + ` ORDER BY e.created_at DESC, e.id DESC`
+	if f.Limit > 0 {
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, f.Limit, f.Offset)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []AuditEvent
+	index := make(map[int]int)
+	for rows.Next() {
+		var ev AuditEvent
+		var actorID sql.NullInt64
+		err := rows.Scan(&ev.ID, &actorID, &ev.Entity, &ev.EntityID, &ev.OwnerID, &ev.Label, &ev.Action, &ev.CreatedAt, &ev.ActorName)
+		if err != nil {
+			return nil, err
+		}
+		if actorID.Valid {
+			id := int(actorID.Int64)
+			ev.ActorID = &id
+		}
+		index[ev.ID] = len(events)
+		events = append(events, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return events, nil
+	}
+
+	// Field changes for the whole page in one query
+	placeholders := make([]string, len(events))
+	ids := make([]interface{}, len(events))
+	for i, ev := range events {
+		placeholders[i] = "?"
+		ids[i] = ev.ID
+	}
+	changeRows, err := s.db.Query(`SELECT event_id, field, old_value, new_value FROM audit_changes
+		WHERE event_id IN (`+strings.Join(placeholders, ", ")+`) ORDER BY id`, ids...)
+	if err != nil {
+		return nil, err
+	}
+	defer changeRows.Close()
+
+	for changeRows.Next() {
+		var eventID int
+		var c AuditChange
+		if err := changeRows.Scan(&eventID, &c.Field, &c.Before, &c.After); err != nil {
+			return nil, err
+		}
+		ev := &events[index[eventID]]
+		ev.Changes = append(ev.Changes, c)
+	}
+	return events, changeRows.Err()
+}
+
+// Progress history functions
+
+// recordProgress stores a dated snapshot of an activity's or task's progress
+func recordProgress(tx *sql.Tx, entity AuditEntity, entityID, userID int, progress float64) error {
+	_, err := tx.Exec(`INSERT INTO progress_snapshots (entity_type, entity_id, user_id, progress, recorded_at) VALUES (?, ?, ?, ?, ?)`,
+		entity, entityID, userID, progress, time.Now().UTC())
+	return err
+}
+
+const progressSnapshotColumns = `s.entity_type, s.entity_id, s.user_id, s.progress, s.recorded_at`
+
+// GetObjectiveProgressSnapshots returns the snapshots of the activities and
+// linked tasks of an objective, oldest first
+func (s *SQLStore) GetObjectiveProgressSnapshots(objectiveID int) ([]ProgressSnapshot, error) {
+	return s.queryProgressSnapshots(`SELECT `+progressSnapshotColumns+` FROM progress_snapshots s
+		WHERE (s.entity_type = 'activity' AND s.entity_id IN (
+			SELECT a.id FROM activities a INNER JOIN expected_outcomes eo ON a.expected_outcome_id = eo.id WHERE eo.objective_id = ?))
+		OR (s.entity_type = 'task' AND s.entity_id IN (
+			SELECT t.id FROM tasks t INNER JOIN expected_outcomes eo ON t.expected_outcome_id = eo.id WHERE eo.objective_id = ?))
+		ORDER BY s.recorded_at ASC, s.id ASC`, objectiveID, objectiveID)
+}
+
+// GetUserProgressSnapshots returns every snapshot of a staff member's
+// activities and tasks, oldest first
+func (s *SQLStore) GetUserProgressSnapshots(userID int) ([]ProgressSnapshot, error) {
+	return s.queryProgressSnapshots(`SELECT `+progressSnapshotColumns+` FROM progress_snapshots s
+		WHERE s.user_id = ? ORDER BY s.recorded_at ASC, s.id ASC`, userID)
+}
+
+func (s *SQLStore) queryProgressSnapshots(query string, args ...interface{}) ([]ProgressSnapshot, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []ProgressSnapshot
+	for rows.Next() {
+		var s ProgressSnapshot
+		if err := rows.Scan(&s.Entity, &s.EntityID, &s.UserID, &s.Progress, &s.RecordedAt); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, rows.Err()
+}
+
+// Notification functions
+
+// CreateNotification adds a notification to a user's inbox, unless they have
+// turned its kind off, in which case n.ID is left 0
+func (s *SQLStore) CreateNotification(n *Notification) error {
+	n.CreatedAt = time.Now().UTC()
+	var disabled int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM notification_preferences WHERE user_id = ? AND kind = ? AND NOT enabled`, n.UserID, n.Kind).Scan(&disabled)
+	if err != nil || disabled > 0 {
+		return err
+	}
+	return s.db.QueryRow(`INSERT INTO notifications (user_id, actor_id, kind, entity_id, message, link, created_at) VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id`,
+		n.UserID, n.ActorID, n.Kind, n.EntityID, n.Message, n.Link, n.CreatedAt).Scan(&n.ID)
+}
+
+const notificationColumns = `n.id, n.user_id, n.actor_id, n.kind, n.entity_id, n.message, n.link, n.read_at, n.created_at`
+
+// GetNotifications returns a user's notifications, newest first, optionally
+// only the unread ones
+func (s *SQLStore) GetNotifications(userID int, unreadOnly bool, limit int) ([]Notification, error) {
+	query := `SELECT ` + notificationColumns + ` FROM notifications n WHERE n.user_id = ?`
+	if unreadOnly {
+		query += ` AND n.read_at IS NULL`
+	}
+	query += ` ORDER BY n.created_at DESC, n.id DESC LIMIT ?`
+
+	return s.queryNotifications(query, userID, limit)
+}
+
+// GetNotificationsSince returns a user's notifications created after since, oldest first
+func (s *SQLStore) GetNotificationsSince(userID int, since time.Time) ([]Notification, error) {
+	return s.queryNotifications(`SELECT `+notificationColumns+` FROM notifications n
+		WHERE n.user_id = ? AND n.created_at > ? ORDER BY n.created_at ASC, n.id ASC`, userID, since.UTC())
+}
+
+func (s *SQLStore) queryNotifications(query string, args ...interface{}) ([]Notification, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []Notification
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, *n)
+	}
+	return notifications, rows.Err()
+}
+
+// GetNotificationByID returns a single notification
+func (s *SQLStore) GetNotificationByID(id int) (*Notification, error) {
+	return scanNotification(s.db.QueryRow(`SELECT `+notificationColumns+` FROM notifications n WHERE n.id = ?`, id))
+}
+
+func scanNotification(row interface{ Scan(...interface{}) error }) (*Notification, error) {
+	var n Notification
+	var actorID sql.NullInt64
+	var readAt sql.NullTime
+	if err := row.Scan(&n.ID, &n.UserID, &actorID, &n.Kind, &n.EntityID, &n.Message, &n.Link, &readAt, &n.CreatedAt); err != nil {
+		return nil, err
+	}
+	if actorID.Valid {
+		id := int(actorID.Int64)
+		n.ActorID = &id
+	}
+	if readAt.Valid {
+		n.ReadAt = &readAt.Time
+	}
+	return &n, nil
+}
+
+// CountUnreadNotifications counts the notifications a user has not read
+func (s *SQLStore) CountUnreadNotifications(userID int) (int, error) {
+	var count int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL`, userID).Scan(&count)
+	return count, err
+}
+
+// HasNotificationSince reports whether a user was sent a notification of a
+// kind about a record at or after since
+func (s *SQLStore) HasNotificationSince(userID int, kind NotificationKind, entityID int, since time.Time) (bool, error) {
+	var exists bool
+	err := s.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM notifications WHERE user_id = ? AND kind = ? AND entity_id = ? AND created_at >= ?)`,
+		userID, kind, entityID, since.UTC()).Scan(&exists)
+	return exists, err
+}
+
+// MarkNotificationRead marks one of a user's notifications as read
+func (s *SQLStore) MarkNotificationRead(id, userID int) error {
+	_, err := s.db.Exec(`UPDATE notifications SET read_at = ? WHERE id = ? AND user_id = ? AND read_at IS NULL`,
+		time.Now().UTC(), id, userID)
+	return err
+}
+
+// MarkAllNotificationsRead marks every unread notification of a user as read
+func (s *SQLStore) MarkAllNotificationsRead(userID int) error {
+	_, err := s.db.Exec(`UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL`,
+		time.Now().UTC(), userID)
+	return err
+}
+
+// GetNotificationPreferences returns which kinds of notification a user
+// receives; kinds they have not chosen are on
+func (s *SQLStore) GetNotificationPreferences(userID int) (map[NotificationKind]bool, error) {
+	prefs := make(map[NotificationKind]bool, len(NotificationKinds))
+	for _, k := range NotificationKinds {
+		prefs[k] = true
+	}
+
+	rows, err := s.db.Query(`SELECT kind, enabled FROM notification_preferences WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var kind NotificationKind
+		var enabled bool
+		if err := rows.Scan(&kind, &enabled); err != nil {
+			return nil, err
+		}
+		prefs[kind] = enabled
+	}
+	return prefs, rows.Err()
+}
+
+// SaveNotificationPreferences stores which kinds of notification a user receives
+func (s *SQLStore) SaveNotificationPreferences(userID int, prefs map[NotificationKind]bool) error {
+	return s.runInTx(func(tx *sql.Tx) error {
+		for kind, enabled := range prefs {
+			_, err := tx.Exec(`INSERT INTO notification_preferences (user_id, kind, enabled) VALUES (?, ?, ?)
+				ON CONFLICT(user_id, kind) DO UPDATE SET enabled = excluded.enabled`, userID, kind, enabled)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetOpenTasksDueBetween returns the tasks of every user that are not
+// completed and are due on a date from from to to, inclusive
+func (s *SQLStore) GetOpenTasksDueBetween(from, to time.Time) ([]Task, error) {
+	query := `SELECT id, expected_outcome_id, user_id, title, description, priority, status, due_date, created_at, completed_at, assigned_to_id, task_type, requested_by, completion_percentage, project_id, assignment_status, assignment_note FROM tasks WHERE status != ? AND due_date >= ? AND due_date <= ? ORDER BY due_date ASC, id ASC`
+	rows, err := s.db.Query(query, TaskStatusCompleted, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []Task
+	for rows.Next() {
+		var task Task
+		var completedAt sql.NullTime
+		var assignedToID sql.NullInt64
+		var expectedOutcomeID sql.NullInt64
+		var projectID sql.NullInt64
+		err := rows.Scan(&task.ID, &expectedOutcomeID, &task.UserID, &task.Title, &task.Description, &task.Priority, &task.Status, &task.DueDate, &task.CreatedAt, &completedAt, &assignedToID, &task.TaskType, &task.RequestedBy, &task.CompletionPercentage, &projectID, &task.AssignmentStatus, &task.AssignmentNote)
+		if err != nil {
+			return nil, err
+		}
+		if completedAt.Valid {
+			task.CompletedAt = &completedAt.Time
+		}
+		if assignedToID.Valid {
+			assignedID := int(assignedToID.Int64)
+			task.AssignedToID = &assignedID
+		}
+		if expectedOutcomeID.Valid {
+			outcomeID := int(expectedOutcomeID.Int64)
+			task.ExpectedOutcomeID = &outcomeID
+		}
+		if projectID.Valid {
+			pid := int(projectID.Int64)
+			task.ProjectID = &pid
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+// GetUsersWithOpenAppraisal returns the staff with objectives in a review
+// cycle who have not signed off their appraisal for it
+func (s *SQLStore) GetUsersWithOpenAppraisal(cycleID int) ([]int, error) {
+	rows, err := s.db.Query(`SELECT DISTINCT o.user_id FROM objectives o
+		WHERE o.review_cycle_id = ? AND NOT EXISTS (
+			SELECT 1 FROM appraisals a WHERE a.user_id = o.user_id AND a.review_cycle_id = o.review_cycle_id AND a.staff_signed_at IS NOT NULL)
+		ORDER BY o.user_id`, cycleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Email functions
+
+// GetEmailPreference returns how a user wants to be emailed; users who have
+// not chosen get an email per notification
+func (s *SQLStore) GetEmailPreference(userID int) (*EmailPreference, error) {
+	pref := EmailPreference{UserID: userID, Frequency: EmailImmediate}
+	var lastDigestAt sql.NullTime
+	err := s.db.QueryRow(`SELECT frequency, last_digest_at FROM email_preferences WHERE user_id = ?`, userID).
+		Scan(&pref.Frequency, &lastDigestAt)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if lastDigestAt.Valid {
+		pref.LastDigestAt = &lastDigestAt.Time
+	}
+	return &pref, nil
+}
+
+// SaveEmailFrequency stores how often a user is emailed. Switching to the
+// daily digest starts it from now, so older notifications are not repeated.
+func (s *SQLStore) SaveEmailFrequency(userID int, frequency EmailFrequency) error {
+	_, err := s.db.Exec(`INSERT INTO email_preferences (user_id, frequency, last_digest_at) VALUES (?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET frequency = excluded.frequency,
+			last_digest_at = CASE WHEN email_preferences.frequency = excluded.frequency THEN email_preferences.last_digest_at ELSE excluded.last_digest_at END`,
+		userID, frequency, time.Now().UTC())
+	return err
+}
+
+// GetDigestSubscribers returns the preferences of users on the daily digest
+func (s *SQLStore) GetDigestSubscribers() ([]EmailPreference, error) {
+	rows, err := s.db.Query(`SELECT user_id, frequency, last_digest_at FROM email_preferences WHERE frequency = ? ORDER BY user_id`, EmailDaily)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prefs []EmailPreference
+	for rows.Next() {
+		var pref EmailPreference
+		var lastDigestAt sql.NullTime
+		if err := rows.Scan(&pref.UserID, &pref.Frequency, &lastDigestAt); err != nil {
+			return nil, err
+		}
+		if lastDigestAt.Valid {
+			pref.LastDigestAt = &lastDigestAt.Time
+		}
+		prefs = append(prefs, pref)
+	}
+	return prefs, rows.Err()
+}
+
+// SetLastDigestAt records when a user's digest was last sent
+func (s *SQLStore) SetLastDigestAt(userID int, at time.Time) error {
+	_, err := s.db.Exec(`UPDATE email_preferences SET last_digest_at = ? WHERE user_id = ?`, at.UTC(), userID)
+	return err
+}
+
+// QueueEmail adds an email to the outbox, to be sent straight away
+func (s *SQLStore) QueueEmail(e *OutboxEmail) error {
+	now := time.Now().UTC()
+	e.Status = OutboxPending
+	e.NextAttemptAt = now
+	e.CreatedAt = now
+	return s.db.QueryRow(`INSERT INTO email_outbox (user_id, to_address, subject, body, status, next_attempt_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id`,
+		e.UserID, e.To, e.Subject, e.Body, e.Status, e.NextAttemptAt, e.CreatedAt).Scan(&e.ID)
+}
+
+const outboxColumns = `id, user_id, to_address, subject, body, status, attempts, last_error, next_attempt_at, created_at, sent_at`
+
+// GetDueOutboxEmails returns pending emails whose next attempt is due, oldest first
+func (s *SQLStore) GetDueOutboxEmails(now time.Time, limit int) ([]OutboxEmail, error) {
+	return s.queryOutbox(`SELECT `+outboxColumns+` FROM email_outbox
+		WHERE status = ? AND next_attempt_at <= ? ORDER BY next_attempt_at ASC, id ASC LIMIT ?`, OutboxPending, now.UTC(), limit)
+}
+
+// GetOutboxEmails returns the most recent emails, newest first
+func (s *SQLStore) GetOutboxEmails(limit int) ([]OutboxEmail, error) {
+	return s.queryOutbox(`SELECT `+outboxColumns+` FROM email_outbox ORDER BY created_at DESC, id DESC LIMIT ?`, limit)
+}
+
+func (s *SQLStore) queryOutbox(query string, args ...interface{}) ([]OutboxEmail, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var emails []OutboxEmail
+	for rows.Next() {
+		var e OutboxEmail
+		var userID sql.NullInt64
+		var sentAt sql.NullTime
+		err := rows.Scan(&e.ID, &userID, &e.To, &e.Subject, &e.Body, &e.Status, &e.Attempts, &e.LastError, &e.NextAttemptAt, &e.CreatedAt, &sentAt)
+		if err != nil {
+			return nil, err
+		}
+		if userID.Valid {
+			id := int(userID.Int64)
+			e.UserID = &id
+		}
+		if sentAt.Valid {
+			e.SentAt = &sentAt.Time
+		}
+		emails = append(emails, e)
+	}
+	return emails, rows.Err()
+}
+
+// MarkEmailSent records the successful delivery of an email
+func (s *SQLStore) MarkEmailSent(id int, at time.Time) error {
+	_, err := s.db.Exec(`UPDATE email_outbox SET status = ?, attempts = attempts + 1, last_error = '', sent_at = ? WHERE id = ?`,
+		OutboxSent, at.UTC(), id)
+	return err
+}
+
+// MarkEmailAttempt stores the outcome of a failed delivery attempt
+func (s *SQLStore) MarkEmailAttempt(e *OutboxEmail) error {
+	_, err := s.db.Exec(`UPDATE email_outbox SET status = ?, attempts = ?, last_error = ?, next_attempt_at = ? WHERE id = ?`,
+		e.Status, e.Attempts, e.LastError, e.NextAttemptAt.UTC(), e.ID)
+	return err
+}
+
+// RetryEmail puts a failed email back in the queue with fresh attempts
+func (s *SQLStore) RetryEmail(id int) error {
+	_, err := s.db.Exec(`UPDATE email_outbox SET status = ?, attempts = 0, next_attempt_at = ? WHERE id = ? AND status = ?`,
+		OutboxPending, time.Now().UTC(), id, OutboxFailed)
+	return err
+}
+
+// Scheduled job functions
+
+// CreateJobRun records the start of a job run
+func (s *SQLStore) CreateJobRun(run *JobRun) error {
+	return s.db.QueryRow(`INSERT INTO job_runs (job, trigger, started_at, status, message) VALUES (?, ?, ?, ?, ?) RETURNING id`,
+		run.Job, run.Trigger, run.StartedAt.UTC(), run.Status, run.Message).Scan(&run.ID)
+}
+
+// FinishJobRun records the outcome of a job run
+func (s *SQLStore) FinishJobRun(run *JobRun) error {
+	_, err := s.db.Exec(`UPDATE job_runs SET finished_at = ?, status = ?, message = ? WHERE id = ?`,
+		run.FinishedAt, run.Status, run.Message, run.ID)
+	return err
+}
+
+const jobRunColumns = `id, job, trigger, started_at, finished_at, status, message`
+
+// GetLastJobRun returns the most recent run of a job, or nil if it never ran
+func (s *SQLStore) GetLastJobRun(job string) (*JobRun, error) {
+	runs, err := s.queryJobRuns(`SELECT `+jobRunColumns+` FROM job_runs WHERE job = ? ORDER BY started_at DESC, id DESC LIMIT 1`, job)
+	if err != nil || len(runs) == 0 {
+		return nil, err
+	}
+	return &runs[0], nil
+}
+
+// GetJobRunsSince returns the runs of a job started at or after since, newest
+// first
+func (s *SQLStore) GetJobRunsSince(job string, since time.Time) ([]JobRun, error) {
+	return s.queryJobRuns(`SELECT `+jobRunColumns+` FROM job_runs WHERE job = ? AND started_at >= ? ORDER BY started_at DESC, id DESC`, job, since.UTC())
+}
+
+// DeleteJobRunsBefore removes job runs started before t and returns how many
+// there were
+func (s *SQLStore) DeleteJobRunsBefore(t time.Time) (int, error) {
+	result, err := s.db.Exec(`DELETE FROM job_runs WHERE started_at < ?`, t.UTC())
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// GetJobRuns returns the most recent runs of every job, newest first
+func (s *SQLStore) GetJobRuns(limit int) ([]JobRun, error) {
+	return s.queryJobRuns(`SELECT `+jobRunColumns+` FROM job_runs ORDER BY started_at DESC, id DESC LIMIT ?`, limit)
+}
+
+func (s *SQLStore) queryJobRuns(query string, args ...interface{}) ([]JobRun, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []JobRun
+	for rows.Next() {
+		var run JobRun
+		var finishedAt sql.NullTime
+		if err := rows.Scan(&run.ID, &run.Job, &run.Trigger, &run.StartedAt, &finishedAt, &run.Status, &run.Message); err != nil {
+			return nil, err
+		}
+		if finishedAt.Valid {
+			run.FinishedAt = &finishedAt.Time
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// Recurring task functions
+
+// GetRecurringActivities returns the activities below 100% whose objective
+// is running on now's date, is not complete and is not in a closed review cycle
+func (s *SQLStore) GetRecurringActivities(now time.Time) ([]RecurringActivity, error) {
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	rows, err := s.db.Query(`
+		SELECT a.id, a.expected_outcome_id, a.title, a.description, a.category, a.progress_percentage, a.implementation_level, a.created_at, a.updated_at, o.user_id, o.project_id
+		FROM activities a
+		INNER JOIN expected_outcomes eo ON a.expected_outcome_id = eo.id
+		INNER JOIN objectives o ON eo.objective_id = o.id
+		LEFT JOIN review_cycles rc ON o.review_cycle_id = rc.id
+		WHERE a.progress_percentage < 100 AND o.status != ? AND o.start_date < ? AND o.end_date >= ?
+			AND COALESCE(rc.status, '') != ?
+		ORDER BY a.id`, StatusComplete, today.AddDate(0, 0, 1), today, CycleStatusClosed)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var activities []RecurringActivity
+	for rows.Next() {
+		var a RecurringActivity
+		var projectID sql.NullInt64
+		err := rows.Scan(&a.ID, &a.ExpectedOutcomeID, &a.Title, &a.Description, &a.Category, &a.ProgressPercentage, &a.ImplementationLevel, &a.CreatedAt, &a.UpdatedAt, &a.UserID, &projectID)
+		if err != nil {
+			return nil, err
+		}
+		if projectID.Valid {
+			pid := int(projectID.Int64)
+			a.ProjectID = &pid
+		}
+		activities = append(activities, a)
+	}
+	return activities, rows.Err()
+}
+
+// ClaimRecurringPeriod reserves an activity's period for a generated task,
+// returning false if it was already claimed
+func (s *SQLStore) ClaimRecurringPeriod(activityID int, periodStart time.Time) (bool, error) {
+	result, err := s.db.Exec(`INSERT INTO recurring_tasks (activity_id, period_start, created_at) VALUES (?, ?, ?) ON CONFLICT DO NOTHING`,
+		activityID, periodStart.UTC(), time.Now().UTC())
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// ReleaseRecurringPeriod gives up a claim whose task could not be created
+func (s *SQLStore) ReleaseRecurringPeriod(activityID int, periodStart time.Time) error {
+	_, err := s.db.Exec(`DELETE FROM recurring_tasks WHERE activity_id = ? AND period_start = ?`, activityID, periodStart.UTC())
+	return err
+}
+
+// SetRecurringTask links a claimed period to the task generated for it
+func (s *SQLStore) SetRecurringTask(activityID int, periodStart time.Time, taskID int) error {
+	_, err := s.db.Exec(`UPDATE recurring_tasks SET task_id = ? WHERE activity_id = ? AND period_start = ?`, taskID, activityID, periodStart.UTC())
+	return err
+}
+
+// Login functions
+
+// CreateLoginEvent records a sign-in attempt
+func (s *SQLStore) CreateLoginEvent(e *LoginEvent) error {
+	return s.db.QueryRow(`INSERT INTO login_events (user_id, username, ip, user_agent, success, failure, created_at) VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id`,
+		e.UserID, e.Username, e.IP, e.UserAgent, e.Success, e.Failure, e.CreatedAt.UTC()).Scan(&e.ID)
+}
+
+const loginEventColumns = `id, user_id, username, ip, user_agent, success, failure, created_at`
+
+// GetLoginEventsByUserID returns the most recent sign-in attempts to an
+// account, newest first
+func (s *SQLStore) GetLoginEventsByUserID(userID, limit int) ([]LoginEvent, error) {
+	return s.queryLoginEvents(`SELECT `+loginEventColumns+` FROM login_events WHERE user_id = ? ORDER BY created_at DESC, id DESC LIMIT ?`, userID, limit)
+}
+
+// GetRecentLoginEvents returns the sign-in attempts since a time with either
+// the username or the client address, newest first
+func (s *SQLStore) GetRecentLoginEvents(username, ip string, since time.Time) ([]LoginEvent, error) {
+	return s.queryLoginEvents(`SELECT `+loginEventColumns+` FROM login_events WHERE (username = ? OR ip = ?) AND created_at >= ? ORDER BY created_at DESC, id DESC`,
+		username, ip, since.UTC())
+}
+
+// DeleteLoginEventsBefore removes sign-in attempts older than t and returns
+// how many there were
+func (s *SQLStore) DeleteLoginEventsBefore(t time.Time) (int, error) {
+	result, err := s.db.Exec(`DELETE FROM login_events WHERE created_at < ?`, t.UTC())
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+func (s *SQLStore) queryLoginEvents(query string, args ...interface{}) ([]LoginEvent, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []LoginEvent
+	for rows.Next() {
+		var e LoginEvent
+		var userID sql.NullInt64
+		if err := rows.Scan(&e.ID, &userID, &e.Username, &e.IP, &e.UserAgent, &e.Success, &e.Failure, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if userID.Valid {
+			id := int(userID.Int64)
+			e.UserID = &id
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// IncrementFailedLogins adds a failed sign-in to an account's count of
+// consecutive failures and returns the new count
+func (s *SQLStore) IncrementFailedLogins(userID int) (int, error) {
+	var failures int
+	err := s.db.QueryRow(`UPDATE users SET failed_logins = failed_logins + 1 WHERE id = ? RETURNING failed_logins`, userID).Scan(&failures)
+	return failures, err
+}
+
+// LockUser stops an account signing in until a time. Its count of failures
+// starts again, so once the lock ends it gets the full number of attempts.
+func (s *SQLStore) LockUser(userID int, until time.Time) error {
+	_, err := s.db.Exec(`UPDATE users SET failed_logins = 0, locked_until = ? WHERE id = ?`, until.UTC(), userID)
+	return err
+}
+
+// UnlockUser lifts an account's lock and clears its count of failures
+func (s *SQLStore) UnlockUser(userID int) error {
+	_, err := s.db.Exec(`UPDATE users SET failed_logins = 0, locked_until = NULL WHERE id = ?`, userID)
+	return err
+}
@@ -0,0 +1,56 @@
+package store
+
+import "time"
+
+// EmailFrequency is how often a user is emailed about their notifications
+type EmailFrequency string
+
+const (
+	EmailImmediate EmailFrequency = "immediate" // One email per notification
+	EmailDaily     EmailFrequency = "daily"     // One digest a day
+	EmailOff       EmailFrequency = "off"
+)
+
+// Label describes the frequency on the preferences page
+func (f EmailFrequency) Label() string {
+	switch f {
+	case EmailImmediate:
+		return "For each notification"
+	case EmailDaily:
+		return "Once a day, as a digest"
+	case EmailOff:
+		return "Never"
+	}
+	return string(f)
+}
+
+// EmailPreference is how a user wants to be emailed
+type EmailPreference struct {
+	UserID       int
+	Frequency    EmailFrequency
+	LastDigestAt *time.Time
+}
+
+// OutboxStatus is where an email is in delivery
+type OutboxStatus string
+
+const (
+	OutboxPending OutboxStatus = "pending"
+	OutboxSent    OutboxStatus = "sent"
+	OutboxFailed  OutboxStatus = "failed" // Gave up after outboxMaxAttempts
+)
+
+// OutboxEmail is an email queued for delivery
+type OutboxEmail struct {
+	ID            int
+	UserID        *int
+	To            string
+	Subject       string
+	Body          string
+	Status        OutboxStatus
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	SentAt        *time.Time
+}
@@ -0,0 +1,252 @@
+package store
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// The objective hierarchy - objectives, their expected outcomes, activities
+// and linked tasks - is read a level at a time for every objective matching
+// a filter, rather than an objective or outcome at a time. Each level is one
+// query whose where clause is the filter on the objectives table, joined as
+// o, so a user's or a whole team's hierarchy takes the same four queries
+// however many objectives it has.
+
+// objectiveData is the activities and tasks of a set of objectives, by
+// objective ID. It is the scoreSource for objectives loaded in a batch.
+type objectiveData struct {
+	activities map[int][]Activity
+	tasks      map[int][]Task
+}
+
+func (d objectiveData) GetActivitiesByObjective(objectiveID int) ([]Activity, error) {
+	return d.activities[objectiveID], nil
+}
+
+func (d objectiveData) GetTasksByObjective(objectiveID int) ([]Task, error) {
+	return d.tasks[objectiveID], nil
+}
+
+// GetObjectivesWithOutcomes returns a user's objectives with their expected
+// outcomes, activities and tasks
+func (s *SQLStore) GetObjectivesWithOutcomes(userID int) ([]ObjectiveWithOutcomes, error) {
+	return s.queryHierarchy(`WHERE o.user_id = ?`, userID)
+}
+
+// GetObjectivesWithOutcomesInCycle is GetObjectivesWithOutcomes for the
+// objectives in one review cycle
+func (s *SQLStore) GetObjectivesWithOutcomesInCycle(userID, cycleID int) ([]ObjectiveWithOutcomes, error) {
+	return s.queryHierarchy(`WHERE o.user_id = ? AND o.review_cycle_id = ?`, userID, cycleID)
+}
+
+// GetTeamObjectivesWithOutcomes returns the objective hierarchies of several
+// users by user ID. Users without objectives are left out of the map.
+func (s *SQLStore) GetTeamObjectivesWithOutcomes(userIDs []int) (map[int][]ObjectiveWithOutcomes, error) {
+	return s.queryTeamHierarchy(userIDs, "")
+}
+
+// GetTeamObjectivesWithOutcomesInCycle is GetTeamObjectivesWithOutcomes for
+// the objectives in one review cycle
+func (s *SQLStore) GetTeamObjectivesWithOutcomesInCycle(userIDs []int, cycleID int) (map[int][]ObjectiveWithOutcomes, error) {
+	return s.queryTeamHierarchy(userIDs, ` AND o.review_cycle_id = ?`, cycleID)
+}
+
+func (s *SQLStore) queryTeamHierarchy(userIDs []int, and string, args ...interface{}) (map[int][]ObjectiveWithOutcomes, error) {
+	team := make(map[int][]ObjectiveWithOutcomes)
+	if len(userIDs) == 0 {
+		return team, nil
+	}
+
+	where := `WHERE o.user_id IN (` + placeholders(len(userIDs)) + `)` + and
+	params := make([]interface{}, 0, len(userIDs)+len(args))
+	for _, id := range userIDs {
+		params = append(params, id)
+	}
+	params = append(params, args...)
+
+	objectives, err := s.queryHierarchy(where, params...)
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range objectives {
+		team[obj.Objective.UserID] = append(team[obj.Objective.UserID], obj)
+	}
+	return team, nil
+}
+
+// queryHierarchy reads the objectives matching where, newest first, with their
+// expected outcomes, activities and tasks in creation and due date order
+func (s *SQLStore) queryHierarchy(where string, args ...interface{}) ([]ObjectiveWithOutcomes, error) {
+	objectives, err := s.queryObjectives(where, args...)
+	if err != nil || len(objectives) == 0 {
+		return nil, err
+	}
+	outcomes, err := s.queryObjectiveOutcomes(where, args...)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.queryObjectiveData(where, args...)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.scoreObjectives(data, objectives); err != nil {
+		return nil, err
+	}
+
+	hierarchy := make([]ObjectiveWithOutcomes, len(objectives))
+	for i, obj := range objectives {
+		hierarchy[i].Objective = obj
+		for _, outcome := range outcomes[obj.ID] {
+			entry := ExpectedOutcomeWithActivities{ExpectedOutcome: outcome}
+			for _, a := range data.activities[obj.ID] {
+				if a.ExpectedOutcomeID == outcome.ID {
+					entry.Activities = append(entry.Activities, a)
+				}
+			}
+			for _, t := range data.tasks[obj.ID] {
+				if *t.ExpectedOutcomeID == outcome.ID {
+					entry.Tasks = append(entry.Tasks, t)
+				}
+			}
+			hierarchy[i].ExpectedOutcomes = append(hierarchy[i].ExpectedOutcomes, entry)
+		}
+	}
+	return hierarchy, nil
+}
+
+// queryObjectives reads the objectives matching where without scoring them
+func (s *SQLStore) queryObjectives(where string, args ...interface{}) ([]Objective, error) {
+	query := `SELECT ` + objectiveColumns + ` FROM objectives o LEFT JOIN review_cycles rc ON o.review_cycle_id = rc.id ` + where + ` ORDER BY o.created_at DESC, o.id DESC`
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var objectives []Objective
+	for rows.Next() {
+		var obj Objective
+		var categoryOther sql.NullString
+		var projectID, cycleID sql.NullInt64
+		var manualRating sql.NullFloat64
+		err := rows.Scan(&obj.ID, &obj.UserID, &obj.Title, &obj.Description, &obj.StartDate, &obj.EndDate, &obj.Visibility, &obj.Status, &obj.Category, &categoryOther, &obj.Weight, &projectID, &obj.CreatedAt, &manualRating, &cycleID, &obj.CycleName, &obj.Locked)
+		if err != nil {
+			return nil, err
+		}
+		if categoryOther.Valid {
+			obj.CategoryOther = categoryOther.String
+		}
+		if projectID.Valid {
+			pid := int(projectID.Int64)
+			obj.ProjectID = &pid
+		}
+		if cycleID.Valid {
+			cid := int(cycleID.Int64)
+			obj.ReviewCycleID = &cid
+		}
+		if manualRating.Valid {
+			obj.ManualRating = &manualRating.Float64
+		}
+		objectives = append(objectives, obj)
+	}
+	return objectives, rows.Err()
+}
+
+// queryObjectiveOutcomes reads the expected outcomes of the objectives
+// matching where, by objective ID
+func (s *SQLStore) queryObjectiveOutcomes(where string, args ...interface{}) (map[int][]ExpectedOutcome, error) {
+	query := `SELECT eo.id, eo.objective_id, eo.title, eo.description, eo.created_at
+		FROM expected_outcomes eo
+		INNER JOIN objectives o ON eo.objective_id = o.id ` + where + `
+		ORDER BY eo.created_at ASC, eo.id ASC`
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	outcomes := make(map[int][]ExpectedOutcome)
+	for rows.Next() {
+		var outcome ExpectedOutcome
+		if err := rows.Scan(&outcome.ID, &outcome.ObjectiveID, &outcome.Title, &outcome.Description, &outcome.CreatedAt); err != nil {
+			return nil, err
+		}
+		outcomes[outcome.ObjectiveID] = append(outcomes[outcome.ObjectiveID], outcome)
+	}
+	return outcomes, rows.Err()
+}
+
+// queryObjectiveData reads the activities and the outcome-linked tasks of the
+// objectives matching where, in two queries
+func (s *SQLStore) queryObjectiveData(where string, args ...interface{}) (objectiveData, error) {
+	data := objectiveData{activities: make(map[int][]Activity), tasks: make(map[int][]Task)}
+
+	query := `SELECT eo.objective_id, a.id, a.expected_outcome_id, a.title, a.description, a.category,
+		       a.progress_percentage, a.implementation_level, a.created_at, a.updated_at
+		FROM activities a
+		INNER JOIN expected_outcomes eo ON a.expected_outcome_id = eo.id
+		INNER JOIN objectives o ON eo.objective_id = o.id ` + where + `
+		ORDER BY a.created_at ASC, a.id ASC`
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return data, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var objectiveID int
+		var a Activity
+		err := rows.Scan(&objectiveID, &a.ID, &a.ExpectedOutcomeID, &a.Title, &a.Description, &a.Category,
+			&a.ProgressPercentage, &a.ImplementationLevel, &a.CreatedAt, &a.UpdatedAt)
+		if err != nil {
+			return data, err
+		}
+		data.activities[objectiveID] = append(data.activities[objectiveID], a)
+	}
+	if err := rows.Err(); err != nil {
+		return data, err
+	}
+	rows.Close()
+
+	query = `SELECT eo.objective_id, t.id, t.expected_outcome_id, t.user_id, t.title, t.description, t.priority, t.status,
+		       t.due_date, t.created_at, t.completed_at, t.assigned_to_id, t.task_type, t.requested_by,
+		       t.completion_percentage, t.project_id, t.assignment_status, t.assignment_note
+		FROM tasks t
+		INNER JOIN expected_outcomes eo ON t.expected_outcome_id = eo.id
+		INNER JOIN objectives o ON eo.objective_id = o.id ` + where + `
+		ORDER BY t.due_date ASC, t.created_at DESC, t.id ASC`
+	taskRows, err := s.db.Query(query, args...)
+	if err != nil {
+		return data, err
+	}
+	defer taskRows.Close()
+	for taskRows.Next() {
+		var objectiveID int
+		var task Task
+		var completedAt sql.NullTime
+		var assignedToID, projectID sql.NullInt64
+		var expectedOutcomeID int
+		err := taskRows.Scan(&objectiveID, &task.ID, &expectedOutcomeID, &task.UserID, &task.Title, &task.Description, &task.Priority, &task.Status, &task.DueDate, &task.CreatedAt, &completedAt, &assignedToID, &task.TaskType, &task.RequestedBy, &task.CompletionPercentage, &projectID, &task.AssignmentStatus, &task.AssignmentNote)
+		if err != nil {
+			return data, err
+		}
+		task.ExpectedOutcomeID = &expectedOutcomeID
+		if completedAt.Valid {
+			task.CompletedAt = &completedAt.Time
+		}
+		if assignedToID.Valid {
+			assignedID := int(assignedToID.Int64)
+			task.AssignedToID = &assignedID
+		}
+		if projectID.Valid {
+			pid := int(projectID.Int64)
+			task.ProjectID = &pid
+		}
+		data.tasks[objectiveID] = append(data.tasks[objectiveID], task)
+	}
+	return data, taskRows.Err()
+}
+
+// placeholders is n comma-separated ? placeholders for an IN list
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
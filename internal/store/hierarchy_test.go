@@ -0,0 +1,225 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"staffperformance/internal/scoring"
+)
+
+// seedTeam creates a supervisor with staff members, each with objectives in
+// cycle that have expected outcomes with activities and tasks. It returns the
+// staff user IDs.
+func seedTeam(tb testing.TB, s Store, staff, objectives, outcomes, items int, cycle *ReviewCycle) []int {
+	tb.Helper()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	boss := createTestUser(tb, s, "boss", RoleSupervisor, nil)
+
+	var ids []int
+	for u := 0; u < staff; u++ {
+		member := createTestUser(tb, s, fmt.Sprintf("staff%d", u), RoleStaff, &boss.ID)
+		ids = append(ids, member.ID)
+		for o := 0; o < objectives; o++ {
+			obj := &Objective{UserID: member.ID, Title: fmt.Sprintf("Objective %d", o), StartDate: start, EndDate: start.AddDate(0, 6, 0),
+				Visibility: VisibilityPublic, Status: StatusOnTrack, Category: CategoryOther, Weight: float64(10 * (o + 1))}
+			if cycle != nil {
+				obj.ReviewCycleID = &cycle.ID
+			}
+			if err := s.CreateObjective(obj, member.ID); err != nil {
+				tb.Fatal(err)
+			}
+			for e := 0; e < outcomes; e++ {
+				outcome := &ExpectedOutcome{ObjectiveID: obj.ID, Title: fmt.Sprintf("Outcome %d", e)}
+				if err := s.CreateExpectedOutcome(outcome, member.ID); err != nil {
+					tb.Fatal(err)
+				}
+				for i := 0; i < items; i++ {
+					activity := &Activity{ExpectedOutcomeID: outcome.ID, Title: fmt.Sprintf("Activity %d", i),
+						Category: CategoryWeekly, ProgressPercentage: float64((u + o + e + i) * 7 % 101)}
+					if err := s.CreateActivity(activity, member.ID); err != nil {
+						tb.Fatal(err)
+					}
+					task := &Task{ExpectedOutcomeID: &outcome.ID, UserID: member.ID, Title: fmt.Sprintf("Task %d", i), Priority: PriorityMedium,
+						Status: TaskStatusInProgress, TaskType: TaskTypePersonal, DueDate: start.AddDate(0, 1, i), CompletionPercentage: float64((o + i) * 13 % 101)}
+					if i%3 == 0 {
+						task.Status = TaskStatusCompleted
+					}
+					if err := s.CreateTask(task, member.ID); err != nil {
+						tb.Fatal(err)
+					}
+				}
+			}
+		}
+	}
+	return ids
+}
+
+// hierarchyPerItem builds a user's hierarchy as the handlers did before the
+// batched loaders: a query per objective for its score and its outcomes, and
+// two per outcome for its activities and tasks. It is the benchmark baseline
+// and what the batched loaders are checked against.
+func hierarchyPerItem(s *SQLStore, userID int) ([]ObjectiveWithOutcomes, error) {
+	objectives, err := s.GetObjectivesByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	var hierarchy []ObjectiveWithOutcomes
+	for _, obj := range objectives {
+		if obj.Performance, err = s.objectivePerformance(s, &obj); err != nil {
+			return nil, err
+		}
+		outcomes, err := s.GetExpectedOutcomesByObjectiveID(obj.ID)
+		if err != nil {
+			return nil, err
+		}
+		item := ObjectiveWithOutcomes{Objective: obj}
+		for _, outcome := range outcomes {
+			activities, err := s.GetActivitiesByExpectedOutcomeID(outcome.ID)
+			if err != nil {
+				return nil, err
+			}
+			tasks, err := s.GetTasksByExpectedOutcome(outcome.ID)
+			if err != nil {
+				return nil, err
+			}
+			item.ExpectedOutcomes = append(item.ExpectedOutcomes, ExpectedOutcomeWithActivities{ExpectedOutcome: outcome, Activities: activities, Tasks: tasks})
+		}
+		hierarchy = append(hierarchy, item)
+	}
+	return hierarchy, nil
+}
+
+// hierarchySummary describes a hierarchy by IDs and scores, ignoring the
+// order of records created in the same second
+func hierarchySummary(hierarchy []ObjectiveWithOutcomes) []string {
+	var lines []string
+	for _, item := range hierarchy {
+		lines = append(lines, fmt.Sprintf("objective %d user %d performance %.4f", item.Objective.ID, item.Objective.UserID, item.Objective.Performance))
+		for _, outcome := range item.ExpectedOutcomes {
+			lines = append(lines, fmt.Sprintf("objective %d outcome %d", item.Objective.ID, outcome.ExpectedOutcome.ID))
+			for _, a := range outcome.Activities {
+				lines = append(lines, fmt.Sprintf("outcome %d activity %d %.0f", outcome.ExpectedOutcome.ID, a.ID, a.ProgressPercentage))
+			}
+			for _, t := range outcome.Tasks {
+				lines = append(lines, fmt.Sprintf("outcome %d task %d %s", outcome.ExpectedOutcome.ID, t.ID, t.Status))
+			}
+		}
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+func testStoreTeamHierarchy(t *testing.T, s Store) {
+	cycle := &ReviewCycle{Name: "FY2026 H1", StartDate: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate: time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC), Status: CycleStatusOpen}
+	if err := s.CreateReviewCycle(cycle); err != nil {
+		t.Fatal(err)
+	}
+	staff := seedTeam(t, s, 3, 2, 2, 2, cycle)
+	idle := createTestUser(t, s, "idle", RoleStaff, nil)
+
+	team, err := s.GetTeamObjectivesWithOutcomes(append(staff, idle.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := team[idle.ID]; ok || len(team) != len(staff) {
+		t.Errorf("team has %d members, want the %d with objectives", len(team), len(staff))
+	}
+	for _, id := range staff {
+		want, err := hierarchyPerItem(s.(*SQLStore), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := s.GetObjectivesWithOutcomes(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(hierarchySummary(got)) != fmt.Sprint(hierarchySummary(want)) {
+			t.Errorf("user %d: GetObjectivesWithOutcomes = %v, want %v", id, hierarchySummary(got), hierarchySummary(want))
+		}
+		if fmt.Sprint(hierarchySummary(team[id])) != fmt.Sprint(hierarchySummary(want)) {
+			t.Errorf("user %d: GetTeamObjectivesWithOutcomes = %v, want %v", id, hierarchySummary(team[id]), hierarchySummary(want))
+		}
+	}
+
+	inCycle, err := s.GetTeamObjectivesWithOutcomesInCycle(staff, cycle.ID)
+	if err != nil || len(inCycle) != len(staff) {
+		t.Fatalf("GetTeamObjectivesWithOutcomesInCycle = %d members, %v", len(inCycle), err)
+	}
+	if other, err := s.GetObjectivesWithOutcomesInCycle(staff[0], cycle.ID+1); err != nil || len(other) != 0 {
+		t.Errorf("GetObjectivesWithOutcomesInCycle for another cycle = %v, %v", other, err)
+	}
+	if empty, err := s.GetTeamObjectivesWithOutcomes(nil); err != nil || len(empty) != 0 {
+		t.Errorf("GetTeamObjectivesWithOutcomes(nil) = %v, %v", empty, err)
+	}
+}
+
+// The benchmarks load the hierarchies of a team of 25 staff with 4
+// objectives each, every objective with 3 expected outcomes of 2 activities
+// and 2 tasks, one query at a time and batched:
+//
+//	go test -run '^$' -bench Hierarchy
+
+const (
+	benchStaff      = 25
+	benchObjectives = 4
+	benchOutcomes   = 3
+	benchItems      = 2
+)
+
+func openBenchmarkStore(b *testing.B) (*SQLStore, []int) {
+	s, err := OpenSQLiteStore(filepath.Join(b.TempDir(), "bench.db"), scoring.TaskActivityMean{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { s.Close() })
+	if err := s.Init(); err != nil {
+		b.Fatal(err)
+	}
+	// Seeding commits thousands of small transactions
+	if _, err := s.db.Exec(`PRAGMA synchronous = OFF`); err != nil {
+		b.Fatal(err)
+	}
+	return s, seedTeam(b, s, benchStaff, benchObjectives, benchOutcomes, benchItems, nil)
+}
+
+func BenchmarkUserHierarchy(b *testing.B) {
+	s, staff := openBenchmarkStore(b)
+	b.Run("PerItem", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := hierarchyPerItem(s, staff[0]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Batched", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := s.GetObjectivesWithOutcomes(staff[0]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkTeamHierarchy(b *testing.B) {
+	s, staff := openBenchmarkStore(b)
+	b.Run("PerItem", func(b *testing.B) {
+		for b.Loop() {
+			for _, id := range staff {
+				if _, err := hierarchyPerItem(s, id); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("Batched", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := s.GetTeamObjectivesWithOutcomes(staff); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
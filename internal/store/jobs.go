@@ -0,0 +1,39 @@
+package store
+
+import "time"
+
+// JobRunStatus is the outcome of a job run
+type JobRunStatus string
+
+const (
+	JobRunning   JobRunStatus = "running"
+	JobSucceeded JobRunStatus = "succeeded"
+	JobFailed    JobRunStatus = "failed"
+)
+
+// JobTrigger is what started a job run
+type JobTrigger string
+
+const (
+	TriggerSchedule JobTrigger = "schedule"
+	TriggerManual   JobTrigger = "manual"
+)
+
+// JobRun is one execution of a job
+type JobRun struct {
+	ID         int
+	Job        string
+	Trigger    JobTrigger
+	StartedAt  time.Time
+	FinishedAt *time.Time
+	Status     JobRunStatus
+	Message    string // Summary, or the error of a failed run
+}
+
+// Duration is how long the run took, zero while it is running
+func (r JobRun) Duration() time.Duration {
+	if r.FinishedAt == nil {
+		return 0
+	}
+	return r.FinishedAt.Sub(r.StartedAt).Round(time.Millisecond)
+}
@@ -0,0 +1,38 @@
+package store
+
+import "time"
+
+// LoginFailure is why a sign-in attempt failed. It is kept in the login
+// history only; the sign-in page gives the same answer for every failure.
+type LoginFailure string
+
+const (
+	LoginUnknownUser   LoginFailure = "unknown_user"
+	LoginWrongPassword LoginFailure = "wrong_password"
+	LoginAccountLocked LoginFailure = "account_locked"
+)
+
+// Label describes the failure for display
+func (f LoginFailure) Label() string {
+	switch f {
+	case LoginUnknownUser:
+		return "unknown user"
+	case LoginWrongPassword:
+		return "wrong password"
+	case LoginAccountLocked:
+		return "account locked"
+	}
+	return string(f)
+}
+
+// LoginEvent is one attempt to sign in with a password
+type LoginEvent struct {
+	ID        int
+	UserID    *int   // Nil when the username matched no account
+	Username  string // As entered
+	IP        string
+	UserAgent string
+	Success   bool
+	Failure   LoginFailure // Empty for successes and attempts recorded before reasons were
+	CreatedAt time.Time
+}
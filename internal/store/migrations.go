@@ -0,0 +1,828 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Migration is one numbered schema change. Up and Down run inside a
+// transaction that also records the change in schema_migrations. Migrations
+// without a Down, such as the ones creating the base schema, cannot be rolled
+// back.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *sql.Tx) error
+	Down    func(tx *sql.Tx) error
+}
+
+// MigrationStatus describes a migration and whether it has been applied
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// migrations lists every SQLite schema change in the order it is applied.
+// Append new migrations with the next version number, and add the same change
+// with the same version to postgresMigrations; never edit or reorder applied ones.
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "initial_schema",
+		Up:      migrateInitialSchema,
+	},
+	{
+		Version: 2,
+		Name:    "legacy_departments",
+		Up: func(tx *sql.Tx) error {
+			return migrateLegacyDepartments(tx)
+		},
+	},
+	{
+		Version: 3,
+		Name:    "project_links",
+		Up: func(tx *sql.Tx) error {
+			if err := addColumn(tx, "objectives", "project_id", "INTEGER REFERENCES projects(id) ON DELETE SET NULL"); err != nil {
+				return err
+			}
+			if err := addColumn(tx, "tasks", "project_id", "INTEGER REFERENCES projects(id) ON DELETE SET NULL"); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS project_assignment_events (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				project_id INTEGER NOT NULL,
+				user_id INTEGER NOT NULL,
+				action TEXT NOT NULL,
+				role TEXT,
+				actor_id INTEGER,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+				FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL
+			)`)
+			return err
+		},
+		Down: func(tx *sql.Tx) error {
+			if _, err := tx.Exec(`DROP TABLE IF EXISTS project_assignment_events`); err != nil {
+				return err
+			}
+			if err := dropColumn(tx, "tasks", "project_id"); err != nil {
+				return err
+			}
+			return dropColumn(tx, "objectives", "project_id")
+		},
+	},
+	{
+		Version: 4,
+		Name:    "must_change_password",
+		Up: func(tx *sql.Tx) error {
+			if err := addColumn(tx, "users", "must_change_password", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+				return err
+			}
+			// Plaintext passwords are rehashed on each user's next successful
+			// login; accounts still using the public default password must change it.
+			_, err := tx.Exec(`UPDATE users SET must_change_password = 1 WHERE password = ?`, "admin123")
+			return err
+		},
+		Down: func(tx *sql.Tx) error {
+			return dropColumn(tx, "users", "must_change_password")
+		},
+	},
+	{
+		Version: 5,
+		Name:    "comments",
+		Up: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS comments (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				objective_id INTEGER,
+				activity_id INTEGER,
+				user_id INTEGER NOT NULL,
+				comment_text TEXT NOT NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (objective_id) REFERENCES objectives(id) ON DELETE CASCADE,
+				FOREIGN KEY (activity_id) REFERENCES activities(id) ON DELETE CASCADE,
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_comments_objective ON comments(objective_id);
+			CREATE INDEX IF NOT EXISTS idx_comments_activity ON comments(activity_id);
+			`)
+			return err
+		},
+		Down: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`DROP TABLE IF EXISTS comments`)
+			return err
+		},
+	},
+	{
+		Version: 6,
+		Name:    "api_tokens",
+		Up: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS api_tokens (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id INTEGER NOT NULL,
+				name TEXT NOT NULL,
+				token_hash TEXT NOT NULL UNIQUE,
+				prefix TEXT NOT NULL,
+				scope TEXT NOT NULL DEFAULT 'read',
+				expires_at DATETIME,
+				last_used_at DATETIME,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_api_tokens_user ON api_tokens(user_id);
+			`)
+			return err
+		},
+		Down: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`DROP TABLE IF EXISTS api_tokens`)
+			return err
+		},
+	},
+	{
+		Version: 7,
+		Name:    "review_cycles",
+		Up: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS review_cycles (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL UNIQUE,
+				start_date DATE NOT NULL,
+				end_date DATE NOT NULL,
+				status TEXT NOT NULL DEFAULT 'Planned',
+				closed_at DATETIME,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			)`)
+			if err != nil {
+				return err
+			}
+			if err := addColumn(tx, "objectives", "review_cycle_id", "INTEGER REFERENCES review_cycles(id) ON DELETE SET NULL"); err != nil {
+				return err
+			}
+			_, err = tx.Exec(`CREATE INDEX IF NOT EXISTS idx_objectives_review_cycle ON objectives(review_cycle_id)`)
+			return err
+		},
+		Down: func(tx *sql.Tx) error {
+			if _, err := tx.Exec(`DROP INDEX IF EXISTS idx_objectives_review_cycle`); err != nil {
+				return err
+			}
+			if err := dropColumn(tx, "objectives", "review_cycle_id"); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`DROP TABLE IF EXISTS review_cycles`)
+			return err
+		},
+	},
+	{
+		Version: 8,
+		Name:    "objective_manual_rating",
+		Up: func(tx *sql.Tx) error {
+			return addColumn(tx, "objectives", "manual_rating", "REAL")
+		},
+		Down: func(tx *sql.Tx) error {
+			return dropColumn(tx, "objectives", "manual_rating")
+		},
+	},
+	{
+		Version: 9,
+		Name:    "appraisals",
+		Up: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS appraisals (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+				review_cycle_id INTEGER NOT NULL REFERENCES review_cycles(id) ON DELETE CASCADE,
+				supervisor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+				self_assessment TEXT NOT NULL DEFAULT '',
+				supervisor_summary TEXT NOT NULL DEFAULT '',
+				final_rating INTEGER,
+				staff_signed_at DATETIME,
+				supervisor_signed_at DATETIME,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				UNIQUE (user_id, review_cycle_id)
+			);
+
+			CREATE TABLE IF NOT EXISTS appraisal_ratings (
+				appraisal_id INTEGER NOT NULL REFERENCES appraisals(id) ON DELETE CASCADE,
+				objective_id INTEGER NOT NULL REFERENCES objectives(id) ON DELETE CASCADE,
+				self_rating INTEGER,
+				self_comment TEXT NOT NULL DEFAULT '',
+				supervisor_rating INTEGER,
+				supervisor_comment TEXT NOT NULL DEFAULT '',
+				PRIMARY KEY (appraisal_id, objective_id)
+			)`)
+			return err
+		},
+		Down: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`DROP TABLE IF EXISTS appraisal_ratings; DROP TABLE IF EXISTS appraisals`)
+			return err
+		},
+	},
+	{
+		Version: 10,
+		Name:    "audit_log",
+		Up: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS audit_events (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+				entity_type TEXT NOT NULL,
+				entity_id INTEGER NOT NULL,
+				owner_id INTEGER NOT NULL,
+				label TEXT NOT NULL DEFAULT '',
+				action TEXT NOT NULL,
+				created_at DATETIME NOT NULL
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_audit_events_entity ON audit_events(entity_type, entity_id);
+			CREATE INDEX IF NOT EXISTS idx_audit_events_created ON audit_events(created_at);
+
+			CREATE TABLE IF NOT EXISTS audit_changes (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				event_id INTEGER NOT NULL REFERENCES audit_events(id) ON DELETE CASCADE,
+				field TEXT NOT NULL,
+				old_value TEXT NOT NULL DEFAULT '',
+				new_value TEXT NOT NULL DEFAULT ''
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_audit_changes_event ON audit_changes(event_id)`)
+			return err
+		},
+		Down: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`DROP TABLE IF EXISTS audit_changes; DROP TABLE IF EXISTS audit_events`)
+			return err
+		},
+	},
+	{
+		Version: 11,
+		Name:    "progress_snapshots",
+		Up: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS progress_snapshots (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				entity_type TEXT NOT NULL,
+				entity_id INTEGER NOT NULL,
+				user_id INTEGER NOT NULL,
+				progress REAL NOT NULL,
+				recorded_at DATETIME NOT NULL
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_progress_snapshots_entity ON progress_snapshots(entity_type, entity_id);
+			CREATE INDEX IF NOT EXISTS idx_progress_snapshots_user ON progress_snapshots(user_id);
+
+			-- Existing progress is recorded as of its last known change. Zero
+			-- progress needs no snapshot.
+			INSERT INTO progress_snapshots (entity_type, entity_id, user_id, progress, recorded_at)
+			SELECT 'activity', a.id, o.user_id, a.progress_percentage, COALESCE(a.updated_at, a.created_at)
+			FROM activities a
+			INNER JOIN expected_outcomes eo ON a.expected_outcome_id = eo.id
+			INNER JOIN objectives o ON eo.objective_id = o.id
+			WHERE a.progress_percentage > 0;
+
+			INSERT INTO progress_snapshots (entity_type, entity_id, user_id, progress, recorded_at)
+			SELECT 'task', t.id, t.user_id,
+				CASE WHEN t.status = 'Completed' THEN 100 ELSE t.completion_percentage END,
+				COALESCE(t.completed_at, t.created_at)
+			FROM tasks t
+			WHERE t.status = 'Completed' OR t.completion_percentage > 0`)
+			return err
+		},
+		Down: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`DROP TABLE IF EXISTS progress_snapshots`)
+			return err
+		},
+	},
+	{
+		Version: 12,
+		Name:    "task_assignment_status",
+		Up: func(tx *sql.Tx) error {
+			if err := addColumn(tx, "tasks", "assignment_status", "TEXT NOT NULL DEFAULT ''"); err != nil {
+				return err
+			}
+			if err := addColumn(tx, "tasks", "assignment_note", "TEXT NOT NULL DEFAULT ''"); err != nil {
+				return err
+			}
+			// Tasks assigned before the workflow existed count as accepted
+			_, err := tx.Exec(`UPDATE tasks SET assignment_status = 'Accepted' WHERE assigned_to_id IS NOT NULL`)
+			return err
+		},
+		Down: func(tx *sql.Tx) error {
+			if err := dropColumn(tx, "tasks", "assignment_note"); err != nil {
+				return err
+			}
+			return dropColumn(tx, "tasks", "assignment_status")
+		},
+	},
+	{
+		Version: 13,
+		Name:    "notifications",
+		Up: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS notifications (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+				actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+				kind TEXT NOT NULL,
+				entity_id INTEGER NOT NULL,
+				message TEXT NOT NULL,
+				link TEXT NOT NULL DEFAULT '',
+				read_at DATETIME,
+				created_at DATETIME NOT NULL
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id, read_at);
+
+			-- Kinds without a row are on
+			CREATE TABLE IF NOT EXISTS notification_preferences (
+				user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+				kind TEXT NOT NULL,
+				enabled INTEGER NOT NULL,
+				PRIMARY KEY (user_id, kind)
+			)`)
+			return err
+		},
+		Down: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`DROP TABLE IF EXISTS notification_preferences; DROP TABLE IF EXISTS notifications`)
+			return err
+		},
+	},
+	{
+		Version: 14,
+		Name:    "email_outbox",
+		Up: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS email_outbox (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+				to_address TEXT NOT NULL,
+				subject TEXT NOT NULL,
+				body TEXT NOT NULL,
+				status TEXT NOT NULL DEFAULT 'pending',
+				attempts INTEGER NOT NULL DEFAULT 0,
+				last_error TEXT NOT NULL DEFAULT '',
+				next_attempt_at DATETIME NOT NULL,
+				created_at DATETIME NOT NULL,
+				sent_at DATETIME
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_email_outbox_due ON email_outbox(status, next_attempt_at);
+
+			-- Users without a row get an email for each notification
+			CREATE TABLE IF NOT EXISTS email_preferences (
+				user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+				frequency TEXT NOT NULL,
+				last_digest_at DATETIME
+			)`)
+			return err
+		},
+		Down: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`DROP TABLE IF EXISTS email_preferences; DROP TABLE IF EXISTS email_outbox`)
+			return err
+		},
+	},
+	{
+		Version: 15,
+		Name:    "scheduled_jobs",
+		Up: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS job_runs (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				job TEXT NOT NULL,
+				trigger TEXT NOT NULL,
+				started_at DATETIME NOT NULL,
+				finished_at DATETIME,
+				status TEXT NOT NULL,
+				message TEXT NOT NULL DEFAULT ''
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_job_runs_job ON job_runs(job, started_at);
+
+			-- One row per activity and period a recurring task was generated
+			-- for; task_id is cleared when the task is deleted so it is not
+			-- generated again
+			CREATE TABLE IF NOT EXISTS recurring_tasks (
+				activity_id INTEGER NOT NULL REFERENCES activities(id) ON DELETE CASCADE,
+				period_start DATETIME NOT NULL,
+				task_id INTEGER REFERENCES tasks(id) ON DELETE SET NULL,
+				created_at DATETIME NOT NULL,
+				PRIMARY KEY (activity_id, period_start)
+			)`)
+			return err
+		},
+		Down: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`DROP TABLE IF EXISTS recurring_tasks; DROP TABLE IF EXISTS job_runs`)
+			return err
+		},
+	},
+	{
+		Version: 16,
+		Name:    "hierarchy_indexes",
+		Up:      migrateHierarchyIndexes,
+		Down:    rollbackHierarchyIndexes,
+	},
+	{
+		Version: 17,
+		Name:    "login_security",
+		Up: func(tx *sql.Tx) error {
+			if err := addColumn(tx, "users", "failed_logins", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+				return err
+			}
+			if err := addColumn(tx, "users", "locked_until", "DATETIME"); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS login_events (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+				username TEXT NOT NULL,
+				ip TEXT NOT NULL,
+				user_agent TEXT NOT NULL DEFAULT '',
+				success INTEGER NOT NULL,
+				created_at DATETIME NOT NULL
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_login_events_user ON login_events(user_id, created_at);
+			CREATE INDEX IF NOT EXISTS idx_login_events_username ON login_events(username, created_at);
+			CREATE INDEX IF NOT EXISTS idx_login_events_ip ON login_events(ip, created_at)`)
+			return err
+		},
+		Down: func(tx *sql.Tx) error {
+			if _, err := tx.Exec(`DROP TABLE IF EXISTS login_events`); err != nil {
+				return err
+			}
+			if err := dropColumn(tx, "users", "locked_until"); err != nil {
+				return err
+			}
+			return dropColumn(tx, "users", "failed_logins")
+		},
+	},
+	{
+		Version: 18,
+		Name:    "login_failures",
+		Up: func(tx *sql.Tx) error {
+			return addColumn(tx, "login_events", "failure", "TEXT NOT NULL DEFAULT ''")
+		},
+		Down: func(tx *sql.Tx) error {
+			return dropColumn(tx, "login_events", "failure")
+		},
+	},
+}
+
+// migrateHierarchyIndexes indexes the foreign keys the objective hierarchy is
+// loaded by, from users down to activities and tasks. The statements are the
+// same for SQLite and PostgreSQL.
+func migrateHierarchyIndexes(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+	CREATE INDEX IF NOT EXISTS idx_objectives_user ON objectives(user_id);
+	CREATE INDEX IF NOT EXISTS idx_expected_outcomes_objective ON expected_outcomes(objective_id);
+	CREATE INDEX IF NOT EXISTS idx_activities_expected_outcome ON activities(expected_outcome_id);
+	CREATE INDEX IF NOT EXISTS idx_tasks_expected_outcome ON tasks(expected_outcome_id)`)
+	return err
+}
+
+func rollbackHierarchyIndexes(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+	DROP INDEX IF EXISTS idx_objectives_user;
+	DROP INDEX IF EXISTS idx_expected_outcomes_objective;
+	DROP INDEX IF EXISTS idx_activities_expected_outcome;
+	DROP INDEX IF EXISTS idx_tasks_expected_outcome`)
+	return err
+}
+
+// migrateInitialSchema creates the tables that existed before versioned
+// migrations. Databases created by older releases may already have the tables
+// but miss columns that used to be added at startup, so those are added here.
+func migrateInitialSchema(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+	CREATE TABLE IF NOT EXISTS departments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT UNIQUE NOT NULL,
+		head_id INTEGER,
+		description TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (head_id) REFERENCES users(id) ON DELETE SET NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		username TEXT UNIQUE NOT NULL,
+		password TEXT NOT NULL,
+		full_name TEXT NOT NULL,
+		email TEXT,
+		role TEXT NOT NULL DEFAULT 'Staff',
+		supervisor_id INTEGER,
+		department_id INTEGER,
+		department TEXT,
+		position TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (supervisor_id) REFERENCES users(id) ON DELETE SET NULL,
+		FOREIGN KEY (department_id) REFERENCES departments(id) ON DELETE SET NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS projects (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		description TEXT,
+		start_date DATETIME,
+		end_date DATETIME,
+		status TEXT NOT NULL DEFAULT 'Active',
+		manager_id INTEGER,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (manager_id) REFERENCES users(id) ON DELETE SET NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS project_assignments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		project_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		role TEXT,
+		assigned_date DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+		UNIQUE(project_id, user_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS objectives (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		title TEXT NOT NULL,
+		description TEXT,
+		start_date DATETIME,
+		end_date DATETIME,
+		visibility TEXT NOT NULL DEFAULT 'Public',
+		status TEXT NOT NULL DEFAULT 'Not Started',
+		category TEXT NOT NULL DEFAULT 'Other',
+		category_other TEXT,
+		weight REAL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS expected_outcomes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		objective_id INTEGER NOT NULL,
+		title TEXT NOT NULL,
+		description TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (objective_id) REFERENCES objectives(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS activities (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		expected_outcome_id INTEGER NOT NULL,
+		title TEXT NOT NULL,
+		description TEXT,
+		category TEXT NOT NULL,
+		progress_percentage REAL DEFAULT 0,
+		implementation_level TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (expected_outcome_id) REFERENCES expected_outcomes(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS tasks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		expected_outcome_id INTEGER,
+		user_id INTEGER NOT NULL,
+		assigned_to_id INTEGER,
+		title TEXT NOT NULL,
+		description TEXT,
+		priority TEXT NOT NULL,
+		status TEXT NOT NULL,
+		task_type TEXT NOT NULL DEFAULT 'Personal',
+		requested_by TEXT,
+		due_date DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		completed_at DATETIME,
+		completion_percentage REAL DEFAULT 0,
+		FOREIGN KEY (expected_outcome_id) REFERENCES expected_outcomes(id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+		FOREIGN KEY (assigned_to_id) REFERENCES users(id) ON DELETE SET NULL
+	);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Columns added to existing databases by releases before versioned migrations
+	legacyColumns := []struct{ table, column, definition string }{
+		{"tasks", "expected_outcome_id", "INTEGER REFERENCES expected_outcomes(id) ON DELETE CASCADE"},
+		{"tasks", "completion_percentage", "REAL DEFAULT 0"},
+		{"objectives", "visibility", "TEXT NOT NULL DEFAULT 'Public'"},
+		{"objectives", "status", "TEXT NOT NULL DEFAULT 'Not Started'"},
+		{"objectives", "category", "TEXT NOT NULL DEFAULT 'Other'"},
+		{"objectives", "category_other", "TEXT"},
+		{"objectives", "weight", "REAL DEFAULT 0"},
+		{"users", "department_id", "INTEGER REFERENCES departments(id) ON DELETE SET NULL"},
+	}
+	for _, c := range legacyColumns {
+		if err := addColumn(tx, c.table, c.column, c.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateLegacyDepartments creates a department for every distinct free-text
+// users.department value and links the users to it. Safe to run repeatedly.
+func migrateLegacyDepartments(tx *sql.Tx) error {
+	result, err := tx.Exec(`INSERT INTO departments (name, description)
+		SELECT DISTINCT TRIM(department), '' FROM users
+		WHERE department_id IS NULL AND TRIM(COALESCE(department, '')) != ''
+		AND TRIM(department) NOT IN (SELECT name FROM departments)`)
+	if err != nil {
+		return err
+	}
+	if created, _ := result.RowsAffected(); created > 0 {
+		log.Printf("Migration: Created %d departments from legacy user data", created)
+	}
+
+	result, err = tx.Exec(`UPDATE users SET department_id = (SELECT id FROM departments WHERE name = TRIM(users.department))
+		WHERE department_id IS NULL AND TRIM(COALESCE(department, '')) != ''`)
+	if err != nil {
+		return err
+	}
+	if linked, _ := result.RowsAffected(); linked > 0 {
+		log.Printf("Migration: Linked %d users to their departments", linked)
+	}
+	return nil
+}
+
+// addColumn adds a column unless the table already has it
+func addColumn(tx *sql.Tx, table, column, definition string) error {
+	exists, err := columnExists(tx, table, column)
+	if err != nil || exists {
+		return err
+	}
+	_, err = tx.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, definition))
+	if err != nil {
+		return fmt.Errorf("add %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+// dropColumn removes a column if the table has it
+func dropColumn(tx *sql.Tx, table, column string) error {
+	exists, err := columnExists(tx, table, column)
+	if err != nil || !exists {
+		return err
+	}
+	_, err = tx.Exec(fmt.Sprintf(`ALTER TABLE %s DROP COLUMN %s`, table, column))
+	if err != nil {
+		return fmt.Errorf("drop %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+// columnExists reads the SQLite table schema, so it is only for SQLite migrations
+func columnExists(tx *sql.Tx, table, column string) (bool, error) {
+	var count int
+	err := tx.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&count)
+	return count > 0, err
+}
+
+// ensureMigrationsTable creates the table that records applied migrations
+func (s *SQLStore) ensureMigrationsTable() error {
+	timestamp := "DATETIME"
+	if s.dialect == DialectPostgres {
+		timestamp = "TIMESTAMPTZ"
+	}
+	_, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at ` + timestamp + ` DEFAULT CURRENT_TIMESTAMP
+	)`)
+	return err
+}
+
+// migrations returns the schema changes for the store's dialect
+func (s *SQLStore) migrations() []Migration {
+	if s.dialect == DialectPostgres {
+		return postgresMigrations
+	}
+	return migrations
+}
+
+// GetMigrationStatus lists every known migration with the time it was applied
+func (s *SQLStore) GetMigrationStatus() ([]MigrationStatus, error) {
+	if err := s.ensureMigrationsTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	for _, m := range s.migrations() {
+		status := MigrationStatus{Migration: m}
+		if appliedAt, ok := applied[m.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// MigrateUp applies every pending migration in version order, stopping at the
+// first failure. It returns the number of migrations applied.
+func (s *SQLStore) MigrateUp() (int, error) {
+	statuses, err := s.GetMigrationStatus()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, m := range statuses {
+		if m.AppliedAt != nil {
+			continue
+		}
+		err := s.runInTx(func(tx *sql.Tx) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, m.Version, m.Name)
+			return err
+		})
+		if err != nil {
+			return count, fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		log.Printf("Migration: Applied %d %s", m.Version, m.Name)
+		count++
+	}
+	return count, nil
+}
+
+// MigrateDown rolls back the given number of most recently applied
+// migrations. It rolls back none if any of them is irreversible.
+func (s *SQLStore) MigrateDown(steps int) (int, error) {
+	statuses, err := s.GetMigrationStatus()
+	if err != nil {
+		return 0, err
+	}
+
+	var rollback []MigrationStatus
+	for i := len(statuses) - 1; i >= 0 && len(rollback) < steps; i-- {
+		if statuses[i].AppliedAt != nil {
+			rollback = append(rollback, statuses[i])
+		}
+	}
+	for _, m := range rollback {
+		if m.Down == nil {
+			return 0, fmt.Errorf("migration %d (%s) is irreversible and cannot be rolled back; restore a backup taken before it instead", m.Version, m.Name)
+		}
+	}
+
+	count := 0
+	for _, m := range rollback {
+		err := s.runInTx(func(tx *sql.Tx) error {
+			if err := m.Down(tx); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = ?`, m.Version)
+			return err
+		})
+		if err != nil {
+			return count, fmt.Errorf("rollback %d (%s): %w", m.Version, m.Name, err)
+		}
+		log.Printf("Migration: Rolled back %d %s", m.Version, m.Name)
+		count++
+	}
+	return count, nil
+}
+
+// runInTx runs fn in a transaction, committing only if it succeeds
+func (s *SQLStore) runInTx(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
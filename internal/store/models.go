@@ -0,0 +1,406 @@
+package store
+
+import (
+	"net/http"
+	"time"
+)
+
+// UserRole represents user roles in the system
+type UserRole string
+
+const (
+	RoleAdmin      UserRole = "Admin"
+	RoleSupervisor UserRole = "Supervisor"
+	RoleStaff      UserRole = "Staff"
+)
+
+// ObjectiveVisibility represents whether an objective is public or private
+type ObjectiveVisibility string
+
+const (
+	VisibilityPublic  ObjectiveVisibility = "Public"
+	VisibilityPrivate ObjectiveVisibility = "Private"
+)
+
+// ObjectiveStatus represents the status of an objective
+type ObjectiveStatus string
+
+const (
+	StatusOnTrack    ObjectiveStatus = "On Track"
+	StatusNotStarted ObjectiveStatus = "Not Started"
+	StatusPending    ObjectiveStatus = "Pending"
+	StatusComplete   ObjectiveStatus = "Complete"
+	StatusNeedHelp   ObjectiveStatus = "Need Help"
+)
+
+// ObjectiveCategory represents the category of an objective
+type ObjectiveCategory string
+
+const (
+	CategoryFinancial             ObjectiveCategory = "Financial"
+	CategoryContinuousImprovement ObjectiveCategory = "Continuous Improvement"
+	CategoryPeople                ObjectiveCategory = "People"
+	CategoryOther                 ObjectiveCategory = "Other"
+)
+
+// User represents a staff member
+type User struct {
+	ID                 int
+	Username           string
+	Password           string
+	FullName           string
+	Email              string
+	Role               UserRole
+	SupervisorID       *int
+	DepartmentID       *int
+	Department         string // Deprecated: kept for backward compatibility
+	Position           string
+	CreatedAt          time.Time
+	SupervisorName     string     // For display purposes
+	DepartmentName     string     // For display purposes
+	OverallPerformance float64    // For supervisor dashboard
+	MustChangePassword bool       // Set for default or reset passwords
+	LockedUntil        *time.Time // Set when repeated failed sign-ins lock the account
+}
+
+// Locked reports whether the account is locked against signing in
+func (u User) Locked() bool {
+	return u.LockedUntil != nil && time.Now().Before(*u.LockedUntil)
+}
+
+// Department represents an organizational department
+type Department struct {
+	ID          int
+	Name        string
+	HeadID      *int
+	Description string
+	CreatedAt   time.Time
+	HeadName    string // For display purposes
+	MemberCount int    // For display purposes
+}
+
+// ProjectStatus represents the lifecycle status of a project
+type ProjectStatus string
+
+const (
+	ProjectStatusPlanned   ProjectStatus = "Planned"
+	ProjectStatusActive    ProjectStatus = "Active"
+	ProjectStatusOnHold    ProjectStatus = "On Hold"
+	ProjectStatusCompleted ProjectStatus = "Completed"
+)
+
+// ReviewCycleStatus is the state of an appraisal period
+type ReviewCycleStatus string
+
+const (
+	CycleStatusPlanned ReviewCycleStatus = "Planned"
+	CycleStatusOpen    ReviewCycleStatus = "Open"
+	CycleStatusClosed  ReviewCycleStatus = "Closed" // Objectives in the cycle are locked
+)
+
+// ReviewCycle is an appraisal period, such as "FY2026 H1", that objectives
+// are scoped to. Admins open and close cycles.
+type ReviewCycle struct {
+	ID             int               `json:"id"`
+	Name           string            `json:"name"`
+	StartDate      time.Time         `json:"start_date"`
+	EndDate        time.Time         `json:"end_date"`
+	Status         ReviewCycleStatus `json:"status"`
+	ClosedAt       *time.Time        `json:"closed_at"`
+	CreatedAt      time.Time         `json:"created_at"`
+	ObjectiveCount int               `json:"-"` // For the cycle list
+}
+
+// Appraisal is the formal review of one staff member for one review cycle.
+// The staff member assesses themselves, their supervisor rates each
+// objective and sets a calibrated final rating, then both sign it off:
+// first the supervisor, then the staff member.
+type Appraisal struct {
+	ID                 int
+	UserID             int // Staff member being appraised
+	ReviewCycleID      int
+	SupervisorID       *int // Who reviewed and signed as supervisor
+	SelfAssessment     string
+	SupervisorSummary  string
+	FinalRating        *int // On the configured rating scale
+	StaffSignedAt      *time.Time
+	SupervisorSignedAt *time.Time
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	StaffName          string // For display purposes
+	SupervisorName     string // For display purposes
+	CycleName          string // For display purposes
+}
+
+// Status describes how far the appraisal has progressed
+func (a Appraisal) Status() string {
+	switch {
+	case a.StaffSignedAt != nil:
+		return "Signed off"
+	case a.SupervisorSignedAt != nil:
+		return "Awaiting staff sign-off"
+	}
+	return "In progress"
+}
+
+// AppraisalRating holds the self and supervisor ratings of one objective in
+// an appraisal
+type AppraisalRating struct {
+	AppraisalID       int
+	ObjectiveID       int
+	SelfRating        *int
+	SelfComment       string
+	SupervisorRating  *int
+	SupervisorComment string
+}
+
+// Project represents a project that employees can be assigned to
+type Project struct {
+	ID          int
+	Name        string
+	Description string
+	StartDate   time.Time
+	EndDate     time.Time
+	Status      ProjectStatus
+	ManagerID   *int
+	CreatedAt   time.Time
+	ManagerName string  // For display purposes
+	Progress    float64 // Rolled up from linked objectives and tasks
+}
+
+// ProjectAssignment represents the assignment of an employee to a project
+type ProjectAssignment struct {
+	ID           int
+	ProjectID    int
+	UserID       int
+	Role         string
+	AssignedDate time.Time
+	ProjectName  string // For display purposes
+	UserName     string // For display purposes
+}
+
+// AssignmentAction describes a change to a project assignment
+type AssignmentAction string
+
+const (
+	AssignmentActionAssigned    AssignmentAction = "Assigned"
+	AssignmentActionRoleChanged AssignmentAction = "Role Changed"
+	AssignmentActionRemoved     AssignmentAction = "Removed"
+)
+
+// ProjectAssignmentEvent records a change to who is assigned to a project
+type ProjectAssignmentEvent struct {
+	ID        int
+	ProjectID int
+	UserID    int
+	Action    AssignmentAction
+	Role      string
+	ActorID   *int
+	CreatedAt time.Time
+	UserName  string // For display purposes
+	ActorName string // For display purposes
+}
+
+// Objective represents a performance objective
+type Objective struct {
+	ID          int       `json:"id"`
+	UserID      int       `json:"user_id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	StartDate   time.Time `json:"start_date"`
+	EndDate     time.Time `json:"end_date"`
+	CreatedAt   time.Time `json:"created_at"`
+	Performance float64   `json:"performance"` // Calculated mean percentage
+	// New fields
+	Visibility    ObjectiveVisibility `json:"visibility"`
+	Status        ObjectiveStatus     `json:"status"`
+	Category      ObjectiveCategory   `json:"category"`
+	CategoryOther string              `json:"category_other"`  // For "Other" category specification
+	Weight        float64             `json:"weight"`          // Percentage weight (0-100)
+	ProjectID     *int                `json:"project_id"`      // Optional project this objective contributes to
+	ReviewCycleID *int                `json:"review_cycle_id"` // Optional review cycle the objective is appraised in
+	OwnerName     string              `json:"-"`               // For display purposes
+	CycleName     string              `json:"-"`               // For display purposes
+	Locked        bool                `json:"locked"`          // Set when the review cycle is closed
+	ManualRating  *float64            `json:"manual_rating"`   // Rating used by the manual_rating scoring strategy
+}
+
+// ExpectedOutcome represents an expected outcome for an objective
+type ExpectedOutcome struct {
+	ID          int       `json:"id"`
+	ObjectiveID int       `json:"objective_id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ActivityCategory represents the frequency category of an activity
+type ActivityCategory string
+
+const (
+	CategoryDaily      ActivityCategory = "Daily"
+	CategoryWeekly     ActivityCategory = "Weekly"
+	CategoryMonthly    ActivityCategory = "Monthly"
+	CategoryQuarterly  ActivityCategory = "Quarterly"
+	CategoryBiannually ActivityCategory = "Biannually"
+	CategoryAnnually   ActivityCategory = "Annually"
+)
+
+// TaskPriority represents task priority levels
+type TaskPriority string
+
+const (
+	PriorityLow    TaskPriority = "Low"
+	PriorityMedium TaskPriority = "Medium"
+	PriorityHigh   TaskPriority = "High"
+	PriorityUrgent TaskPriority = "Urgent"
+)
+
+// TaskStatus represents task completion status
+type TaskStatus string
+
+const (
+	TaskStatusPending    TaskStatus = "Pending"
+	TaskStatusInProgress TaskStatus = "In Progress"
+	TaskStatusCompleted  TaskStatus = "Completed"
+	TaskStatusOnHold     TaskStatus = "On Hold"
+)
+
+// TaskType represents the type of task
+type TaskType string
+
+const (
+	TaskTypePersonal        TaskType = "Personal"
+	TaskTypeServiceRequest  TaskType = "Service Request"
+	TaskTypeStaffAssignment TaskType = "Staff Assignment"
+	TaskTypeResponse        TaskType = "Response"
+)
+
+// AssignmentStatus is where a task assigned to another user stands
+type AssignmentStatus string
+
+const (
+	AssignmentNone     AssignmentStatus = "" // Not assigned to anyone
+	AssignmentPending  AssignmentStatus = "Pending"
+	AssignmentAccepted AssignmentStatus = "Accepted"
+	AssignmentDeclined AssignmentStatus = "Declined"
+)
+
+// Task represents a standalone task linked to an expected outcome
+type Task struct {
+	ID                   int              `json:"id"`
+	ExpectedOutcomeID    *int             `json:"expected_outcome_id"` // Links task to an expected outcome
+	UserID               int              `json:"user_id"`
+	AssignedToID         *int             `json:"assigned_to_id"`
+	Title                string           `json:"title"`
+	Description          string           `json:"description"`
+	Priority             TaskPriority     `json:"priority"`
+	Status               TaskStatus       `json:"status"`
+	TaskType             TaskType         `json:"task_type"`
+	RequestedBy          string           `json:"requested_by"`
+	DueDate              time.Time        `json:"due_date"`
+	CreatedAt            time.Time        `json:"created_at"`
+	CompletedAt          *time.Time       `json:"completed_at"`
+	CompletionPercentage float64          `json:"completion_percentage"` // 0-100, indicates how much of the task is completed
+	ProjectID            *int             `json:"project_id"`            // Optional project this task contributes to
+	AssignmentStatus     AssignmentStatus `json:"assignment_status"`     // Set while AssignedToID is
+	AssignmentNote       string           `json:"assignment_note"`       // Reason given when declining or reassigning
+	AssignedToUser       *User            `json:"-"`
+	CreatedByUser        *User            `json:"-"` // The requester, for assigned tasks
+	// For display purposes
+	ObjectiveTitle       string `json:"-"`
+	ExpectedOutcomeTitle string `json:"-"`
+}
+
+// IsAssigned reports whether the task is with someone other than its creator
+func (t *Task) IsAssigned() bool {
+	return t.AssignedToID != nil && t.AssignmentStatus != AssignmentDeclined
+}
+
+// IsAssignedTo reports whether the task is currently with the given user
+func (t *Task) IsAssignedTo(userID int) bool {
+	return t.IsAssigned() && *t.AssignedToID == userID
+}
+
+// WorkerID is the user doing the task: the assignee once accepted, otherwise
+// the creator. Progress on the task counts towards this user's velocity.
+func (t *Task) WorkerID() int {
+	if t.IsAssigned() && t.AssignmentStatus == AssignmentAccepted {
+		return *t.AssignedToID
+	}
+	return t.UserID
+}
+
+// Activity represents a task or activity
+type Activity struct {
+	ID                  int              `json:"id"`
+	ExpectedOutcomeID   int              `json:"expected_outcome_id"`
+	Title               string           `json:"title"`
+	Description         string           `json:"description"`
+	Category            ActivityCategory `json:"category"`
+	ProgressPercentage  float64          `json:"progress_percentage"`
+	ImplementationLevel string           `json:"implementation_level"`
+	CreatedAt           time.Time        `json:"created_at"`
+	UpdatedAt           time.Time        `json:"updated_at"`
+}
+
+type ObjectiveWithOutcomes struct {
+	Objective        Objective
+	ExpectedOutcomes []ExpectedOutcomeWithActivities
+}
+
+type ExpectedOutcomeWithActivities struct {
+	ExpectedOutcome ExpectedOutcome
+	Activities      []Activity
+	Tasks           []Task // Tasks linked to this expected outcome
+}
+
+// Comment represents a comment on an objective or activity
+type Comment struct {
+	ID          int
+	ObjectiveID *int
+	ActivityID  *int
+	UserID      int
+	CommentText string
+	CreatedAt   time.Time
+	Username    string // For displaying commenter name
+	UserRole    string // For displaying commenter role
+}
+
+// TokenScope limits what a personal access token may do
+type TokenScope string
+
+const (
+	TokenScopeRead  TokenScope = "read" // GET and HEAD requests only
+	TokenScopeWrite TokenScope = "write"
+)
+
+// APIToken is a personal access token for scripts and integrations. Only a
+// hash of the token is stored; Prefix is kept so users can recognise it.
+type APIToken struct {
+	ID         int
+	UserID     int
+	Name       string
+	Prefix     string
+	Scope      TokenScope
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+	Username   string // For the admin token list
+}
+
+// Expired reports whether the token is past its expiry time
+func (t *APIToken) Expired() bool {
+	return t.ExpiresAt != nil && !time.Now().Before(*t.ExpiresAt)
+}
+
+// Allows reports whether the token's scope permits a request method
+func (t *APIToken) Allows(method string) bool {
+	switch t.Scope {
+	case TokenScopeWrite:
+		return true
+	case TokenScopeRead:
+		return method == http.MethodGet || method == http.MethodHead
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package store
+
+import "time"
+
+// NotificationKind is the kind of event a user is notified about. Users can
+// turn each kind off on the notification preferences page.
+type NotificationKind string
+
+const (
+	NotifyComment       NotificationKind = "comment"        // A supervisor commented on one of your objectives
+	NotifyTaskAssigned  NotificationKind = "task_assigned"  // A task was assigned to you
+	NotifyTaskStatus    NotificationKind = "task_status"    // A task you created or work on changed status
+	NotifyTaskOverdue   NotificationKind = "task_overdue"   // A task you work on is past its due date
+	NotifyTaskDueSoon   NotificationKind = "task_due_soon"  // A task you work on is due within dueSoonDays
+	NotifyCycleDeadline NotificationKind = "cycle_deadline" // Your review cycle ends within cycleDeadlineDays
+)
+
+// NotificationKinds lists every kind, in the order of the preferences page
+var NotificationKinds = []NotificationKind{NotifyComment, NotifyTaskAssigned, NotifyTaskStatus, NotifyTaskDueSoon, NotifyTaskOverdue, NotifyCycleDeadline}
+
+// Label describes the kind on the preferences page
+func (k NotificationKind) Label() string {
+	switch k {
+	case NotifyComment:
+		return "Comments on my objectives"
+	case NotifyTaskAssigned:
+		return "Tasks assigned to me"
+	case NotifyTaskStatus:
+		return "Status changes on tasks I created or work on"
+	case NotifyTaskDueSoon:
+		return "Tasks due in the next few days"
+	case NotifyTaskOverdue:
+		return "Overdue tasks"
+	case NotifyCycleDeadline:
+		return "Review cycles about to end"
+	}
+	return string(k)
+}
+
+// Notification is a message in a user's inbox. EntityID is the objective,
+// task or review cycle it is about.
+type Notification struct {
+	ID        int
+	UserID    int
+	ActorID   *int // Nil for notifications from the system
+	Kind      NotificationKind
+	EntityID  int
+	Message   string
+	Link      string // Page to open when the notification is clicked
+	ReadAt    *time.Time
+	CreatedAt time.Time
+}
+
+// IsRead reports whether the user has seen the notification
+func (n Notification) IsRead() bool {
+	return n.ReadAt != nil
+}
@@ -0,0 +1,84 @@
+package store
+
+import "staffperformance/internal/scoring"
+
+// scoreSource reads what objective scores are computed from
+type scoreSource interface {
+	GetActivitiesByObjective(objectiveID int) ([]Activity, error)
+	GetTasksByObjective(objectiveID int) ([]Task, error)
+}
+
+// objectivePerformance scores an objective with the store's strategy from its
+// activities, the tasks linked to its expected outcomes and its manual rating
+func (s *SQLStore) objectivePerformance(src scoreSource, obj *Objective) (float64, error) {
+	activities, err := src.GetActivitiesByObjective(obj.ID)
+	if err != nil {
+		return 0, err
+	}
+	tasks, err := src.GetTasksByObjective(obj.ID)
+	if err != nil {
+		return 0, err
+	}
+	return s.scoring.Score(scoringInput(obj, activities, tasks)), nil
+}
+
+// scoreObjectives sets the Performance of each objective. Call it after the
+// rows the objectives were read from are closed.
+func (s *SQLStore) scoreObjectives(src scoreSource, objectives []Objective) error {
+	for i := range objectives {
+		performance, err := s.objectivePerformance(src, &objectives[i])
+		if err != nil {
+			return err
+		}
+		objectives[i].Performance = performance
+	}
+	return nil
+}
+
+// scoringInput groups an objective's activities and tasks by expected outcome
+func scoringInput(obj *Objective, activities []Activity, tasks []Task) scoring.Objective {
+	return ScoringInputWith(obj, activities, tasks, func(t Task) (float64, bool) {
+		return TaskProgress(t), true
+	})
+}
+
+// ScoringInputWith is scoringInput with the progress of each task taken from
+// progress, which also reports whether the task counts at all
+func ScoringInputWith(obj *Objective, activities []Activity, tasks []Task, progress func(Task) (float64, bool)) scoring.Objective {
+	input := scoring.Objective{ManualRating: obj.ManualRating}
+	index := make(map[int]int)
+	outcome := func(id int) *scoring.Outcome {
+		i, ok := index[id]
+		if !ok {
+			i = len(input.Outcomes)
+			index[id] = i
+			input.Outcomes = append(input.Outcomes, scoring.Outcome{})
+		}
+		return &input.Outcomes[i]
+	}
+
+	for _, a := range activities {
+		o := outcome(a.ExpectedOutcomeID)
+		o.Activities = append(o.Activities, a.ProgressPercentage)
+	}
+	for _, t := range tasks {
+		if t.ExpectedOutcomeID == nil {
+			continue
+		}
+		p, ok := progress(t)
+		if !ok {
+			continue
+		}
+		o := outcome(*t.ExpectedOutcomeID)
+		o.Tasks = append(o.Tasks, p)
+	}
+	return input
+}
+
+// TaskProgress is a task's completion percentage; completed tasks count as 100
+func TaskProgress(t Task) float64 {
+	if t.Status == TaskStatusCompleted {
+		return 100
+	}
+	return t.CompletionPercentage
+}
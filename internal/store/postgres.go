@@ -0,0 +1,457 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"staffperformance/internal/scoring"
+)
+
+// OpenPostgresStore connects to the PostgreSQL database at url, a
+// postgres:// URL or key=value connection string, without changing its
+// schema. Objectives read from it are scored with strategy.
+func OpenPostgresStore(url string, strategy scoring.Strategy) (*SQLStore, error) {
+	connector, err := pq.NewConnector(url)
+	if err != nil {
+		return nil, err
+	}
+	db := sql.OpenDB(placeholderConnector{connector})
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLStore{db: db, dialect: DialectPostgres, scoring: strategy}, nil
+}
+
+// placeholderConnector makes PostgreSQL connections that accept the ?
+// placeholders the store's queries are written with
+type placeholderConnector struct {
+	driver.Connector
+}
+
+func (c placeholderConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return placeholderConn{conn}, nil
+}
+
+// placeholderConn numbers the placeholders of every statement before passing
+// it to the PostgreSQL connection
+type placeholderConn struct {
+	driver.Conn
+}
+
+func (c placeholderConn) Prepare(query string) (driver.Stmt, error) {
+	return c.Conn.Prepare(numberPlaceholders(query))
+}
+
+func (c placeholderConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, numberPlaceholders(query))
+	}
+	return c.Prepare(query)
+}
+
+func (c placeholderConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if q, ok := c.Conn.(driver.QueryerContext); ok {
+		return q.QueryContext(ctx, numberPlaceholders(query), args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c placeholderConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if e, ok := c.Conn.(driver.ExecerContext); ok {
+		return e.ExecContext(ctx, numberPlaceholders(query), args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c placeholderConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c placeholderConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c placeholderConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// numberPlaceholders rewrites ? placeholders as $1, $2, ... Question marks in
+// quoted strings and identifiers are left alone.
+func numberPlaceholders(query string) string {
+	if !strings.Contains(query, "?") {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	var quote rune
+	for _, r := range query {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '?':
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// postgresMigrations lists the PostgreSQL schema changes. A new database gets
+// the whole schema as of SQLite migration 15 in one step, which stands in for
+// SQLite migrations 1 to 15 and, like the SQLite initial schema, has no Down.
+// Later changes are added here with the same version as their SQLite migration.
+var postgresMigrations = []Migration{
+	{
+		Version: 15,
+		Name:    "postgres_schema",
+		Up:      migratePostgresSchema,
+	},
+	{
+		Version: 16,
+		Name:    "hierarchy_indexes",
+		Up:      migrateHierarchyIndexes,
+		Down:    rollbackHierarchyIndexes,
+	},
+	{
+		Version: 17,
+		Name:    "login_security",
+		Up: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`
+			ALTER TABLE users ADD COLUMN failed_logins INTEGER NOT NULL DEFAULT 0;
+			ALTER TABLE users ADD COLUMN locked_until TIMESTAMPTZ;
+
+			CREATE TABLE login_events (
+				id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+				user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+				username TEXT NOT NULL,
+				ip TEXT NOT NULL,
+				user_agent TEXT NOT NULL DEFAULT '',
+				success BOOLEAN NOT NULL,
+				created_at TIMESTAMPTZ NOT NULL
+			);
+
+			CREATE INDEX idx_login_events_user ON login_events(user_id, created_at);
+			CREATE INDEX idx_login_events_username ON login_events(username, created_at);
+			CREATE INDEX idx_login_events_ip ON login_events(ip, created_at)`)
+			return err
+		},
+		Down: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`
+			DROP TABLE IF EXISTS login_events;
+			ALTER TABLE users DROP COLUMN IF EXISTS locked_until;
+			ALTER TABLE users DROP COLUMN IF EXISTS failed_logins`)
+			return err
+		},
+	},
+	{
+		Version: 18,
+		Name:    "login_failures",
+		Up: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`ALTER TABLE login_events ADD COLUMN failure TEXT NOT NULL DEFAULT ''`)
+			return err
+		},
+		Down: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`ALTER TABLE login_events DROP COLUMN IF EXISTS failure`)
+			return err
+		},
+	},
+}
+
+// migratePostgresSchema creates the tables of the SQLite schema with
+// PostgreSQL types: identity columns for AUTOINCREMENT, TIMESTAMPTZ for
+// DATETIME and BOOLEAN for flags
+func migratePostgresSchema(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+	CREATE TABLE departments (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		name TEXT UNIQUE NOT NULL,
+		head_id INTEGER,
+		description TEXT,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE users (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		username TEXT UNIQUE NOT NULL,
+		password TEXT NOT NULL,
+		full_name TEXT NOT NULL,
+		email TEXT,
+		role TEXT NOT NULL DEFAULT 'Staff',
+		supervisor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+		department_id INTEGER REFERENCES departments(id) ON DELETE SET NULL,
+		department TEXT,
+		position TEXT,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		must_change_password BOOLEAN NOT NULL DEFAULT FALSE
+	);
+
+	ALTER TABLE departments ADD FOREIGN KEY (head_id) REFERENCES users(id) ON DELETE SET NULL;
+
+	CREATE TABLE projects (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		name TEXT NOT NULL,
+		description TEXT,
+		start_date TIMESTAMPTZ,
+		end_date TIMESTAMPTZ,
+		status TEXT NOT NULL DEFAULT 'Active',
+		manager_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE project_assignments (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		role TEXT,
+		assigned_date TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (project_id, user_id)
+	);
+
+	CREATE TABLE project_assignment_events (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		action TEXT NOT NULL,
+		role TEXT,
+		actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE review_cycles (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE,
+		start_date DATE NOT NULL,
+		end_date DATE NOT NULL,
+		status TEXT NOT NULL DEFAULT 'Planned',
+		closed_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE objectives (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		title TEXT NOT NULL,
+		description TEXT,
+		start_date TIMESTAMPTZ,
+		end_date TIMESTAMPTZ,
+		visibility TEXT NOT NULL DEFAULT 'Public',
+		status TEXT NOT NULL DEFAULT 'Not Started',
+		category TEXT NOT NULL DEFAULT 'Other',
+		category_other TEXT,
+		weight DOUBLE PRECISION DEFAULT 0,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		project_id INTEGER REFERENCES projects(id) ON DELETE SET NULL,
+		review_cycle_id INTEGER REFERENCES review_cycles(id) ON DELETE SET NULL,
+		manual_rating DOUBLE PRECISION
+	);
+	CREATE INDEX idx_objectives_review_cycle ON objectives(review_cycle_id);
+
+	CREATE TABLE expected_outcomes (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		objective_id INTEGER NOT NULL REFERENCES objectives(id) ON DELETE CASCADE,
+		title TEXT NOT NULL,
+		description TEXT,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE activities (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		expected_outcome_id INTEGER NOT NULL REFERENCES expected_outcomes(id) ON DELETE CASCADE,
+		title TEXT NOT NULL,
+		description TEXT,
+		category TEXT NOT NULL,
+		progress_percentage DOUBLE PRECISION DEFAULT 0,
+		implementation_level TEXT,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE tasks (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		expected_outcome_id INTEGER REFERENCES expected_outcomes(id) ON DELETE CASCADE,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		assigned_to_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+		title TEXT NOT NULL,
+		description TEXT,
+		priority TEXT NOT NULL,
+		status TEXT NOT NULL,
+		task_type TEXT NOT NULL DEFAULT 'Personal',
+		requested_by TEXT,
+		due_date TIMESTAMPTZ,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		completed_at TIMESTAMPTZ,
+		completion_percentage DOUBLE PRECISION DEFAULT 0,
+		project_id INTEGER REFERENCES projects(id) ON DELETE SET NULL,
+		assignment_status TEXT NOT NULL DEFAULT '',
+		assignment_note TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE TABLE comments (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		objective_id INTEGER REFERENCES objectives(id) ON DELETE CASCADE,
+		activity_id INTEGER REFERENCES activities(id) ON DELETE CASCADE,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		comment_text TEXT NOT NULL,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX idx_comments_objective ON comments(objective_id);
+	CREATE INDEX idx_comments_activity ON comments(activity_id);
+
+	CREATE TABLE api_tokens (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		name TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		prefix TEXT NOT NULL,
+		scope TEXT NOT NULL DEFAULT 'read',
+		expires_at TIMESTAMPTZ,
+		last_used_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX idx_api_tokens_user ON api_tokens(user_id);
+
+	CREATE TABLE appraisals (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		review_cycle_id INTEGER NOT NULL REFERENCES review_cycles(id) ON DELETE CASCADE,
+		supervisor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+		self_assessment TEXT NOT NULL DEFAULT '',
+		supervisor_summary TEXT NOT NULL DEFAULT '',
+		final_rating INTEGER,
+		staff_signed_at TIMESTAMPTZ,
+		supervisor_signed_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (user_id, review_cycle_id)
+	);
+
+	CREATE TABLE appraisal_ratings (
+		appraisal_id INTEGER NOT NULL REFERENCES appraisals(id) ON DELETE CASCADE,
+		objective_id INTEGER NOT NULL REFERENCES objectives(id) ON DELETE CASCADE,
+		self_rating INTEGER,
+		self_comment TEXT NOT NULL DEFAULT '',
+		supervisor_rating INTEGER,
+		supervisor_comment TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (appraisal_id, objective_id)
+	);
+
+	CREATE TABLE audit_events (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+		entity_type TEXT NOT NULL,
+		entity_id INTEGER NOT NULL,
+		owner_id INTEGER NOT NULL,
+		label TEXT NOT NULL DEFAULT '',
+		action TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL
+	);
+	CREATE INDEX idx_audit_events_entity ON audit_events(entity_type, entity_id);
+	CREATE INDEX idx_audit_events_created ON audit_events(created_at);
+
+	CREATE TABLE audit_changes (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		event_id INTEGER NOT NULL REFERENCES audit_events(id) ON DELETE CASCADE,
+		field TEXT NOT NULL,
+		old_value TEXT NOT NULL DEFAULT '',
+		new_value TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX idx_audit_changes_event ON audit_changes(event_id);
+
+	CREATE TABLE progress_snapshots (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		entity_type TEXT NOT NULL,
+		entity_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		progress DOUBLE PRECISION NOT NULL,
+		recorded_at TIMESTAMPTZ NOT NULL
+	);
+	CREATE INDEX idx_progress_snapshots_entity ON progress_snapshots(entity_type, entity_id);
+	CREATE INDEX idx_progress_snapshots_user ON progress_snapshots(user_id);
+
+	CREATE TABLE notifications (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+		kind TEXT NOT NULL,
+		entity_id INTEGER NOT NULL,
+		message TEXT NOT NULL,
+		link TEXT NOT NULL DEFAULT '',
+		read_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ NOT NULL
+	);
+	CREATE INDEX idx_notifications_user ON notifications(user_id, read_at);
+
+	CREATE TABLE notification_preferences (
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		kind TEXT NOT NULL,
+		enabled BOOLEAN NOT NULL,
+		PRIMARY KEY (user_id, kind)
+	);
+
+	CREATE TABLE email_outbox (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+		to_address TEXT NOT NULL,
+		subject TEXT NOT NULL,
+		body TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		next_attempt_at TIMESTAMPTZ NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL,
+		sent_at TIMESTAMPTZ
+	);
+	CREATE INDEX idx_email_outbox_due ON email_outbox(status, next_attempt_at);
+
+	CREATE TABLE email_preferences (
+		user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+		frequency TEXT NOT NULL,
+		last_digest_at TIMESTAMPTZ
+	);
+
+	CREATE TABLE job_runs (
+		id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		job TEXT NOT NULL,
+		trigger TEXT NOT NULL,
+		started_at TIMESTAMPTZ NOT NULL,
+		finished_at TIMESTAMPTZ,
+		status TEXT NOT NULL,
+		message TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX idx_job_runs_job ON job_runs(job, started_at);
+
+	CREATE TABLE recurring_tasks (
+		activity_id INTEGER NOT NULL REFERENCES activities(id) ON DELETE CASCADE,
+		period_start TIMESTAMPTZ NOT NULL,
+		task_id INTEGER REFERENCES tasks(id) ON DELETE SET NULL,
+		created_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (activity_id, period_start)
+	);
+	`)
+	return err
+}
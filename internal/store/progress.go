@@ -0,0 +1,14 @@
+package store
+
+import "time"
+
+// ProgressSnapshot is the progress of an activity or task from the moment it
+// was recorded until the next snapshot of the same record. Tasks count as 100
+// once completed, as they do for scoring.
+type ProgressSnapshot struct {
+	Entity     AuditEntity // AuditActivity or AuditTask
+	EntityID   int
+	UserID     int // Staff member doing the work: the owner, or the assignee of an accepted task
+	Progress   float64
+	RecordedAt time.Time
+}
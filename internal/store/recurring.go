@@ -0,0 +1,9 @@
+package store
+
+// RecurringActivity is an activity that may need a task this period, with
+// the owner of its objective
+type RecurringActivity struct {
+	Activity
+	UserID    int
+	ProjectID *int
+}
@@ -0,0 +1,248 @@
+// Package store keeps the application's data. Store is everything the web
+// application reads and writes; SQLStore implements it for SQLite and
+// PostgreSQL, with the schema managed by versioned migrations.
+package store
+
+import "time"
+
+// Dialect names a supported database backend
+type Dialect string
+
+const (
+	DialectSQLite   Dialect = "sqlite"
+	DialectPostgres Dialect = "postgres"
+)
+
+// Dialects lists the backends accepted by the db_driver setting
+var Dialects = []string{string(DialectSQLite), string(DialectPostgres)}
+
+// Store is all the data the application keeps. SQLStore implements it for
+// SQLite and PostgreSQL; both must pass the conformance tests in store_test.go.
+type Store interface {
+	UserStore
+	DepartmentStore
+	ObjectiveStore
+	TaskStore
+	CommentStore
+	ProjectStore
+	ReviewCycleStore
+	AppraisalStore
+	APITokenStore
+	AuditStore
+	NotificationStore
+	EmailStore
+	JobStore
+	LoginStore
+	Migrator
+
+	// Init applies pending migrations
+	Init() error
+	Close() error
+}
+
+// UserStore keeps user accounts
+type UserStore interface {
+	GetUserByUsername(username string) (*User, error)
+	GetUserByID(id int) (*User, error)
+	CreateUser(user *User) error
+	UpdateUser(user *User) error
+	UpdateUserPassword(userID int, hash string, mustChange bool) error
+	DeleteUser(id int) error
+	GetAllUsers() ([]User, error)
+	GetUsersByRole(role UserRole) ([]User, error)
+	GetStaffBySupervisor(supervisorID int) ([]User, error)
+}
+
+// DepartmentStore keeps departments and their members
+type DepartmentStore interface {
+	CreateDepartment(dept *Department) error
+	GetDepartmentByID(id int) (*Department, error)
+	GetAllDepartments() ([]Department, error)
+	UpdateDepartment(dept *Department) error
+	DeleteDepartment(id int) error
+	GetUsersByDepartment(departmentID int) ([]User, error)
+	SetUserDepartment(userID int, departmentID *int) error
+}
+
+// ObjectiveStore keeps objectives with their expected outcomes and
+// activities. Changes are recorded in the audit trail against actorID.
+type ObjectiveStore interface {
+	CreateObjective(obj *Objective, actorID int) error
+	GetObjectivesByUserID(userID int) ([]Objective, error)
+	GetObjectivesByUserIDInCycle(userID, cycleID int) ([]Objective, error)
+	GetObjectiveByID(id int) (*Objective, error)
+	UpdateObjective(obj *Objective, actorID int) error
+	DeleteObjective(id int, actorID int) error
+
+	// Whole hierarchies with their expected outcomes, activities and tasks,
+	// read in the same few queries however many objectives they hold
+	GetObjectivesWithOutcomes(userID int) ([]ObjectiveWithOutcomes, error)
+	GetObjectivesWithOutcomesInCycle(userID, cycleID int) ([]ObjectiveWithOutcomes, error)
+	GetTeamObjectivesWithOutcomes(userIDs []int) (map[int][]ObjectiveWithOutcomes, error)
+	GetTeamObjectivesWithOutcomesInCycle(userIDs []int, cycleID int) (map[int][]ObjectiveWithOutcomes, error)
+
+	CreateExpectedOutcome(outcome *ExpectedOutcome, actorID int) error
+	GetExpectedOutcomesByObjectiveID(objectiveID int) ([]ExpectedOutcome, error)
+	GetExpectedOutcomeByID(id int) (*ExpectedOutcome, error)
+	UpdateExpectedOutcome(outcome *ExpectedOutcome, actorID int) error
+	DeleteExpectedOutcome(id int, actorID int) error
+
+	CreateActivity(activity *Activity, actorID int) error
+	GetActivitiesByExpectedOutcomeID(outcomeID int) ([]Activity, error)
+	GetActivitiesByObjectiveID(objectiveID int) ([]Activity, error)
+	GetActivitiesByObjective(objectiveID int) ([]Activity, error)
+	GetActivityByID(id int) (*Activity, error)
+	UpdateActivity(activity *Activity, actorID int) error
+	DeleteActivity(id int, actorID int) error
+
+	GetObjectiveProgressSnapshots(objectiveID int) ([]ProgressSnapshot, error)
+	GetUserProgressSnapshots(userID int) ([]ProgressSnapshot, error)
+}
+
+// TaskStore keeps tasks. Changes are recorded in the audit trail against actorID.
+type TaskStore interface {
+	CreateTask(task *Task, actorID int) error
+	GetTasksByUserID(userID int) ([]Task, error)
+	GetTasksByUserIDs(userIDs []int) (map[int][]Task, error)
+	GetTaskByID(id int) (*Task, error)
+	UpdateTask(task *Task, actorID int) error
+	DeleteTask(id int, actorID int) error
+	GetTaskCountsByStatus(userID int) (completed, pending int, err error)
+	GetTasksAssignedToUser(userID int) ([]Task, error)
+	GetAllUserTasks(userID int) ([]Task, error)
+	GetTasksByExpectedOutcome(expectedOutcomeID int) ([]Task, error)
+	GetTasksByObjective(objectiveID int) ([]Task, error)
+	GetOpenTasksDueBetween(from, to time.Time) ([]Task, error)
+}
+
+// CommentStore keeps supervisor comments on objectives and activities
+type CommentStore interface {
+	CreateComment(comment *Comment) error
+	GetCommentsByObjective(objectiveID int) ([]Comment, error)
+	GetObjectiveCommentsByUserID(userID int) (map[int][]Comment, error)
+	GetCommentsByActivity(activityID int) ([]Comment, error)
+	GetCommentByID(id int) (*Comment, error)
+	DeleteComment(id int) error
+}
+
+// ProjectStore keeps projects and who is assigned to them
+type ProjectStore interface {
+	CreateProject(proj *Project) error
+	GetProjectByID(id int) (*Project, error)
+	GetAllProjects() ([]Project, error)
+	GetProjectsForUser(userID int) ([]Project, error)
+	UpdateProject(proj *Project) error
+	DeleteProject(id int) error
+	AssignUserToProject(projectID, userID int, role string, actorID int) error
+	GetProjectAssignments(projectID int) ([]ProjectAssignment, error)
+	GetUserProjects(userID int) ([]ProjectAssignment, error)
+	IsUserAssignedToProject(projectID, userID int) (bool, error)
+	RemoveUserFromProject(projectID, userID int, actorID int) error
+	GetProjectAssignmentHistory(projectID int) ([]ProjectAssignmentEvent, error)
+	GetObjectivesByProject(projectID int) ([]Objective, error)
+	GetTasksByProject(projectID int) ([]Task, error)
+}
+
+// ReviewCycleStore keeps review cycles
+type ReviewCycleStore interface {
+	CreateReviewCycle(cycle *ReviewCycle) error
+	GetReviewCycleByID(id int) (*ReviewCycle, error)
+	GetAllReviewCycles() ([]ReviewCycle, error)
+	UpdateReviewCycle(cycle *ReviewCycle) error
+	SetReviewCycleStatus(id int, status ReviewCycleStatus) error
+	DeleteReviewCycle(id int) error
+}
+
+// AppraisalStore keeps appraisals and their ratings
+type AppraisalStore interface {
+	CreateAppraisal(a *Appraisal) error
+	GetAppraisal(userID, cycleID int) (*Appraisal, error)
+	GetAppraisalsByUserID(userID int) ([]Appraisal, error)
+	GetAppraisalsBySupervisor(supervisorID int) ([]Appraisal, error)
+	GetAllAppraisals(excludeUserID int) ([]Appraisal, error)
+	GetAppraisalRatings(appraisalID int) ([]AppraisalRating, error)
+	SaveSelfAssessment(a *Appraisal, ratings []AppraisalRating) error
+	SaveSupervisorReview(a *Appraisal, ratings []AppraisalRating) error
+	SignAppraisalAsSupervisor(id, supervisorID int) error
+	SignAppraisalAsStaff(id int) error
+	GetUsersWithOpenAppraisal(cycleID int) ([]int, error)
+}
+
+// APITokenStore keeps API tokens by the hash of their secret
+type APITokenStore interface {
+	CreateAPIToken(token *APIToken, tokenHash string) error
+	GetAPITokenByHash(tokenHash string) (*APIToken, error)
+	GetAPITokenByID(id int) (*APIToken, error)
+	GetAPITokensByUserID(userID int) ([]APIToken, error)
+	GetAllAPITokens() ([]APIToken, error)
+	TouchAPIToken(id int, usedAt time.Time) error
+	DeleteAPIToken(id int) error
+}
+
+// AuditStore reads the audit trail written by the other stores
+type AuditStore interface {
+	CountAuditEvents(f AuditFilter) (int, error)
+	GetAuditEvents(f AuditFilter) ([]AuditEvent, error)
+}
+
+// NotificationStore keeps in-app notifications and the kinds users turned off
+type NotificationStore interface {
+	CreateNotification(n *Notification) error
+	GetNotifications(userID int, unreadOnly bool, limit int) ([]Notification, error)
+	GetNotificationsSince(userID int, since time.Time) ([]Notification, error)
+	GetNotificationByID(id int) (*Notification, error)
+	CountUnreadNotifications(userID int) (int, error)
+	HasNotificationSince(userID int, kind NotificationKind, entityID int, since time.Time) (bool, error)
+	MarkNotificationRead(id, userID int) error
+	MarkAllNotificationsRead(userID int) error
+	GetNotificationPreferences(userID int) (map[NotificationKind]bool, error)
+	SaveNotificationPreferences(userID int, prefs map[NotificationKind]bool) error
+}
+
+// EmailStore keeps email preferences and the outbox of email to deliver
+type EmailStore interface {
+	GetEmailPreference(userID int) (*EmailPreference, error)
+	SaveEmailFrequency(userID int, frequency EmailFrequency) error
+	GetDigestSubscribers() ([]EmailPreference, error)
+	SetLastDigestAt(userID int, at time.Time) error
+	QueueEmail(e *OutboxEmail) error
+	GetDueOutboxEmails(now time.Time, limit int) ([]OutboxEmail, error)
+	GetOutboxEmails(limit int) ([]OutboxEmail, error)
+	MarkEmailSent(id int, at time.Time) error
+	MarkEmailAttempt(e *OutboxEmail) error
+	RetryEmail(id int) error
+}
+
+// JobStore keeps the history of scheduled job runs and the periods of
+// recurring activities that already have a task
+type JobStore interface {
+	CreateJobRun(run *JobRun) error
+	FinishJobRun(run *JobRun) error
+	GetLastJobRun(job string) (*JobRun, error)
+	GetJobRuns(limit int) ([]JobRun, error)
+	GetJobRunsSince(job string, since time.Time) ([]JobRun, error)
+	DeleteJobRunsBefore(t time.Time) (int, error)
+	GetRecurringActivities(now time.Time) ([]RecurringActivity, error)
+	ClaimRecurringPeriod(activityID int, periodStart time.Time) (bool, error)
+	ReleaseRecurringPeriod(activityID int, periodStart time.Time) error
+	SetRecurringTask(activityID int, periodStart time.Time, taskID int) error
+}
+
+// LoginStore keeps the history of sign-in attempts and each account's count
+// of consecutive failures, which locks it when it grows too large
+type LoginStore interface {
+	CreateLoginEvent(e *LoginEvent) error
+	GetLoginEventsByUserID(userID, limit int) ([]LoginEvent, error)
+	GetRecentLoginEvents(username, ip string, since time.Time) ([]LoginEvent, error)
+	DeleteLoginEventsBefore(t time.Time) (int, error)
+	IncrementFailedLogins(userID int) (int, error)
+	LockUser(userID int, until time.Time) error
+	UnlockUser(userID int) error
+}
+
+// Migrator applies and rolls back schema migrations
+type Migrator interface {
+	GetMigrationStatus() ([]MigrationStatus, error)
+	MigrateUp() (int, error)
+	MigrateDown(steps int) (int, error)
+}
@@ -0,0 +1,539 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"staffperformance/internal/scoring"
+)
+
+// The conformance suite every Store must pass. It runs against a new SQLite
+// file, and against PostgreSQL when SP_TEST_POSTGRES_URL names a database;
+// that database's public schema is dropped and recreated, so use a throwaway one:
+//
+//	SP_TEST_POSTGRES_URL=postgres://localhost/staffperformance_test?sslmode=disable go test -run Store
+
+func TestSQLiteStore(t *testing.T) {
+	runStoreConformance(t, func(t *testing.T) Store {
+		s, err := OpenSQLiteStore(filepath.Join(t.TempDir(), "test.db"), scoring.TaskActivityMean{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	})
+}
+
+func TestPostgresStore(t *testing.T) {
+	url := os.Getenv("SP_TEST_POSTGRES_URL")
+	if url == "" {
+		t.Skip("SP_TEST_POSTGRES_URL is not set")
+	}
+	runStoreConformance(t, func(t *testing.T) Store {
+		s, err := OpenPostgresStore(url, scoring.TaskActivityMean{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.db.Exec(`DROP SCHEMA public CASCADE; CREATE SCHEMA public`); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	})
+}
+
+// runStoreConformance runs each case on a newly initialised store
+func runStoreConformance(t *testing.T, open func(t *testing.T) Store) {
+	cases := []struct {
+		name string
+		test func(t *testing.T, s Store)
+	}{
+		{"Migrations", testStoreMigrations},
+		{"Users", testStoreUsers},
+		{"ObjectiveHierarchy", testStoreObjectiveHierarchy},
+		{"TeamHierarchy", testStoreTeamHierarchy},
+		{"Tasks", testStoreTasks},
+		{"Comments", testStoreComments},
+		{"ReviewCycles", testStoreReviewCycles},
+		{"Notifications", testStoreNotifications},
+		{"RecurringPeriods", testStoreRecurringPeriods},
+		{"Logins", testStoreLogins},
+		{"JobRuns", testStoreJobRuns},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := open(t)
+			t.Cleanup(func() { s.Close() })
+			if err := s.Init(); err != nil {
+				t.Fatal(err)
+			}
+			c.test(t, s)
+		})
+	}
+}
+
+func testStoreMigrations(t *testing.T, s Store) {
+	statuses, err := s.GetMigrationStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range statuses {
+		if m.AppliedAt == nil {
+			t.Errorf("migration %d (%s) not applied", m.Version, m.Name)
+		}
+	}
+	if n, err := s.MigrateUp(); err != nil || n != 0 {
+		t.Errorf("MigrateUp on a current schema = %d, %v; want 0, nil", n, err)
+	}
+
+	// The latest migration can be rolled back and applied again
+	if n, err := s.MigrateDown(1); err != nil || n != 1 {
+		t.Fatalf("MigrateDown(1) = %d, %v; want 1, nil", n, err)
+	}
+	if n, err := s.MigrateUp(); err != nil || n != 1 {
+		t.Errorf("MigrateUp after rollback = %d, %v; want 1, nil", n, err)
+	}
+
+	// Rolling back past the base schema is refused before anything is undone
+	if n, err := s.MigrateDown(len(statuses)); err == nil || n != 0 {
+		t.Errorf("MigrateDown(%d) = %d, %v; want 0 and an error", len(statuses), n, err)
+	}
+	if statuses, err = s.GetMigrationStatus(); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range statuses {
+		if m.AppliedAt == nil {
+			t.Errorf("migration %d (%s) rolled back by a refused rollback", m.Version, m.Name)
+		}
+	}
+}
+
+// createTestUser adds a user with the given role and optional supervisor
+func createTestUser(t testing.TB, s Store, username string, role UserRole, supervisorID *int) *User {
+	t.Helper()
+	u := &User{Username: username, Password: "password-hash", FullName: username + " Name", Email: username + "@example.com", Role: role, SupervisorID: supervisorID}
+	if err := s.CreateUser(u); err != nil {
+		t.Fatal(err)
+	}
+	if u.ID == 0 {
+		t.Fatal("CreateUser did not set the ID")
+	}
+	return u
+}
+
+func testStoreUsers(t *testing.T, s Store) {
+	boss := createTestUser(t, s, "boss", RoleSupervisor, nil)
+	staff := createTestUser(t, s, "staff", RoleStaff, &boss.ID)
+
+	got, err := s.GetUserByID(staff.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Username != "staff" || got.SupervisorID == nil || *got.SupervisorID != boss.ID {
+		t.Errorf("GetUserByID = %+v", got)
+	}
+	if got.Password != "password-hash" {
+		t.Errorf("stored password = %q", got.Password)
+	}
+
+	reports, err := s.GetStaffBySupervisor(boss.ID)
+	if err != nil || len(reports) != 1 || reports[0].ID != staff.ID {
+		t.Errorf("GetStaffBySupervisor = %v, %v", reports, err)
+	}
+	supervisors, err := s.GetUsersByRole(RoleSupervisor)
+	if err != nil || len(supervisors) != 1 || supervisors[0].ID != boss.ID {
+		t.Errorf("GetUsersByRole = %v, %v", supervisors, err)
+	}
+
+	dept := &Department{Name: "Finance"}
+	if err := s.CreateDepartment(dept); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetUserDepartment(staff.ID, &dept.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.GetUserByID(staff.ID); got.DepartmentName != "Finance" {
+		t.Errorf("department name = %q, want Finance", got.DepartmentName)
+	}
+
+	if err := s.UpdateUserPassword(staff.ID, "hash", true); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.GetUserByID(staff.ID); got.Password != "hash" || !got.MustChangePassword {
+		t.Errorf("after UpdateUserPassword: password %q, must change %v", got.Password, got.MustChangePassword)
+	}
+
+	if err := s.DeleteUser(staff.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetUserByID(staff.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUserByID after delete: %v, want sql.ErrNoRows", err)
+	}
+}
+
+func testStoreObjectiveHierarchy(t *testing.T, s Store) {
+	owner := createTestUser(t, s, "owner", RoleStaff, nil)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	obj := &Objective{UserID: owner.ID, Title: "Grow revenue", StartDate: start, EndDate: start.AddDate(0, 6, 0),
+		Visibility: VisibilityPublic, Status: StatusOnTrack, Category: CategoryFinancial, Weight: 40}
+	if err := s.CreateObjective(obj, owner.ID); err != nil {
+		t.Fatal(err)
+	}
+	outcome := &ExpectedOutcome{ObjectiveID: obj.ID, Title: "New customers"}
+	if err := s.CreateExpectedOutcome(outcome, owner.ID); err != nil {
+		t.Fatal(err)
+	}
+	activity := &Activity{ExpectedOutcomeID: outcome.ID, Title: "Sales calls", Category: CategoryWeekly, ProgressPercentage: 50}
+	if err := s.CreateActivity(activity, owner.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.GetObjectiveByID(obj.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != obj.Title || got.Weight != 40 || !got.StartDate.Equal(start) || got.Locked {
+		t.Errorf("GetObjectiveByID = %+v", got)
+	}
+	if got.Performance != 50 {
+		t.Errorf("performance = %v, want 50", got.Performance)
+	}
+
+	tree, err := s.GetObjectivesWithOutcomes(owner.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree) != 1 || len(tree[0].ExpectedOutcomes) != 1 || len(tree[0].ExpectedOutcomes[0].Activities) != 1 {
+		t.Fatalf("GetObjectivesWithOutcomes = %+v", tree)
+	}
+	if a := tree[0].ExpectedOutcomes[0].Activities[0]; a.ID != activity.ID || a.ProgressPercentage != 50 {
+		t.Errorf("activity = %+v", a)
+	}
+
+	obj.Title = "Grow revenue 10%"
+	if err := s.UpdateObjective(obj, owner.ID); err != nil {
+		t.Fatal(err)
+	}
+	events, err := s.GetAuditEvents(AuditFilter{Entity: AuditObjective, EntityID: obj.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d audit events for the objective, want 2", len(events))
+	}
+	if n, err := s.CountAuditEvents(AuditFilter{OwnerID: owner.ID}); err != nil || n != 4 {
+		t.Errorf("CountAuditEvents = %d, %v; want 4", n, err)
+	}
+
+	if err := s.DeleteObjective(obj.ID, owner.ID); err != nil {
+		t.Fatal(err)
+	}
+	if objectives, err := s.GetObjectivesByUserID(owner.ID); err != nil || len(objectives) != 0 {
+		t.Errorf("objectives after delete = %v, %v", objectives, err)
+	}
+}
+
+func testStoreTasks(t *testing.T, s Store) {
+	owner := createTestUser(t, s, "owner", RoleSupervisor, nil)
+	helper := createTestUser(t, s, "helper", RoleStaff, &owner.ID)
+	due := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	task := &Task{UserID: owner.ID, Title: "Prepare budget", Priority: PriorityHigh, Status: TaskStatusPending,
+		TaskType: TaskTypeStaffAssignment, DueDate: due, AssignedToID: &helper.ID, AssignmentStatus: AssignmentPending}
+	if err := s.CreateTask(task, owner.ID); err != nil {
+		t.Fatal(err)
+	}
+	done := &Task{UserID: owner.ID, Title: "Book room", Priority: PriorityLow, Status: TaskStatusCompleted, TaskType: TaskTypePersonal, DueDate: due}
+	if err := s.CreateTask(done, owner.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.GetTaskByID(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != task.Title || !got.DueDate.Equal(due) || got.AssignedToID == nil || *got.AssignedToID != helper.ID {
+		t.Errorf("GetTaskByID = %+v", got)
+	}
+
+	assigned, err := s.GetTasksAssignedToUser(helper.ID)
+	if err != nil || len(assigned) != 1 || assigned[0].ID != task.ID {
+		t.Errorf("GetTasksAssignedToUser = %v, %v", assigned, err)
+	}
+	if completed, pending, err := s.GetTaskCountsByStatus(owner.ID); err != nil || completed != 1 || pending != 1 {
+		t.Errorf("GetTaskCountsByStatus = %d, %d, %v; want 1, 1", completed, pending, err)
+	}
+
+	task.Status = TaskStatusInProgress
+	task.CompletionPercentage = 30
+	if err := s.UpdateTask(task, helper.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.GetTaskByID(task.ID); got.Status != TaskStatusInProgress || got.CompletionPercentage != 30 {
+		t.Errorf("after UpdateTask: status %q, completion %v", got.Status, got.CompletionPercentage)
+	}
+
+	team, err := s.GetTasksByUserIDs([]int{owner.ID, helper.ID})
+	if err != nil || len(team) != 1 || len(team[owner.ID]) != 2 {
+		t.Errorf("GetTasksByUserIDs = %v, %v; want the owner's two tasks", team, err)
+	}
+	if team, err := s.GetTasksByUserIDs(nil); err != nil || len(team) != 0 {
+		t.Errorf("GetTasksByUserIDs(nil) = %v, %v", team, err)
+	}
+
+	open, err := s.GetOpenTasksDueBetween(due.AddDate(0, 0, -1), due.AddDate(0, 0, 1))
+	if err != nil || len(open) != 1 || open[0].ID != task.ID {
+		t.Errorf("GetOpenTasksDueBetween = %v, %v", open, err)
+	}
+
+	if err := s.DeleteTask(task.ID, owner.ID); err != nil {
+		t.Fatal(err)
+	}
+	if tasks, err := s.GetTasksByUserID(owner.ID); err != nil || len(tasks) != 1 {
+		t.Errorf("tasks after delete = %v, %v", tasks, err)
+	}
+}
+
+func testStoreComments(t *testing.T, s Store) {
+	boss := createTestUser(t, s, "boss", RoleSupervisor, nil)
+	staff := createTestUser(t, s, "staff", RoleStaff, &boss.ID)
+	obj := &Objective{UserID: staff.ID, Title: "Learn Go", Visibility: VisibilityPublic, Status: StatusNotStarted, Category: CategoryPeople}
+	if err := s.CreateObjective(obj, staff.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	comment := &Comment{ObjectiveID: &obj.ID, UserID: boss.ID, CommentText: "Good start"}
+	if err := s.CreateComment(comment); err != nil {
+		t.Fatal(err)
+	}
+	comments, err := s.GetCommentsByObjective(obj.ID)
+	if err != nil || len(comments) != 1 {
+		t.Fatalf("GetCommentsByObjective = %v, %v", comments, err)
+	}
+	if c := comments[0]; c.CommentText != "Good start" || c.Username != "boss" {
+		t.Errorf("comment = %+v", c)
+	}
+	other := &Objective{UserID: boss.ID, Title: "Coach the team", Visibility: VisibilityPublic, Status: StatusNotStarted, Category: CategoryPeople}
+	if err := s.CreateObjective(other, boss.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateComment(&Comment{ObjectiveID: &other.ID, UserID: boss.ID, CommentText: "Note to self"}); err != nil {
+		t.Fatal(err)
+	}
+	byObjective, err := s.GetObjectiveCommentsByUserID(staff.ID)
+	if err != nil || len(byObjective) != 1 || len(byObjective[obj.ID]) != 1 || byObjective[obj.ID][0].ID != comment.ID {
+		t.Errorf("GetObjectiveCommentsByUserID = %v, %v; want the comment on the staff member's objective", byObjective, err)
+	}
+
+	if err := s.DeleteComment(comment.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetCommentByID(comment.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetCommentByID after delete: %v, want sql.ErrNoRows", err)
+	}
+}
+
+func testStoreReviewCycles(t *testing.T, s Store) {
+	owner := createTestUser(t, s, "owner", RoleStaff, nil)
+	cycle := &ReviewCycle{Name: "FY2026 H1", StartDate: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate: time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC), Status: CycleStatusOpen}
+	if err := s.CreateReviewCycle(cycle); err != nil {
+		t.Fatal(err)
+	}
+	obj := &Objective{UserID: owner.ID, Title: "Ship it", Visibility: VisibilityPublic, Status: StatusOnTrack,
+		Category: CategoryOther, ReviewCycleID: &cycle.ID}
+	if err := s.CreateObjective(obj, owner.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.SetReviewCycleStatus(cycle.ID, CycleStatusClosed); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.GetReviewCycleByID(cycle.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != CycleStatusClosed || got.ClosedAt == nil || !got.EndDate.Equal(cycle.EndDate) {
+		t.Errorf("GetReviewCycleByID = %+v", got)
+	}
+
+	objectives, err := s.GetObjectivesByUserIDInCycle(owner.ID, cycle.ID)
+	if err != nil || len(objectives) != 1 {
+		t.Fatalf("GetObjectivesByUserIDInCycle = %v, %v", objectives, err)
+	}
+	if !objectives[0].Locked || objectives[0].CycleName != "FY2026 H1" {
+		t.Errorf("objective in closed cycle: locked %v, cycle %q", objectives[0].Locked, objectives[0].CycleName)
+	}
+}
+
+func testStoreNotifications(t *testing.T, s Store) {
+	u := createTestUser(t, s, "reader", RoleStaff, nil)
+
+	n := &Notification{UserID: u.ID, Kind: NotifyTaskAssigned, EntityID: 7, Message: "Task assigned", Link: "/tasks"}
+	if err := s.CreateNotification(n); err != nil {
+		t.Fatal(err)
+	}
+	if n.ID == 0 {
+		t.Fatal("CreateNotification did not set the ID")
+	}
+	if count, err := s.CountUnreadNotifications(u.ID); err != nil || count != 1 {
+		t.Errorf("CountUnreadNotifications = %d, %v; want 1", count, err)
+	}
+
+	// Kinds turned off are not delivered
+	if err := s.SaveNotificationPreferences(u.ID, map[NotificationKind]bool{NotifyTaskAssigned: false, NotifyComment: true}); err != nil {
+		t.Fatal(err)
+	}
+	prefs, err := s.GetNotificationPreferences(u.ID)
+	if err != nil || prefs[NotifyTaskAssigned] || !prefs[NotifyComment] {
+		t.Errorf("GetNotificationPreferences = %v, %v", prefs, err)
+	}
+	muted := &Notification{UserID: u.ID, Kind: NotifyTaskAssigned, EntityID: 8, Message: "Muted"}
+	if err := s.CreateNotification(muted); err != nil || muted.ID != 0 {
+		t.Errorf("muted notification: ID %d, %v; want 0, nil", muted.ID, err)
+	}
+
+	if err := s.MarkNotificationRead(n.ID, u.ID); err != nil {
+		t.Fatal(err)
+	}
+	unread, err := s.GetNotifications(u.ID, true, 10)
+	if err != nil || len(unread) != 0 {
+		t.Errorf("unread after MarkNotificationRead = %v, %v", unread, err)
+	}
+	if has, err := s.HasNotificationSince(u.ID, NotifyTaskAssigned, 7, n.CreatedAt.Add(-time.Minute)); err != nil || !has {
+		t.Errorf("HasNotificationSince = %v, %v; want true", has, err)
+	}
+}
+
+func testStoreRecurringPeriods(t *testing.T, s Store) {
+	owner := createTestUser(t, s, "owner", RoleStaff, nil)
+	obj := &Objective{UserID: owner.ID, Title: "Routine", Visibility: VisibilityPublic, Status: StatusOnTrack, Category: CategoryOther}
+	if err := s.CreateObjective(obj, owner.ID); err != nil {
+		t.Fatal(err)
+	}
+	outcome := &ExpectedOutcome{ObjectiveID: obj.ID, Title: "Reports filed"}
+	if err := s.CreateExpectedOutcome(outcome, owner.ID); err != nil {
+		t.Fatal(err)
+	}
+	activity := &Activity{ExpectedOutcomeID: outcome.ID, Title: "Weekly report", Category: CategoryWeekly}
+	if err := s.CreateActivity(activity, owner.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	week := time.Date(2026, 2, 2, 0, 0, 0, 0, time.UTC)
+	if claimed, err := s.ClaimRecurringPeriod(activity.ID, week); err != nil || !claimed {
+		t.Fatalf("first claim = %v, %v; want true", claimed, err)
+	}
+	if claimed, err := s.ClaimRecurringPeriod(activity.ID, week); err != nil || claimed {
+		t.Errorf("second claim = %v, %v; want false", claimed, err)
+	}
+	if err := s.ReleaseRecurringPeriod(activity.ID, week); err != nil {
+		t.Fatal(err)
+	}
+	if claimed, err := s.ClaimRecurringPeriod(activity.ID, week); err != nil || !claimed {
+		t.Errorf("claim after release = %v, %v; want true", claimed, err)
+	}
+}
+
+func testStoreLogins(t *testing.T, s Store) {
+	u := createTestUser(t, s, "signer", RoleStaff, nil)
+
+	start := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+	events := []*LoginEvent{
+		{UserID: &u.ID, Username: "signer", IP: "10.0.0.1", UserAgent: "Firefox", Success: false, CreatedAt: start},
+		{UserID: &u.ID, Username: "signer", IP: "10.0.0.1", UserAgent: "Firefox", Success: true, CreatedAt: start.Add(time.Second)},
+		{Username: "nobody", IP: "10.0.0.2", Success: false, Failure: LoginUnknownUser, CreatedAt: start.Add(2 * time.Second)},
+		{Username: "nobody", IP: "10.0.0.3", Success: false, CreatedAt: start.Add(3 * time.Second)},
+	}
+	for _, e := range events {
+		if err := s.CreateLoginEvent(e); err != nil {
+			t.Fatal(err)
+		}
+		if e.ID == 0 {
+			t.Fatal("CreateLoginEvent did not set the ID")
+		}
+	}
+
+	history, err := s.GetLoginEventsByUserID(u.ID, 10)
+	if err != nil || len(history) != 2 || !history[0].Success || history[1].Success {
+		t.Fatalf("GetLoginEventsByUserID = %+v, %v; want the success then the failure", history, err)
+	}
+	if history[0].IP != "10.0.0.1" || history[0].UserAgent != "Firefox" || !history[0].CreatedAt.Equal(start.Add(time.Second)) {
+		t.Errorf("login event = %+v", history[0])
+	}
+
+	recent, err := s.GetRecentLoginEvents("nobody", "10.0.0.1", start.Add(time.Second))
+	if err != nil || len(recent) != 3 || recent[0].ID != events[3].ID || recent[0].UserID != nil || recent[2].ID != events[1].ID {
+		t.Errorf("GetRecentLoginEvents = %+v, %v; want the last three events, newest first", recent, err)
+	}
+	if len(recent) == 3 && recent[1].Failure != LoginUnknownUser {
+		t.Errorf("login event failure = %q, want %q", recent[1].Failure, LoginUnknownUser)
+	}
+
+	if n, err := s.DeleteLoginEventsBefore(start.Add(2 * time.Second)); err != nil || n != 2 {
+		t.Errorf("DeleteLoginEventsBefore = %d, %v; want 2", n, err)
+	}
+	if history, err := s.GetLoginEventsByUserID(u.ID, 10); err != nil || len(history) != 0 {
+		t.Errorf("history after DeleteLoginEventsBefore = %v, %v", history, err)
+	}
+
+	// Failures count up until the account is locked, then start again
+	for want := 1; want <= 2; want++ {
+		if n, err := s.IncrementFailedLogins(u.ID); err != nil || n != want {
+			t.Fatalf("IncrementFailedLogins = %d, %v; want %d", n, err, want)
+		}
+	}
+	until := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	if err := s.LockUser(u.ID, until); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.GetUserByUsername("signer")
+	if err != nil || !got.Locked() || !got.LockedUntil.Equal(until) {
+		t.Fatalf("locked user = %+v, %v; want locked until %v", got, err, until)
+	}
+	if n, err := s.IncrementFailedLogins(u.ID); err != nil || n != 1 {
+		t.Errorf("IncrementFailedLogins after LockUser = %d, %v; want 1", n, err)
+	}
+
+	if err := s.UnlockUser(u.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := s.GetUserByID(u.ID); err != nil || got.Locked() || got.LockedUntil != nil {
+		t.Errorf("unlocked user = %+v, %v", got, err)
+	}
+	if n, err := s.IncrementFailedLogins(u.ID); err != nil || n != 1 {
+		t.Errorf("IncrementFailedLogins after UnlockUser = %d, %v; want 1", n, err)
+	}
+}
+
+func testStoreJobRuns(t *testing.T, s Store) {
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	runs := []*JobRun{
+		{Job: "digest", Trigger: TriggerSchedule, StartedAt: start, Status: JobFailed},
+		{Job: "digest", Trigger: TriggerSchedule, StartedAt: start.Add(time.Minute), Status: JobSucceeded},
+		{Job: "prune", Trigger: TriggerManual, StartedAt: start.Add(2 * time.Minute), Status: JobSucceeded},
+	}
+	for _, run := range runs {
+		if err := s.CreateJobRun(run); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	since, err := s.GetJobRunsSince("digest", start.Add(time.Second))
+	if err != nil || len(since) != 1 || since[0].ID != runs[1].ID {
+		t.Errorf("GetJobRunsSince = %+v, %v; want the second digest run", since, err)
+	}
+	if last, err := s.GetLastJobRun("digest"); err != nil || last == nil || last.ID != runs[1].ID {
+		t.Errorf("GetLastJobRun = %+v, %v; want the second digest run", last, err)
+	}
+
+	if n, err := s.DeleteJobRunsBefore(start.Add(2 * time.Minute)); err != nil || n != 2 {
+		t.Errorf("DeleteJobRunsBefore = %d, %v; want 2", n, err)
+	}
+	if all, err := s.GetJobRuns(10); err != nil || len(all) != 1 || all[0].ID != runs[2].ID {
+		t.Errorf("job runs after DeleteJobRunsBefore = %+v, %v; want only the prune run", all, err)
+	}
+}
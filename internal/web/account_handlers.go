@@ -0,0 +1,267 @@
+package web
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"staffperformance/internal/auth"
+	"staffperformance/internal/store"
+)
+
+// Change password handler - lets a user replace their own password. Users
+// flagged with MustChangePassword are redirected here by RequireAuth.
+func (app *App) changePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	data := ChangePasswordData{
+		User:      *user,
+		MinLength: auth.MinPasswordLength,
+	}
+
+	if r.Method == http.MethodPost {
+		current := r.FormValue("current_password")
+		newPassword := r.FormValue("new_password")
+		confirm := r.FormValue("confirm_password")
+
+		if ok, _ := auth.CheckPassword(user.Password, current); !ok {
+			data.Error = "Current password is incorrect"
+		} else if err := auth.ValidateNewPassword(newPassword); err != nil {
+			data.Error = "New " + err.Error()
+		} else if newPassword != confirm {
+			data.Error = "New passwords do not match"
+		} else if newPassword == current {
+			data.Error = "New password must differ from the current password"
+		}
+
+		if data.Error == "" {
+			hash, err := auth.HashPassword(newPassword)
+			if err != nil {
+				log.Println("Error hashing password:", err)
+				http.Error(w, "Error changing password", http.StatusInternalServerError)
+				return
+			}
+
+			if err := app.store.UpdateUserPassword(user.ID, hash, false); err != nil {
+				log.Println("Error changing password:", err)
+				http.Error(w, "Error changing password", http.StatusInternalServerError)
+				return
+			}
+
+			log.Printf("Password changed for user: %s", user.Username)
+			data.User.MustChangePassword = false
+			data.Success = true
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}
+
+	err := app.render(w, r, "change_password.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// signInsShown is how many of an account's latest sign-in attempts are listed
+const signInsShown = 50
+
+// Sign-ins handler - lists the latest attempts to sign in to the user's
+// account. Admins can see another account's with user_id.
+func (app *App) signInsHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	account := user
+	if idStr := r.URL.Query().Get("user_id"); idStr != "" {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+		if id != user.ID {
+			if !app.auth.Authorize(w, user, auth.ResourceStaff, auth.ActionWrite, id) {
+				return
+			}
+			account, err = app.store.GetUserByID(id)
+			if err != nil {
+				http.Error(w, "User not found", http.StatusNotFound)
+				return
+			}
+		}
+	}
+
+	events, err := app.store.GetLoginEventsByUserID(account.ID, signInsShown)
+	if err != nil {
+		log.Println("Error loading login history:", err)
+		http.Error(w, "Error loading sign-ins", http.StatusInternalServerError)
+		return
+	}
+
+	data := SignInsData{
+		User:    *user,
+		Account: *account,
+		Events:  events,
+		Limit:   signInsShown,
+	}
+	err = app.render(w, r, "sign_ins.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// tokenExpiryDays are the lifetimes offered when creating an API token; 0 never expires
+var tokenExpiryDays = []int{30, 90, 365, 0}
+
+// API tokens handler - lists the user's personal access tokens and creates
+// new ones. The plaintext of a new token is shown once, on this response.
+func (app *App) apiTokensHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	if !requireBrowserSession(w, r) {
+		return
+	}
+
+	data := TokenListData{
+		User:       *user,
+		Scopes:     []store.TokenScope{store.TokenScopeRead, store.TokenScopeWrite},
+		ExpiryDays: tokenExpiryDays,
+	}
+
+	if r.Method == http.MethodPost {
+		name := strings.TrimSpace(r.FormValue("name"))
+		scope := store.TokenScope(r.FormValue("scope"))
+		days, err := strconv.Atoi(r.FormValue("expires_days"))
+
+		if name == "" {
+			data.Error = "Token name is required"
+		} else if len(name) > 100 {
+			data.Error = "Token name must be at most 100 characters"
+		} else if !oneOf(scope, data.Scopes) {
+			data.Error = "Invalid token scope"
+		} else if err != nil || !oneOf(days, tokenExpiryDays) {
+			data.Error = "Invalid token expiry"
+		}
+
+		if data.Error == "" {
+			plaintext, hash, err := auth.GenerateAPIToken()
+			if err != nil {
+				log.Println("Error generating API token:", err)
+				http.Error(w, "Error creating token", http.StatusInternalServerError)
+				return
+			}
+
+			token := &store.APIToken{
+				UserID: user.ID,
+				Name:   name,
+				Prefix: plaintext[:auth.APITokenDisplayLength],
+				Scope:  scope,
+			}
+			if days > 0 {
+				expires := time.Now().AddDate(0, 0, days)
+				token.ExpiresAt = &expires
+			}
+
+			if err := app.store.CreateAPIToken(token, hash); err != nil {
+				log.Println("Error creating API token:", err)
+				http.Error(w, "Error creating token", http.StatusInternalServerError)
+				return
+			}
+
+			log.Printf("API token %q (%s) created for user: %s", token.Name, token.Scope, user.Username)
+			data.NewToken = plaintext
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}
+
+	tokens, err := app.store.GetAPITokensByUserID(user.ID)
+	if err != nil {
+		log.Println("Error fetching API tokens:", err)
+		http.Error(w, "Error loading tokens", http.StatusInternalServerError)
+		return
+	}
+	data.Tokens = tokens
+
+	app.renderTokenList(w, r, data)
+}
+
+// Admin API tokens handler - lists every user's tokens so they can be revoked
+func (app *App) adminTokensHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	if !requireBrowserSession(w, r) {
+		return
+	}
+
+	tokens, err := app.store.GetAllAPITokens()
+	if err != nil {
+		log.Println("Error fetching API tokens:", err)
+		http.Error(w, "Error loading tokens", http.StatusInternalServerError)
+		return
+	}
+
+	app.renderTokenList(w, r, TokenListData{User: *user, Tokens: tokens, AllUsers: true})
+}
+
+// Revoke API token handler - deletes a token. Users revoke their own tokens;
+// admins may revoke anyone's.
+func (app *App) revokeAPITokenHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	if !requireBrowserSession(w, r) {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid token ID", http.StatusBadRequest)
+		return
+	}
+
+	token, err := app.store.GetAPITokenByID(id)
+	if err != nil {
+		http.Error(w, "Token not found", http.StatusNotFound)
+		return
+	}
+
+	if !app.auth.Authorize(w, user, auth.ResourceTokens, auth.ActionWrite, token.UserID) {
+		return
+	}
+
+	if err := app.store.DeleteAPIToken(token.ID); err != nil {
+		log.Println("Error revoking API token:", err)
+		http.Error(w, "Error revoking token", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("API token %q of %s revoked by %s", token.Name, token.Username, user.Username)
+
+	if r.FormValue("from") == "admin" {
+		http.Redirect(w, r, "/admin/tokens", http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, "/account/tokens", http.StatusSeeOther)
+}
+
+// requireBrowserSession rejects requests authenticated with an API token, so
+// a leaked token cannot be used to mint or revoke tokens
+func requireBrowserSession(w http.ResponseWriter, r *http.Request) bool {
+	if auth.CurrentToken(r) != nil {
+		http.Error(w, "API tokens cannot manage tokens", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func (app *App) renderTokenList(w http.ResponseWriter, r *http.Request, data TokenListData) {
+	err := app.render(w, r, "api_tokens.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,310 @@
+package web
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"staffperformance/internal/auth"
+	"staffperformance/internal/store"
+)
+
+// API error codes returned in the "code" field of error bodies
+const (
+	apiErrBadRequest       = "bad_request"
+	apiErrUnauthorized     = "unauthorized"
+	apiErrForbidden        = "forbidden"
+	apiErrNotFound         = "not_found"
+	apiErrMethodNotAllowed = "method_not_allowed"
+	apiErrValidation       = "validation_failed"
+	apiErrLocked           = "locked"
+	apiErrInternal         = "internal_error"
+)
+
+// Pagination defaults for list endpoints
+const (
+	apiDefaultPerPage = 20
+	apiMaxPerPage     = 100
+)
+
+// maxAPIBodyBytes limits the size of JSON request bodies
+const maxAPIBodyBytes = 1 << 20
+
+// apiError is the body of every error response: {"error": {...}}
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// apiPagination describes the page returned by a list endpoint
+type apiPagination struct {
+	Page       int `json:"page"`
+	PerPage    int `json:"per_page"`
+	Total      int `json:"total"`
+	TotalPages int `json:"total_pages"`
+}
+
+// validationError is returned by input validation. The API reports it as 422;
+// web forms show the message to the user.
+type validationError struct {
+	message string
+}
+
+func (e *validationError) Error() string {
+	return e.message
+}
+
+func validationErrorf(format string, args ...interface{}) error {
+	return &validationError{message: fmt.Sprintf(format, args...)}
+}
+
+// isValidationError reports whether err was caused by invalid input
+func isValidationError(err error) bool {
+	var validationErr *validationError
+	return errors.As(err, &validationErr)
+}
+
+// registerAPIRoutes adds the /api/v1 routes to mux
+func (app *App) registerAPIRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/", app.apiAuth(func(w http.ResponseWriter, r *http.Request) {
+		writeAPIError(w, http.StatusNotFound, apiErrNotFound, "Unknown API endpoint")
+	}))
+
+	mux.HandleFunc("/api/v1/objectives", app.apiAuth(app.apiObjectivesHandler))
+	mux.HandleFunc("/api/v1/objectives/{id}", app.apiAuth(app.apiObjectiveHandler))
+	mux.HandleFunc("/api/v1/objectives/{id}/outcomes", app.apiAuth(app.apiObjectiveOutcomesHandler))
+	mux.HandleFunc("/api/v1/objectives/{id}/progress", app.apiAuth(app.apiObjectiveProgressHandler))
+	mux.HandleFunc("/api/v1/objectives/{id}/rating", app.apiAuth(app.apiObjectiveRatingHandler))
+
+	mux.HandleFunc("/api/v1/outcomes/{id}", app.apiAuth(app.apiOutcomeHandler))
+	mux.HandleFunc("/api/v1/outcomes/{id}/activities", app.apiAuth(app.apiOutcomeActivitiesHandler))
+	mux.HandleFunc("/api/v1/outcomes/{id}/tasks", app.apiAuth(app.apiOutcomeTasksHandler))
+
+	mux.HandleFunc("/api/v1/activities/{id}", app.apiAuth(app.apiActivityHandler))
+
+	mux.HandleFunc("/api/v1/tasks", app.apiAuth(app.apiTasksHandler))
+	mux.HandleFunc("/api/v1/tasks/{id}", app.apiAuth(app.apiTaskHandler))
+}
+
+// apiAuth is the API counterpart of RequireAuth: it answers with JSON errors
+// instead of redirecting to the login page. Both session cookies and bearer
+// tokens are accepted.
+func (app *App) apiAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, token, err := app.auth.Authenticate(r)
+		if err != nil || user == nil {
+			if _, ok := auth.BearerToken(r); ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="staffperformance"`)
+				writeAPIError(w, http.StatusUnauthorized, apiErrUnauthorized, "Invalid or expired API token")
+				return
+			}
+			writeAPIError(w, http.StatusUnauthorized, apiErrUnauthorized, "Authentication required")
+			return
+		}
+		if token != nil && !token.Allows(r.Method) {
+			writeAPIError(w, http.StatusForbidden, apiErrForbidden, "API token does not allow this request")
+			return
+		}
+		if user.MustChangePassword {
+			writeAPIError(w, http.StatusForbidden, apiErrForbidden, "Password change required")
+			return
+		}
+		next(w, auth.WithAuth(r, user, token))
+	}
+}
+
+// writeJSON writes v as a JSON response with the given status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println("Error encoding API response:", err)
+	}
+}
+
+// writeAPIData writes a single resource as {"data": ...}
+func writeAPIData(w http.ResponseWriter, status int, data interface{}) {
+	writeJSON(w, status, map[string]interface{}{"data": data})
+}
+
+// writeAPIError writes {"error": {"code": ..., "message": ...}}
+func writeAPIError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]apiError{"error": {Code: code, Message: message}})
+}
+
+// writeAPIFailure maps an error from loading or saving a resource to a response
+func writeAPIFailure(w http.ResponseWriter, err error, what string) {
+	var validationErr *validationError
+	switch {
+	case errors.As(err, &validationErr):
+		writeAPIError(w, http.StatusUnprocessableEntity, apiErrValidation, validationErr.message)
+	case errors.Is(err, sql.ErrNoRows):
+		writeAPIError(w, http.StatusNotFound, apiErrNotFound, what+" not found")
+	default:
+		log.Printf("API error (%s): %v", what, err)
+		writeAPIError(w, http.StatusInternalServerError, apiErrInternal, "Internal server error")
+	}
+}
+
+// apiMethodNotAllowed answers requests whose method a route does not support
+func apiMethodNotAllowed(w http.ResponseWriter, allowed string) {
+	w.Header().Set("Allow", allowed)
+	writeAPIError(w, http.StatusMethodNotAllowed, apiErrMethodNotAllowed, "Method not allowed")
+}
+
+// apiForbidden reports whether the user may not perform the action on a record
+// owned by ownerID, writing a 403 response if so
+func (app *App) apiForbidden(w http.ResponseWriter, user *store.User, resource auth.Resource, action auth.Action, ownerID int) bool {
+	if app.auth.CanAccess(user, resource, action, ownerID) {
+		return false
+	}
+	writeAPIError(w, http.StatusForbidden, apiErrForbidden, "Access denied")
+	return true
+}
+
+// apiLocked reports whether an objective is locked by a closed review cycle,
+// writing a 409 response if so
+func apiLocked(w http.ResponseWriter, obj *store.Objective) bool {
+	if !obj.Locked {
+		return false
+	}
+	writeAPIError(w, http.StatusConflict, apiErrLocked, errCycleClosedMessage)
+	return true
+}
+
+// apiTaskLocked is apiLocked for the objective a task linked to outcomeID
+// counts towards
+func (app *App) apiTaskLocked(w http.ResponseWriter, outcomeID *int) bool {
+	obj, err := app.outcomeObjective(outcomeID)
+	if err != nil {
+		writeAPIFailure(w, err, "Objective")
+		return true
+	}
+	return obj != nil && apiLocked(w, obj)
+}
+
+// apiPathID parses the {id} path segment, writing a 400 response if it is invalid
+func apiPathID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 1 {
+		writeAPIError(w, http.StatusBadRequest, apiErrBadRequest, "Invalid ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// decodeAPIBody decodes a JSON request body into v, rejecting unknown fields
+// and writing a 400 response on failure
+func decodeAPIBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeAPIError(w, http.StatusBadRequest, apiErrBadRequest, "Invalid JSON body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// writeAPIList writes one page of items as {"data": [...], "pagination": {...}}.
+// The page is chosen with the page and per_page query parameters.
+func writeAPIList[T any](w http.ResponseWriter, r *http.Request, items []T) {
+	page, err := queryInt(r, "page", 1)
+	if err != nil || page < 1 {
+		writeAPIError(w, http.StatusBadRequest, apiErrBadRequest, "page must be a positive integer")
+		return
+	}
+	perPage, err := queryInt(r, "per_page", apiDefaultPerPage)
+	if err != nil || perPage < 1 || perPage > apiMaxPerPage {
+		writeAPIError(w, http.StatusBadRequest, apiErrBadRequest, fmt.Sprintf("per_page must be between 1 and %d", apiMaxPerPage))
+		return
+	}
+
+	total := len(items)
+	start := (page - 1) * perPage
+	if start > total {
+		start = total
+	}
+	end := start + perPage
+	if end > total {
+		end = total
+	}
+
+	data := items[start:end]
+	if data == nil {
+		data = []T{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": data,
+		"pagination": apiPagination{
+			Page:       page,
+			PerPage:    perPage,
+			Total:      total,
+			TotalPages: int(math.Ceil(float64(total) / float64(perPage))),
+		},
+	})
+}
+
+// queryInt reads an optional integer query parameter
+func queryInt(r *http.Request, name string, fallback int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// optional is a nullable value in a request body. It tells an omitted field
+// (leave unchanged) apart from an explicit null (clear the value).
+type optional[T any] struct {
+	Set   bool
+	Value *T
+}
+
+// optionalID is a nullable link to another record
+type optionalID = optional[int]
+
+func (o *optional[T]) UnmarshalJSON(b []byte) error {
+	o.Set = true
+	if string(b) == "null" {
+		o.Value = nil
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	o.Value = &v
+	return nil
+}
+
+// parseAPIDate accepts a date as YYYY-MM-DD or RFC 3339; empty clears the date
+func parseAPIDate(field, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, validationErrorf("%s must be a date in YYYY-MM-DD or RFC 3339 format", field)
+	}
+	return t, nil
+}
+
+// oneOf reports whether value is one of the allowed values
+func oneOf[T comparable](value T, allowed []T) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	return false
+}
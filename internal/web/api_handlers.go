@@ -0,0 +1,869 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"staffperformance/internal/auth"
+	"staffperformance/internal/store"
+)
+
+// Values accepted by the API for enumerated fields
+var (
+	objectiveVisibilities = []store.ObjectiveVisibility{store.VisibilityPublic, store.VisibilityPrivate}
+	objectiveStatuses     = []store.ObjectiveStatus{store.StatusNotStarted, store.StatusOnTrack, store.StatusPending, store.StatusNeedHelp, store.StatusComplete}
+	objectiveCategories   = []store.ObjectiveCategory{store.CategoryFinancial, store.CategoryContinuousImprovement, store.CategoryPeople, store.CategoryOther}
+	activityCategories    = []store.ActivityCategory{store.CategoryDaily, store.CategoryWeekly, store.CategoryMonthly, store.CategoryQuarterly, store.CategoryBiannually, store.CategoryAnnually}
+	taskPriorities        = []store.TaskPriority{store.PriorityLow, store.PriorityMedium, store.PriorityHigh, store.PriorityUrgent}
+	taskStatuses          = []store.TaskStatus{store.TaskStatusPending, store.TaskStatusInProgress, store.TaskStatusCompleted, store.TaskStatusOnHold}
+	taskTypes             = []store.TaskType{store.TaskTypePersonal, store.TaskTypeServiceRequest, store.TaskTypeStaffAssignment, store.TaskTypeResponse}
+)
+
+// objectiveInput is the body of objective create and update requests. Omitted
+// fields keep their current value (or the default when creating).
+type objectiveInput struct {
+	Title         *string                    `json:"title"`
+	Description   *string                    `json:"description"`
+	StartDate     *string                    `json:"start_date"`
+	EndDate       *string                    `json:"end_date"`
+	Visibility    *store.ObjectiveVisibility `json:"visibility"`
+	Status        *store.ObjectiveStatus     `json:"status"`
+	Category      *store.ObjectiveCategory   `json:"category"`
+	CategoryOther *string                    `json:"category_other"`
+	Weight        *float64                   `json:"weight"`
+	ProjectID     optionalID                 `json:"project_id"`
+	ReviewCycleID optionalID                 `json:"review_cycle_id"`
+	ManualRating  optional[float64]          `json:"manual_rating"`
+}
+
+func (in *objectiveInput) apply(app *App, obj *store.Objective, user *store.User) error {
+	var err error
+	if in.Title != nil {
+		obj.Title = strings.TrimSpace(*in.Title)
+	}
+	if in.Description != nil {
+		obj.Description = *in.Description
+	}
+	if in.StartDate != nil {
+		if obj.StartDate, err = parseAPIDate("start_date", *in.StartDate); err != nil {
+			return err
+		}
+	}
+	if in.EndDate != nil {
+		if obj.EndDate, err = parseAPIDate("end_date", *in.EndDate); err != nil {
+			return err
+		}
+	}
+	if in.Visibility != nil {
+		obj.Visibility = *in.Visibility
+	}
+	if in.Status != nil {
+		obj.Status = *in.Status
+	}
+	if in.Category != nil {
+		obj.Category = *in.Category
+	}
+	if in.CategoryOther != nil {
+		obj.CategoryOther = *in.CategoryOther
+	}
+	if in.Weight != nil {
+		obj.Weight = *in.Weight
+	}
+	if in.ProjectID.Set {
+		if obj.ProjectID, err = app.apiProjectID(user, in.ProjectID.Value); err != nil {
+			return err
+		}
+	}
+	if in.ReviewCycleID.Set {
+		if id := in.ReviewCycleID.Value; id != nil {
+			cycle, err := app.store.GetReviewCycleByID(*id)
+			if err != nil || cycle.Status == store.CycleStatusClosed {
+				return validationErrorf("review_cycle_id must be a review cycle that is not closed")
+			}
+		}
+		obj.ReviewCycleID = in.ReviewCycleID.Value
+	}
+	if in.ManualRating.Set {
+		if !app.canRate(user, obj.UserID) {
+			return validationErrorf("manual_rating is set by the objective owner's supervisor")
+		}
+		obj.ManualRating = in.ManualRating.Value
+	}
+
+	switch {
+	case obj.Title == "":
+		return validationErrorf("title is required")
+	case !oneOf(obj.Visibility, objectiveVisibilities):
+		return validationErrorf("visibility must be one of %v", objectiveVisibilities)
+	case !oneOf(obj.Status, objectiveStatuses):
+		return validationErrorf("status must be one of %v", objectiveStatuses)
+	case !oneOf(obj.Category, objectiveCategories):
+		return validationErrorf("category must be one of %v", objectiveCategories)
+	case !validWeight(obj.Weight):
+		return validationErrorf("weight must be between 0 and 100")
+	case obj.ManualRating != nil && !validRating(*obj.ManualRating):
+		return validationErrorf("manual_rating must be between 0 and 100")
+	case !obj.StartDate.IsZero() && !obj.EndDate.IsZero() && obj.EndDate.Before(obj.StartDate):
+		return validationErrorf("end_date must not be before start_date")
+	}
+	return app.checkCycleWeight(obj)
+}
+
+// ratingInput is the body of objective rating requests; null clears the rating
+type ratingInput struct {
+	ManualRating optional[float64] `json:"manual_rating"`
+}
+
+// outcomeInput is the body of expected outcome create and update requests
+type outcomeInput struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+}
+
+func (in *outcomeInput) apply(outcome *store.ExpectedOutcome) error {
+	if in.Title != nil {
+		outcome.Title = strings.TrimSpace(*in.Title)
+	}
+	if in.Description != nil {
+		outcome.Description = *in.Description
+	}
+	if outcome.Title == "" {
+		return validationErrorf("title is required")
+	}
+	return nil
+}
+
+// activityInput is the body of activity create and update requests
+type activityInput struct {
+	Title               *string                 `json:"title"`
+	Description         *string                 `json:"description"`
+	Category            *store.ActivityCategory `json:"category"`
+	ProgressPercentage  *float64                `json:"progress_percentage"`
+	ImplementationLevel *string                 `json:"implementation_level"`
+}
+
+func (in *activityInput) apply(activity *store.Activity) error {
+	if in.Title != nil {
+		activity.Title = strings.TrimSpace(*in.Title)
+	}
+	if in.Description != nil {
+		activity.Description = *in.Description
+	}
+	if in.Category != nil {
+		activity.Category = *in.Category
+	}
+	if in.ProgressPercentage != nil {
+		activity.ProgressPercentage = *in.ProgressPercentage
+	}
+	if in.ImplementationLevel != nil {
+		activity.ImplementationLevel = *in.ImplementationLevel
+	}
+
+	switch {
+	case activity.Title == "":
+		return validationErrorf("title is required")
+	case !oneOf(activity.Category, activityCategories):
+		return validationErrorf("category must be one of %v", activityCategories)
+	case activity.ProgressPercentage < 0 || activity.ProgressPercentage > 100:
+		return validationErrorf("progress_percentage must be between 0 and 100")
+	}
+	return nil
+}
+
+// taskInput is the body of task create and update requests
+type taskInput struct {
+	Title                *string             `json:"title"`
+	Description          *string             `json:"description"`
+	Priority             *store.TaskPriority `json:"priority"`
+	Status               *store.TaskStatus   `json:"status"`
+	TaskType             *store.TaskType     `json:"task_type"`
+	RequestedBy          *string             `json:"requested_by"`
+	DueDate              *string             `json:"due_date"`
+	CompletionPercentage *float64            `json:"completion_percentage"`
+	ExpectedOutcomeID    optionalID          `json:"expected_outcome_id"`
+	ProjectID            optionalID          `json:"project_id"`
+	AssignedToID         optionalID          `json:"assigned_to_id"`
+}
+
+func (in *taskInput) apply(app *App, task *store.Task, user *store.User) error {
+	var err error
+	if in.Title != nil {
+		task.Title = strings.TrimSpace(*in.Title)
+	}
+	if in.Description != nil {
+		task.Description = *in.Description
+	}
+	if in.Priority != nil {
+		task.Priority = *in.Priority
+	}
+	if in.TaskType != nil {
+		task.TaskType = *in.TaskType
+	}
+	if in.RequestedBy != nil {
+		task.RequestedBy = *in.RequestedBy
+	}
+	if in.DueDate != nil {
+		if task.DueDate, err = parseAPIDate("due_date", *in.DueDate); err != nil {
+			return err
+		}
+	}
+	if in.CompletionPercentage != nil {
+		task.CompletionPercentage = *in.CompletionPercentage
+	}
+	if in.ExpectedOutcomeID.Set {
+		if id := in.ExpectedOutcomeID.Value; id != nil {
+			_, obj, err := app.loadOutcome(*id)
+			if err != nil || !app.auth.CanAccess(user, auth.ResourceObjectives, auth.ActionWrite, obj.UserID) {
+				return validationErrorf("expected_outcome_id must be an expected outcome of one of your objectives")
+			}
+		}
+		task.ExpectedOutcomeID = in.ExpectedOutcomeID.Value
+	}
+	if in.ProjectID.Set {
+		if task.ProjectID, err = app.apiProjectID(user, in.ProjectID.Value); err != nil {
+			return err
+		}
+	}
+
+	// Same completion rules as the task form
+	if in.Status != nil {
+		if *in.Status == store.TaskStatusCompleted && task.Status != store.TaskStatusCompleted {
+			now := time.Now()
+			task.CompletedAt = &now
+			task.CompletionPercentage = 100
+		} else if *in.Status != store.TaskStatusCompleted {
+			task.CompletedAt = nil
+		}
+		task.Status = *in.Status
+	}
+
+	switch {
+	case task.Title == "":
+		return validationErrorf("title is required")
+	case !oneOf(task.Priority, taskPriorities):
+		return validationErrorf("priority must be one of %v", taskPriorities)
+	case !oneOf(task.Status, taskStatuses):
+		return validationErrorf("status must be one of %v", taskStatuses)
+	case !oneOf(task.TaskType, taskTypes):
+		return validationErrorf("task_type must be one of %v", taskTypes)
+	case task.CompletionPercentage < 0 || task.CompletionPercentage > 100:
+		return validationErrorf("completion_percentage must be between 0 and 100")
+	}
+
+	// Same assignment rules as the task form. Leaving assigned_to_id out
+	// keeps the current assignee.
+	if in.AssignedToID.Set || in.TaskType != nil {
+		assigneeID := in.AssignedToID.Value
+		if !in.AssignedToID.Set && task.IsAssigned() {
+			assigneeID = task.AssignedToID
+		}
+		return app.assignTask(user, task, assigneeID)
+	}
+	return nil
+}
+
+// apiProjectID checks that a project the user links work to is one of their
+// projects, like userProjectID does for the web forms
+func (app *App) apiProjectID(user *store.User, id *int) (*int, error) {
+	if id == nil {
+		return nil, nil
+	}
+	projects, err := app.store.GetProjectsForUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range projects {
+		if p.ID == *id {
+			return id, nil
+		}
+	}
+	return nil, validationErrorf("project_id must be one of your projects")
+}
+
+// loadOutcome loads an expected outcome together with its objective, whose
+// owner decides who may access the outcome
+func (app *App) loadOutcome(id int) (*store.ExpectedOutcome, *store.Objective, error) {
+	outcome, err := app.store.GetExpectedOutcomeByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	obj, err := app.store.GetObjectiveByID(outcome.ObjectiveID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return outcome, obj, nil
+}
+
+// GET lists a user's objectives (?user_id=, default the current user, and
+// optionally ?review_cycle_id=); POST creates one
+func (app *App) apiObjectivesHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	switch r.Method {
+	case http.MethodGet:
+		ownerID, err := queryInt(r, "user_id", user.ID)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, apiErrBadRequest, "Invalid user_id")
+			return
+		}
+		if app.apiForbidden(w, user, auth.ResourceObjectives, auth.ActionRead, ownerID) {
+			return
+		}
+		var objectives []store.Objective
+		if cycleID, _ := queryInt(r, "review_cycle_id", 0); cycleID > 0 {
+			objectives, err = app.store.GetObjectivesByUserIDInCycle(ownerID, cycleID)
+		} else {
+			objectives, err = app.store.GetObjectivesByUserID(ownerID)
+		}
+		if err != nil {
+			writeAPIFailure(w, err, "Objectives")
+			return
+		}
+		writeAPIList(w, r, objectives)
+
+	case http.MethodPost:
+		if app.apiForbidden(w, user, auth.ResourceObjectives, auth.ActionWrite, user.ID) {
+			return
+		}
+		var in objectiveInput
+		if !decodeAPIBody(w, r, &in) {
+			return
+		}
+		obj := &store.Objective{
+			UserID:     user.ID,
+			Visibility: store.VisibilityPublic,
+			Status:     store.StatusNotStarted,
+			Category:   store.CategoryOther,
+		}
+		if err := in.apply(app, obj, user); err != nil {
+			writeAPIFailure(w, err, "Objective")
+			return
+		}
+		if err := app.store.CreateObjective(obj, user.ID); err != nil {
+			writeAPIFailure(w, err, "Objective")
+			return
+		}
+		created, err := app.store.GetObjectiveByID(obj.ID)
+		if err != nil {
+			writeAPIFailure(w, err, "Objective")
+			return
+		}
+		writeAPIData(w, http.StatusCreated, created)
+
+	default:
+		apiMethodNotAllowed(w, "GET, POST")
+	}
+}
+
+// GET, PUT/PATCH and DELETE a single objective
+func (app *App) apiObjectiveHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	id, ok := apiPathID(w, r)
+	if !ok {
+		return
+	}
+
+	obj, err := app.store.GetObjectiveByID(id)
+	if err != nil {
+		writeAPIFailure(w, err, "Objective")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if app.apiForbidden(w, user, auth.ResourceObjectives, auth.ActionRead, obj.UserID) {
+			return
+		}
+		writeAPIData(w, http.StatusOK, obj)
+
+	case http.MethodPut, http.MethodPatch:
+		if app.apiForbidden(w, user, auth.ResourceObjectives, auth.ActionWrite, obj.UserID) || apiLocked(w, obj) {
+			return
+		}
+		var in objectiveInput
+		if !decodeAPIBody(w, r, &in) {
+			return
+		}
+		if err := in.apply(app, obj, user); err != nil {
+			writeAPIFailure(w, err, "Objective")
+			return
+		}
+		if err := app.store.UpdateObjective(obj, user.ID); err != nil {
+			writeAPIFailure(w, err, "Objective")
+			return
+		}
+		writeAPIData(w, http.StatusOK, obj)
+
+	case http.MethodDelete:
+		if app.apiForbidden(w, user, auth.ResourceObjectives, auth.ActionWrite, obj.UserID) || apiLocked(w, obj) {
+			return
+		}
+		if err := app.store.DeleteObjective(obj.ID, user.ID); err != nil {
+			writeAPIFailure(w, err, "Objective")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		apiMethodNotAllowed(w, "GET, PUT, PATCH, DELETE")
+	}
+}
+
+// GET lists an objective's expected outcomes; POST adds one
+func (app *App) apiObjectiveOutcomesHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	id, ok := apiPathID(w, r)
+	if !ok {
+		return
+	}
+
+	obj, err := app.store.GetObjectiveByID(id)
+	if err != nil {
+		writeAPIFailure(w, err, "Objective")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if app.apiForbidden(w, user, auth.ResourceObjectives, auth.ActionRead, obj.UserID) {
+			return
+		}
+		outcomes, err := app.store.GetExpectedOutcomesByObjectiveID(obj.ID)
+		if err != nil {
+			writeAPIFailure(w, err, "Expected outcomes")
+			return
+		}
+		writeAPIList(w, r, outcomes)
+
+	case http.MethodPost:
+		if app.apiForbidden(w, user, auth.ResourceObjectives, auth.ActionWrite, obj.UserID) || apiLocked(w, obj) {
+			return
+		}
+		var in outcomeInput
+		if !decodeAPIBody(w, r, &in) {
+			return
+		}
+		outcome := &store.ExpectedOutcome{ObjectiveID: obj.ID}
+		if err := in.apply(outcome); err != nil {
+			writeAPIFailure(w, err, "Expected outcome")
+			return
+		}
+		if err := app.store.CreateExpectedOutcome(outcome, user.ID); err != nil {
+			writeAPIFailure(w, err, "Expected outcome")
+			return
+		}
+		created, err := app.store.GetExpectedOutcomeByID(outcome.ID)
+		if err != nil {
+			writeAPIFailure(w, err, "Expected outcome")
+			return
+		}
+		writeAPIData(w, http.StatusCreated, created)
+
+	default:
+		apiMethodNotAllowed(w, "GET, POST")
+	}
+}
+
+// PUT sets the manual rating of an objective. Ratings come from the owner's
+// supervisor or an admin, never the owner.
+func (app *App) apiObjectiveRatingHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	id, ok := apiPathID(w, r)
+	if !ok {
+		return
+	}
+	if r.Method != http.MethodPut {
+		apiMethodNotAllowed(w, "PUT")
+		return
+	}
+
+	obj, err := app.store.GetObjectiveByID(id)
+	if err != nil {
+		writeAPIFailure(w, err, "Objective")
+		return
+	}
+	if !app.canRate(user, obj.UserID) {
+		writeAPIError(w, http.StatusForbidden, apiErrForbidden, "Access denied")
+		return
+	}
+	if apiLocked(w, obj) {
+		return
+	}
+
+	var in ratingInput
+	if !decodeAPIBody(w, r, &in) {
+		return
+	}
+	switch {
+	case !in.ManualRating.Set:
+		writeAPIFailure(w, validationErrorf("manual_rating is required; use null to clear it"), "Objective")
+		return
+	case in.ManualRating.Value != nil && !validRating(*in.ManualRating.Value):
+		writeAPIFailure(w, validationErrorf("manual_rating must be between 0 and 100"), "Objective")
+		return
+	}
+	obj.ManualRating = in.ManualRating.Value
+	if err := app.store.UpdateObjective(obj, user.ID); err != nil {
+		writeAPIFailure(w, err, "Objective")
+		return
+	}
+	writeAPIData(w, http.StatusOK, obj)
+}
+
+// GET the weekly burn-up of an objective
+func (app *App) apiObjectiveProgressHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	id, ok := apiPathID(w, r)
+	if !ok {
+		return
+	}
+	if r.Method != http.MethodGet {
+		apiMethodNotAllowed(w, "GET")
+		return
+	}
+
+	obj, err := app.store.GetObjectiveByID(id)
+	if err != nil {
+		writeAPIFailure(w, err, "Objective")
+		return
+	}
+	if app.apiForbidden(w, user, auth.ResourceObjectives, auth.ActionRead, obj.UserID) {
+		return
+	}
+	points, err := app.BurnUp(obj, time.Now().UTC())
+	if err != nil {
+		writeAPIFailure(w, err, "Objective progress")
+		return
+	}
+	writeAPIData(w, http.StatusOK, points)
+}
+
+// GET, PUT/PATCH and DELETE a single expected outcome
+func (app *App) apiOutcomeHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	id, ok := apiPathID(w, r)
+	if !ok {
+		return
+	}
+
+	outcome, obj, err := app.loadOutcome(id)
+	if err != nil {
+		writeAPIFailure(w, err, "Expected outcome")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if app.apiForbidden(w, user, auth.ResourceObjectives, auth.ActionRead, obj.UserID) {
+			return
+		}
+		writeAPIData(w, http.StatusOK, outcome)
+
+	case http.MethodPut, http.MethodPatch:
+		if app.apiForbidden(w, user, auth.ResourceObjectives, auth.ActionWrite, obj.UserID) || apiLocked(w, obj) {
+			return
+		}
+		var in outcomeInput
+		if !decodeAPIBody(w, r, &in) {
+			return
+		}
+		if err := in.apply(outcome); err != nil {
+			writeAPIFailure(w, err, "Expected outcome")
+			return
+		}
+		if err := app.store.UpdateExpectedOutcome(outcome, user.ID); err != nil {
+			writeAPIFailure(w, err, "Expected outcome")
+			return
+		}
+		writeAPIData(w, http.StatusOK, outcome)
+
+	case http.MethodDelete:
+		if app.apiForbidden(w, user, auth.ResourceObjectives, auth.ActionWrite, obj.UserID) || apiLocked(w, obj) {
+			return
+		}
+		if err := app.store.DeleteExpectedOutcome(outcome.ID, user.ID); err != nil {
+			writeAPIFailure(w, err, "Expected outcome")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		apiMethodNotAllowed(w, "GET, PUT, PATCH, DELETE")
+	}
+}
+
+// GET lists an expected outcome's activities; POST adds one
+func (app *App) apiOutcomeActivitiesHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	id, ok := apiPathID(w, r)
+	if !ok {
+		return
+	}
+
+	outcome, obj, err := app.loadOutcome(id)
+	if err != nil {
+		writeAPIFailure(w, err, "Expected outcome")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if app.apiForbidden(w, user, auth.ResourceObjectives, auth.ActionRead, obj.UserID) {
+			return
+		}
+		activities, err := app.store.GetActivitiesByExpectedOutcomeID(outcome.ID)
+		if err != nil {
+			writeAPIFailure(w, err, "Activities")
+			return
+		}
+		writeAPIList(w, r, activities)
+
+	case http.MethodPost:
+		if app.apiForbidden(w, user, auth.ResourceObjectives, auth.ActionWrite, obj.UserID) || apiLocked(w, obj) {
+			return
+		}
+		var in activityInput
+		if !decodeAPIBody(w, r, &in) {
+			return
+		}
+		activity := &store.Activity{ExpectedOutcomeID: outcome.ID}
+		if err := in.apply(activity); err != nil {
+			writeAPIFailure(w, err, "Activity")
+			return
+		}
+		if err := app.store.CreateActivity(activity, user.ID); err != nil {
+			writeAPIFailure(w, err, "Activity")
+			return
+		}
+		created, err := app.store.GetActivityByID(activity.ID)
+		if err != nil {
+			writeAPIFailure(w, err, "Activity")
+			return
+		}
+		writeAPIData(w, http.StatusCreated, created)
+
+	default:
+		apiMethodNotAllowed(w, "GET, POST")
+	}
+}
+
+// GET lists the tasks linked to an expected outcome
+func (app *App) apiOutcomeTasksHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	if r.Method != http.MethodGet {
+		apiMethodNotAllowed(w, "GET")
+		return
+	}
+
+	id, ok := apiPathID(w, r)
+	if !ok {
+		return
+	}
+
+	outcome, obj, err := app.loadOutcome(id)
+	if err != nil {
+		writeAPIFailure(w, err, "Expected outcome")
+		return
+	}
+	if app.apiForbidden(w, user, auth.ResourceObjectives, auth.ActionRead, obj.UserID) {
+		return
+	}
+
+	tasks, err := app.store.GetTasksByExpectedOutcome(outcome.ID)
+	if err != nil {
+		writeAPIFailure(w, err, "Tasks")
+		return
+	}
+	writeAPIList(w, r, tasks)
+}
+
+// GET, PUT/PATCH and DELETE a single activity
+func (app *App) apiActivityHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	id, ok := apiPathID(w, r)
+	if !ok {
+		return
+	}
+
+	activity, err := app.store.GetActivityByID(id)
+	if err != nil {
+		writeAPIFailure(w, err, "Activity")
+		return
+	}
+	_, obj, err := app.loadOutcome(activity.ExpectedOutcomeID)
+	if err != nil {
+		writeAPIFailure(w, err, "Activity")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if app.apiForbidden(w, user, auth.ResourceObjectives, auth.ActionRead, obj.UserID) {
+			return
+		}
+		writeAPIData(w, http.StatusOK, activity)
+
+	case http.MethodPut, http.MethodPatch:
+		if app.apiForbidden(w, user, auth.ResourceObjectives, auth.ActionWrite, obj.UserID) || apiLocked(w, obj) {
+			return
+		}
+		var in activityInput
+		if !decodeAPIBody(w, r, &in) {
+			return
+		}
+		if err := in.apply(activity); err != nil {
+			writeAPIFailure(w, err, "Activity")
+			return
+		}
+		if err := app.store.UpdateActivity(activity, user.ID); err != nil {
+			writeAPIFailure(w, err, "Activity")
+			return
+		}
+		updated, err := app.store.GetActivityByID(activity.ID)
+		if err != nil {
+			writeAPIFailure(w, err, "Activity")
+			return
+		}
+		writeAPIData(w, http.StatusOK, updated)
+
+	case http.MethodDelete:
+		if app.apiForbidden(w, user, auth.ResourceObjectives, auth.ActionWrite, obj.UserID) || apiLocked(w, obj) {
+			return
+		}
+		if err := app.store.DeleteActivity(activity.ID, user.ID); err != nil {
+			writeAPIFailure(w, err, "Activity")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		apiMethodNotAllowed(w, "GET, PUT, PATCH, DELETE")
+	}
+}
+
+// GET lists a user's tasks (?user_id=, default the current user); POST creates one
+func (app *App) apiTasksHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	switch r.Method {
+	case http.MethodGet:
+		ownerID, err := queryInt(r, "user_id", user.ID)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, apiErrBadRequest, "Invalid user_id")
+			return
+		}
+		if app.apiForbidden(w, user, auth.ResourceTasks, auth.ActionRead, ownerID) {
+			return
+		}
+		tasks, err := app.store.GetTasksByUserID(ownerID)
+		if err != nil {
+			writeAPIFailure(w, err, "Tasks")
+			return
+		}
+		writeAPIList(w, r, tasks)
+
+	case http.MethodPost:
+		if app.apiForbidden(w, user, auth.ResourceTasks, auth.ActionWrite, user.ID) {
+			return
+		}
+		var in taskInput
+		if !decodeAPIBody(w, r, &in) {
+			return
+		}
+		task := &store.Task{
+			UserID:   user.ID,
+			Priority: store.PriorityMedium,
+			Status:   store.TaskStatusPending,
+			TaskType: store.TaskTypePersonal,
+		}
+		if err := in.apply(app, task, user); err != nil {
+			writeAPIFailure(w, err, "Task")
+			return
+		}
+		if app.apiTaskLocked(w, task.ExpectedOutcomeID) {
+			return
+		}
+		if err := app.store.CreateTask(task, user.ID); err != nil {
+			writeAPIFailure(w, err, "Task")
+			return
+		}
+		app.notifyTaskChanges(user, store.Task{}, task)
+		created, err := app.store.GetTaskByID(task.ID)
+		if err != nil {
+			writeAPIFailure(w, err, "Task")
+			return
+		}
+		writeAPIData(w, http.StatusCreated, created)
+
+	default:
+		apiMethodNotAllowed(w, "GET, POST")
+	}
+}
+
+// GET, PUT/PATCH and DELETE a single task
+func (app *App) apiTaskHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	id, ok := apiPathID(w, r)
+	if !ok {
+		return
+	}
+
+	task, err := app.store.GetTaskByID(id)
+	if err != nil {
+		writeAPIFailure(w, err, "Task")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		// Assignees and their supervisors can read the task too
+		if !app.canAccessTask(user, task, auth.ActionRead) {
+			writeAPIError(w, http.StatusForbidden, apiErrForbidden, "Access denied")
+			return
+		}
+		writeAPIData(w, http.StatusOK, task)
+
+	case http.MethodPut, http.MethodPatch:
+		if app.apiForbidden(w, user, auth.ResourceTasks, auth.ActionWrite, task.UserID) {
+			return
+		}
+		if app.apiTaskLocked(w, task.ExpectedOutcomeID) {
+			return
+		}
+		var in taskInput
+		if !decodeAPIBody(w, r, &in) {
+			return
+		}
+		before := *task
+		if err := in.apply(app, task, user); err != nil {
+			writeAPIFailure(w, err, "Task")
+			return
+		}
+		if app.apiTaskLocked(w, task.ExpectedOutcomeID) {
+			return
+		}
+		if err := app.store.UpdateTask(task, user.ID); err != nil {
+			writeAPIFailure(w, err, "Task")
+			return
+		}
+		app.notifyTaskChanges(user, before, task)
+		writeAPIData(w, http.StatusOK, task)
+
+	case http.MethodDelete:
+		if app.apiForbidden(w, user, auth.ResourceTasks, auth.ActionWrite, task.UserID) {
+			return
+		}
+		if app.apiTaskLocked(w, task.ExpectedOutcomeID) {
+			return
+		}
+		if err := app.store.DeleteTask(task.ID, user.ID); err != nil {
+			writeAPIFailure(w, err, "Task")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		apiMethodNotAllowed(w, "GET, PUT, PATCH, DELETE")
+	}
+}
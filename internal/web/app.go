@@ -0,0 +1,240 @@
+// Package web serves the application's pages and JSON API and runs its
+// background mailer and job scheduler. Everything is reached through an App,
+// built by New from its dependencies.
+package web
+
+import (
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+
+	"staffperformance/internal/auth"
+	"staffperformance/internal/mail"
+	"staffperformance/internal/scoring"
+	"staffperformance/internal/store"
+)
+
+// App is the web application. Its handlers, mailer and scheduler reach the
+// database, sessions and configuration only through it, so tests can run one
+// against a scratch database.
+type App struct {
+	store          store.Store
+	auth           *auth.Authenticator
+	scoring        scoring.Strategy
+	ratings        RatingScale
+	mailer         mail.Sender
+	baseURL        string
+	login          LoginPolicy
+	trustProxy     bool
+	dir            string
+	templates      *template.Template
+	emailTemplates *texttemplate.Template
+	jobs           []Job
+	jobMu          sync.Mutex // Keeps a manual job run from overlapping a scheduled one
+	loginLocks     loginLocks // Per client address and username
+}
+
+// Options are what an App is built from
+type Options struct {
+	Store      store.Store
+	Auth       *auth.Authenticator
+	Scoring    scoring.Strategy // Scores objectives on dashboards and reports; must match Store's
+	Ratings    RatingScale      // DefaultRatingScale when empty
+	Mailer     mail.Sender      // Email is logged when nil
+	BaseURL    string           // Where links in email point
+	Login      LoginPolicy      // DefaultLoginPolicy when MaxFailures is zero
+	TrustProxy bool             // Client addresses come from X-Forwarded-For; only behind a proxy that sets it
+	Dir        string           // Holds the templates and static directories; the working directory when empty
+}
+
+// New creates an App, loading its page and email templates
+func New(opts Options) (*App, error) {
+	app := &App{
+		store:      opts.Store,
+		auth:       opts.Auth,
+		scoring:    opts.Scoring,
+		ratings:    opts.Ratings,
+		mailer:     opts.Mailer,
+		baseURL:    strings.TrimSuffix(opts.BaseURL, "/"),
+		login:      opts.Login,
+		trustProxy: opts.TrustProxy,
+		dir:        opts.Dir,
+	}
+	if len(app.ratings) == 0 {
+		app.ratings = DefaultRatingScale
+	}
+	if app.mailer == nil {
+		app.mailer = &mail.LogSender{}
+	}
+	if app.login.MaxFailures == 0 {
+		app.login = DefaultLoginPolicy
+	}
+	app.jobs = app.scheduledJobs()
+
+	var err error
+	app.templates, err = template.New("").Funcs(app.templateFuncs()).ParseGlob(filepath.Join(app.dir, "templates", "*.html"))
+	if err != nil {
+		return nil, err
+	}
+	// One email template per notification kind plus the digest. The first
+	// line of a rendered template is the subject.
+	app.emailTemplates, err = texttemplate.New("").ParseGlob(filepath.Join(app.dir, "templates", "email", "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// templateFuncs are helpers available to every template
+func (app *App) templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		// deref turns an optional ID into a value usable with eq; nil becomes 0
+		"deref": func(id *int) int {
+			if id == nil {
+				return 0
+			}
+			return *id
+		},
+		// rating describes an appraisal rating on the configured scale
+		"rating": app.ratings.Label,
+		// csrfField is the hidden input every POST form needs; render
+		// replaces it with one holding the request's token
+		"csrfField": func() template.HTML { return "" },
+	}
+}
+
+// render executes a page template. It runs a copy of the parsed templates
+// whose csrfField carries the request's CSRF token; the parsed set itself is
+// never executed, so it can always be copied.
+func (app *App) render(w http.ResponseWriter, r *http.Request, name string, data any) error {
+	t, err := app.templates.Clone()
+	if err != nil {
+		return err
+	}
+	field := template.HTML(`<input type="hidden" name="` + auth.CSRFField + `" value="` + template.HTMLEscapeString(auth.CSRFToken(r)) + `">`)
+	t.Funcs(template.FuncMap{"csrfField": func() template.HTML { return field }})
+	return t.ExecuteTemplate(w, name, data)
+}
+
+// Handler routes requests to the pages, the JSON API and the static files.
+// Browser requests that change anything must carry the session's CSRF token.
+func (app *App) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	// Serve static files
+	fs := http.FileServer(http.Dir(filepath.Join(app.dir, "static")))
+	mux.Handle("/static/", http.StripPrefix("/static/", fs))
+
+	// Public routes
+	mux.HandleFunc("/", app.homeHandler)
+	mux.HandleFunc("/login", app.loginHandler)
+	mux.HandleFunc("/logout", app.logoutHandler)
+	mux.HandleFunc("/register", app.registrationHandler)
+
+	// Protected routes
+	mux.HandleFunc("/dashboard", app.auth.RequireAuth(app.dashboardHandler))
+
+	// Main menu routes
+	mux.HandleFunc("/tasks", app.auth.RequireAuth(app.tasksHandler))
+	mux.HandleFunc("/reports", app.auth.RequireAuth(app.reportsHandler))
+	mux.HandleFunc("/reports/export", app.auth.RequireAuth(app.reportExportHandler))
+	mux.HandleFunc("/objectives", app.auth.RequireAuth(app.objectivesPageHandler))
+
+	// Task routes
+	mux.HandleFunc("/tasks/new", app.auth.RequireAuth(app.newTaskHandler))
+	mux.HandleFunc("/tasks/edit", app.auth.RequireAuth(app.editTaskHandler))
+	mux.HandleFunc("/tasks/assignment", app.auth.RequireAuth(app.taskAssignmentHandler))
+	mux.HandleFunc("/tasks/delete", app.auth.RequireAuth(app.deleteTaskHandler))
+
+	// Objective routes
+	mux.HandleFunc("/objectives/new", app.auth.RequireAuth(app.newObjectiveHandler))
+	mux.HandleFunc("/objectives/edit", app.auth.RequireAuth(app.editObjectiveHandler))
+	mux.HandleFunc("/objectives/delete", app.auth.RequireAuth(app.deleteObjectiveHandler))
+	mux.HandleFunc("/objectives/progress", app.auth.RequireAuth(app.objectiveProgressHandler))
+
+	// Expected Outcome routes
+	mux.HandleFunc("/outcomes/new", app.auth.RequireAuth(app.newExpectedOutcomeHandler))
+	mux.HandleFunc("/outcomes/edit", app.auth.RequireAuth(app.editExpectedOutcomeHandler))
+	mux.HandleFunc("/outcomes/delete", app.auth.RequireAuth(app.deleteExpectedOutcomeHandler))
+
+	// Activity routes
+	mux.HandleFunc("/activities/new", app.auth.RequireAuth(app.newActivityHandler))
+	mux.HandleFunc("/activities/edit", app.auth.RequireAuth(app.editActivityHandler))
+	mux.HandleFunc("/activities/delete", app.auth.RequireAuth(app.deleteActivityHandler))
+
+	// Project routes
+	mux.HandleFunc("/projects", app.auth.RequireAuth(app.projectListHandler))
+	mux.HandleFunc("/projects/new", app.auth.RequireRole(store.RoleAdmin, store.RoleSupervisor)(app.newProjectHandler))
+	mux.HandleFunc("/projects/edit", app.auth.RequireAuth(app.editProjectHandler))
+	mux.HandleFunc("/projects/delete", app.auth.RequireAuth(app.deleteProjectHandler))
+	mux.HandleFunc("/projects/view", app.auth.RequireAuth(app.viewProjectHandler))
+	mux.HandleFunc("/projects/assign", app.auth.RequireAuth(app.assignProjectMemberHandler))
+	mux.HandleFunc("/projects/unassign", app.auth.RequireAuth(app.unassignProjectMemberHandler))
+
+	// Staff management routes (Admin only)
+	mux.HandleFunc("/staff", app.auth.RequirePermission(auth.ResourceStaff, auth.ActionWrite)(app.staffListHandler))
+	mux.HandleFunc("/staff/new", app.auth.RequirePermission(auth.ResourceStaff, auth.ActionWrite)(app.newStaffHandler))
+	mux.HandleFunc("/staff/edit", app.auth.RequirePermission(auth.ResourceStaff, auth.ActionWrite)(app.editStaffHandler))
+	mux.HandleFunc("/staff/delete", app.auth.RequirePermission(auth.ResourceStaff, auth.ActionWrite)(app.deleteStaffHandler))
+	mux.HandleFunc("/staff/unlock", app.auth.RequirePermission(auth.ResourceStaff, auth.ActionWrite)(app.unlockStaffHandler))
+
+	// Department management routes (Admin only)
+	mux.HandleFunc("/departments", app.auth.RequirePermission(auth.ResourceDepartments, auth.ActionRead)(app.departmentListHandler))
+	mux.HandleFunc("/departments/new", app.auth.RequirePermission(auth.ResourceDepartments, auth.ActionWrite)(app.newDepartmentHandler))
+	mux.HandleFunc("/departments/edit", app.auth.RequirePermission(auth.ResourceDepartments, auth.ActionWrite)(app.editDepartmentHandler))
+	mux.HandleFunc("/departments/delete", app.auth.RequirePermission(auth.ResourceDepartments, auth.ActionWrite)(app.deleteDepartmentHandler))
+	mux.HandleFunc("/departments/members", app.auth.RequirePermission(auth.ResourceDepartments, auth.ActionWrite)(app.moveDepartmentMemberHandler))
+
+	// Review cycle routes
+	mux.HandleFunc("/cycles", app.auth.RequirePermission(auth.ResourceCycles, auth.ActionRead)(app.reviewCyclesHandler))
+	mux.HandleFunc("/cycles/new", app.auth.RequirePermission(auth.ResourceCycles, auth.ActionWrite)(app.newReviewCycleHandler))
+	mux.HandleFunc("/cycles/edit", app.auth.RequirePermission(auth.ResourceCycles, auth.ActionWrite)(app.editReviewCycleHandler))
+	mux.HandleFunc("/cycles/status", app.auth.RequirePermission(auth.ResourceCycles, auth.ActionWrite)(app.reviewCycleStatusHandler))
+	mux.HandleFunc("/cycles/delete", app.auth.RequirePermission(auth.ResourceCycles, auth.ActionWrite)(app.deleteReviewCycleHandler))
+
+	// Account routes
+	mux.HandleFunc("/account/password", app.auth.RequireAuth(app.changePasswordHandler))
+	mux.HandleFunc("/account/tokens", app.auth.RequirePermission(auth.ResourceTokens, auth.ActionWrite)(app.apiTokensHandler))
+	mux.HandleFunc("/account/signins", app.auth.RequireAuth(app.signInsHandler))
+	mux.HandleFunc("/account/tokens/revoke", app.auth.RequirePermission(auth.ResourceTokens, auth.ActionWrite)(app.revokeAPITokenHandler))
+	mux.HandleFunc("/admin/tokens", app.auth.RequireRole(store.RoleAdmin)(app.adminTokensHandler))
+
+	// Supervisor routes
+	mux.HandleFunc("/supervisor/dashboard", app.auth.RequireRole(store.RoleSupervisor, store.RoleAdmin)(app.supervisorDashboardHandler))
+	mux.HandleFunc("/supervisor/staff", app.auth.RequirePermission(auth.ResourceStaff, auth.ActionRead)(app.viewStaffReportHandler))
+	mux.HandleFunc("/supervisor/staff/pdf", app.auth.RequirePermission(auth.ResourceStaff, auth.ActionRead)(app.staffReportPDFHandler))
+	mux.HandleFunc("/supervisor/rating", app.auth.RequirePermission(auth.ResourceRatings, auth.ActionWrite)(app.rateObjectiveHandler))
+	mux.HandleFunc("/supervisor/export", app.auth.RequireRole(store.RoleSupervisor, store.RoleAdmin)(app.teamExportHandler))
+	mux.HandleFunc("/admin/reports/export", app.auth.RequireRole(store.RoleAdmin)(app.organisationExportHandler))
+
+	// Comment routes
+	mux.HandleFunc("/comments/new", app.auth.RequirePermission(auth.ResourceComments, auth.ActionWrite)(app.addCommentHandler))
+	mux.HandleFunc("/comments/delete", app.auth.RequirePermission(auth.ResourceComments, auth.ActionWrite)(app.deleteCommentHandler))
+
+	// Appraisal routes
+	mux.HandleFunc("/appraisals", app.auth.RequirePermission(auth.ResourceAppraisals, auth.ActionRead)(app.appraisalsHandler))
+	mux.HandleFunc("/appraisals/view", app.auth.RequirePermission(auth.ResourceAppraisals, auth.ActionRead)(app.appraisalHandler))
+	mux.HandleFunc("/appraisals/print", app.auth.RequirePermission(auth.ResourceAppraisals, auth.ActionRead)(app.appraisalPrintHandler))
+
+	// Audit routes
+	mux.HandleFunc("/admin/audit", app.auth.RequireRole(store.RoleAdmin)(app.auditLogHandler))
+	mux.HandleFunc("/admin/outbox", app.auth.RequireRole(store.RoleAdmin)(app.outboxHandler))
+	mux.HandleFunc("/admin/outbox/retry", app.auth.RequireRole(store.RoleAdmin)(app.retryEmailHandler))
+	mux.HandleFunc("/admin/jobs", app.auth.RequireRole(store.RoleAdmin)(app.jobsHandler))
+	mux.HandleFunc("/admin/jobs/run", app.auth.RequireRole(store.RoleAdmin)(app.runJobHandler))
+	mux.HandleFunc("/audit/history", app.auth.RequireAuth(app.auditHistoryHandler))
+
+	// Notification routes
+	mux.HandleFunc("/notifications", app.auth.RequireAuth(app.notificationsHandler))
+	mux.HandleFunc("/notifications/open", app.auth.RequireAuth(app.openNotificationHandler))
+	mux.HandleFunc("/notifications/read", app.auth.RequireAuth(app.markNotificationsReadHandler))
+	mux.HandleFunc("/notifications/preferences", app.auth.RequireAuth(app.notificationPreferencesHandler))
+
+	// JSON API
+	app.registerAPIRoutes(mux)
+
+	return app.auth.ProtectCSRF(mux)
+}
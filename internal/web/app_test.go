@@ -0,0 +1,1067 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/sessions"
+
+	"staffperformance/internal/auth"
+	"staffperformance/internal/scoring"
+	"staffperformance/internal/store"
+)
+
+// Handler tests drive a real App through HTTP against a new SQLite file, with
+// the templates from the repository root.
+
+const testPassword = "password123"
+
+// testServer is an App served by httptest, with its store for setup and checks
+type testServer struct {
+	*httptest.Server
+	app   *App
+	store store.Store
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	return newTestServerWith(t, Options{})
+}
+
+// newTestServerWith serves an App created with opts, after filling in the
+// store, authenticator, template directory and, unless set, scoring strategy
+func newTestServerWith(t *testing.T, opts Options) *testServer {
+	t.Helper()
+	if opts.Scoring == nil {
+		opts.Scoring = scoring.TaskActivityMean{}
+	}
+	s, err := store.OpenSQLiteStore(filepath.Join(t.TempDir(), "test.db"), opts.Scoring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	key := []byte("0123456789abcdef0123456789abcdef")
+	authenticator := auth.NewAuthenticator(s, [][]byte{key}, sessions.Options{Path: "/", HttpOnly: true})
+	opts.Store = s
+	opts.Auth = authenticator
+	opts.Dir = filepath.Join("..", "..")
+	app, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(app.Handler())
+	t.Cleanup(srv.Close)
+	return &testServer{Server: srv, app: app, store: s}
+}
+
+// createUser adds a user whose password is testPassword
+func (ts *testServer) createUser(t *testing.T, username string, role store.UserRole, supervisor *store.User) *store.User {
+	t.Helper()
+	hash, err := auth.HashPassword(testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &store.User{Username: username, Password: hash, FullName: username, Role: role}
+	if supervisor != nil {
+		user.SupervisorID = &supervisor.ID
+	}
+	if err := ts.store.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// createOutcome adds a public objective for owner with one expected outcome
+func (ts *testServer) createOutcome(t *testing.T, owner *store.User) *store.ExpectedOutcome {
+	t.Helper()
+	obj := &store.Objective{
+		UserID:     owner.ID,
+		Title:      owner.Username + "'s objective",
+		Visibility: store.VisibilityPublic,
+		Status:     store.StatusOnTrack,
+		Category:   store.CategoryPeople,
+	}
+	if err := ts.store.CreateObjective(obj, owner.ID); err != nil {
+		t.Fatal(err)
+	}
+	outcome := &store.ExpectedOutcome{ObjectiveID: obj.ID, Title: "Outcome"}
+	if err := ts.store.CreateExpectedOutcome(outcome, owner.ID); err != nil {
+		t.Fatal(err)
+	}
+	return outcome
+}
+
+// testClient is a browser that keeps its cookies and the CSRF token of the
+// last page it loaded with a form. It does not follow redirects.
+type testClient struct {
+	*http.Client
+	csrfToken string
+}
+
+var csrfFieldPattern = regexp.MustCompile(`name="` + auth.CSRFField + `" value="([^"]+)"`)
+
+// client returns a signed-out client holding the login page's CSRF token
+func (ts *testServer) client(t *testing.T) *testClient {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &testClient{Client: &http.Client{
+		Jar: jar,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+	expectStatus(t, ts.get(t, c, "/"), http.StatusOK)
+	return c
+}
+
+// login signs in as username and returns the signed-in client. Signing in
+// replaces the session's CSRF token, so it loads a page with a form for the
+// new one.
+func (ts *testServer) login(t *testing.T, username string) *testClient {
+	t.Helper()
+	c := ts.client(t)
+	resp := ts.post(t, c, "/login", url.Values{"username": {username}, "password": {testPassword}})
+	expectRedirect(t, resp, "/dashboard")
+	expectStatus(t, ts.get(t, c, "/account/password"), http.StatusOK)
+	return c
+}
+
+func (ts *testServer) get(t *testing.T, c *testClient, path string) *http.Response {
+	t.Helper()
+	resp, err := c.Get(ts.URL + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := csrfFieldPattern.FindSubmatch(body); m != nil {
+		c.csrfToken = string(m[1])
+	}
+	return resp
+}
+
+// post submits a form with the client's CSRF token
+func (ts *testServer) post(t *testing.T, c *testClient, path string, form url.Values) *http.Response {
+	t.Helper()
+	if form == nil {
+		form = url.Values{}
+	}
+	form.Set(auth.CSRFField, c.csrfToken)
+	resp, err := c.PostForm(ts.URL+path, form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func expectStatus(t *testing.T, resp *http.Response, want int) {
+	t.Helper()
+	if resp.StatusCode != want {
+		t.Fatalf("%s %s: got status %d, want %d", resp.Request.Method, resp.Request.URL.Path, resp.StatusCode, want)
+	}
+}
+
+func expectRedirect(t *testing.T, resp *http.Response, location string) {
+	t.Helper()
+	expectStatus(t, resp, http.StatusSeeOther)
+	if got := resp.Header.Get("Location"); got != location {
+		t.Fatalf("%s %s: redirected to %q, want %q", resp.Request.Method, resp.Request.URL.Path, got, location)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	ts := newTestServer(t)
+	ts.createUser(t, "alice", store.RoleStaff, nil)
+
+	anonymous := ts.client(t)
+	expectRedirect(t, ts.get(t, anonymous, "/dashboard"), "/")
+	expectStatus(t, ts.get(t, anonymous, "/"), http.StatusOK)
+
+	resp := ts.post(t, anonymous, "/login", url.Values{"username": {"alice"}, "password": {"wrong"}})
+	expectStatus(t, resp, http.StatusUnauthorized)
+	resp = ts.post(t, anonymous, "/login", url.Values{"username": {"nobody"}, "password": {testPassword}})
+	expectStatus(t, resp, http.StatusUnauthorized)
+	expectRedirect(t, ts.get(t, anonymous, "/dashboard"), "/")
+
+	alice := ts.login(t, "alice")
+	expectStatus(t, ts.get(t, alice, "/dashboard"), http.StatusOK)
+	expectStatus(t, ts.get(t, alice, "/tasks"), http.StatusOK)
+
+	// Signing out takes a POST with the CSRF token, so a link or image on
+	// another site cannot do it
+	expectStatus(t, ts.get(t, alice, "/logout"), http.StatusMethodNotAllowed)
+	resp, err := alice.PostForm(ts.URL+"/logout", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	expectStatus(t, resp, http.StatusForbidden)
+	expectStatus(t, ts.get(t, alice, "/dashboard"), http.StatusOK)
+
+	expectRedirect(t, ts.post(t, alice, "/logout", nil), "/")
+	expectRedirect(t, ts.get(t, alice, "/dashboard"), "/")
+}
+
+func TestLoginDefaultAdminMustChangePassword(t *testing.T) {
+	ts := newTestServer(t)
+	if err := auth.CreateDefaultAdmin(ts.store); err != nil {
+		t.Fatal(err)
+	}
+
+	c := ts.client(t)
+	resp := ts.post(t, c, "/login", url.Values{"username": {"admin"}, "password": {"admin123"}})
+	expectRedirect(t, resp, "/account/password")
+	expectRedirect(t, ts.get(t, c, "/dashboard"), "/account/password")
+}
+
+func TestObjectiveCRUD(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.createUser(t, "alice", store.RoleStaff, nil)
+	ts.createUser(t, "bob", store.RoleStaff, nil)
+	aliceClient := ts.login(t, "alice")
+	bobClient := ts.login(t, "bob")
+
+	expectStatus(t, ts.get(t, aliceClient, "/objectives/new"), http.StatusOK)
+	form := url.Values{
+		"title":      {"Reduce costs"},
+		"start_date": {"2026-01-01"},
+		"end_date":   {"2026-12-31"},
+		"visibility": {string(store.VisibilityPublic)},
+		"status":     {string(store.StatusOnTrack)},
+		"category":   {string(store.CategoryFinancial)},
+		"weight":     {"50"},
+	}
+	expectRedirect(t, ts.post(t, aliceClient, "/objectives/new", form), "/dashboard")
+
+	objectives, err := ts.store.GetObjectivesByUserID(alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objectives) != 1 || objectives[0].Title != "Reduce costs" || objectives[0].Weight != 50 {
+		t.Fatalf("got objectives %+v, want one titled Reduce costs weighted 50", objectives)
+	}
+	path := "/objectives/edit?id=" + strconv.Itoa(objectives[0].ID)
+	expectStatus(t, ts.get(t, aliceClient, path), http.StatusOK)
+
+	form.Set("title", "Reduce travel costs")
+	form.Set("status", string(store.StatusComplete))
+	expectRedirect(t, ts.post(t, aliceClient, path, form), "/dashboard")
+	obj, err := ts.store.GetObjectiveByID(objectives[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.Title != "Reduce travel costs" || obj.Status != store.StatusComplete {
+		t.Fatalf("got objective %q with status %q after edit", obj.Title, obj.Status)
+	}
+
+	// Another staff member can neither edit nor delete it
+	form.Set("title", "Taken over")
+	expectStatus(t, ts.post(t, bobClient, path, form), http.StatusForbidden)
+	deletePath := "/objectives/delete?id=" + strconv.Itoa(obj.ID)
+	expectStatus(t, ts.post(t, bobClient, deletePath, nil), http.StatusForbidden)
+	if obj, err := ts.store.GetObjectiveByID(obj.ID); err != nil || obj.Title != "Reduce travel costs" {
+		t.Fatalf("objective changed by another user: %+v, %v", obj, err)
+	}
+
+	expectRedirect(t, ts.post(t, aliceClient, deletePath, nil), "/dashboard")
+	if _, err := ts.store.GetObjectiveByID(obj.ID); err == nil {
+		t.Fatal("objective still exists after delete")
+	}
+}
+
+func TestObjectiveWeightValidation(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.createUser(t, "alice", store.RoleStaff, nil)
+	c := ts.login(t, "alice")
+
+	form := url.Values{
+		"title":      {"Reduce costs"},
+		"visibility": {string(store.VisibilityPublic)},
+		"status":     {string(store.StatusOnTrack)},
+		"category":   {string(store.CategoryFinancial)},
+	}
+	for _, weight := range []string{"NaN", "Inf", "-Inf", "-5", "100.5", "heavy"} {
+		form.Set("weight", weight)
+		expectStatus(t, ts.post(t, c, "/objectives/new", form), http.StatusBadRequest)
+	}
+	if objectives, err := ts.store.GetObjectivesByUserID(alice.ID); err != nil || len(objectives) != 0 {
+		t.Fatalf("got objectives %+v, %v, want none", objectives, err)
+	}
+
+	form.Set("weight", "40")
+	expectRedirect(t, ts.post(t, c, "/objectives/new", form), "/dashboard")
+	objectives, err := ts.store.GetObjectivesByUserID(alice.ID)
+	if err != nil || len(objectives) != 1 {
+		t.Fatalf("got objectives %+v, %v, want one", objectives, err)
+	}
+	path := "/objectives/edit?id=" + strconv.Itoa(objectives[0].ID)
+	for _, weight := range []string{"NaN", "101"} {
+		form.Set("weight", weight)
+		expectStatus(t, ts.post(t, c, path, form), http.StatusBadRequest)
+	}
+	if obj, err := ts.store.GetObjectiveByID(objectives[0].ID); err != nil || obj.Weight != 40 {
+		t.Fatalf("objective weight changed by an invalid edit: %+v, %v", obj, err)
+	}
+}
+
+func TestManualRatingPermissions(t *testing.T) {
+	ts := newTestServerWith(t, Options{Scoring: scoring.ManualRating{Fallback: scoring.TaskActivityMean{}}})
+	boss := ts.createUser(t, "boss", store.RoleSupervisor, nil)
+	alice := ts.createUser(t, "alice", store.RoleStaff, boss)
+	carol := ts.createUser(t, "carol", store.RoleStaff, nil)
+	aliceObjective := ts.createOutcome(t, alice).ObjectiveID
+	bossObjective := ts.createOutcome(t, boss).ObjectiveID
+	carolObjective := ts.createOutcome(t, carol).ObjectiveID
+	aliceClient := ts.login(t, "alice")
+	bossClient := ts.login(t, "boss")
+
+	rating := func(id int) *float64 {
+		t.Helper()
+		obj, err := ts.store.GetObjectiveByID(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return obj.ManualRating
+	}
+	rate := func(c *testClient, id int, value string) *http.Response {
+		t.Helper()
+		return ts.post(t, c, "/supervisor/rating", url.Values{"objective_id": {strconv.Itoa(id)}, "manual_rating": {value}})
+	}
+
+	// Owners cannot rate their own objectives, from the objective form or the
+	// supervisor view
+	form := url.Values{
+		"title":         {"Rated by myself"},
+		"visibility":    {string(store.VisibilityPublic)},
+		"status":        {string(store.StatusOnTrack)},
+		"category":      {string(store.CategoryPeople)},
+		"manual_rating": {"100"},
+	}
+	expectStatus(t, ts.post(t, aliceClient, "/objectives/edit?id="+strconv.Itoa(aliceObjective), form), http.StatusSeeOther)
+	expectStatus(t, rate(aliceClient, aliceObjective, "100"), http.StatusForbidden)
+	expectStatus(t, rate(bossClient, bossObjective, "100"), http.StatusForbidden)
+	if got := rating(aliceObjective); got != nil {
+		t.Fatalf("alice rated her own objective %v", *got)
+	}
+	if got := rating(bossObjective); got != nil {
+		t.Fatalf("boss rated their own objective %v", *got)
+	}
+
+	// Supervisors rate their staff, and only their staff
+	expectStatus(t, rate(bossClient, carolObjective, "90"), http.StatusForbidden)
+	for _, value := range []string{"NaN", "-1", "101", "good"} {
+		expectStatus(t, rate(bossClient, aliceObjective, value), http.StatusBadRequest)
+	}
+	expectRedirect(t, rate(bossClient, aliceObjective, "80"), "/supervisor/staff?id="+strconv.Itoa(alice.ID))
+	if got := rating(aliceObjective); got == nil || *got != 80 {
+		t.Fatalf("got rating %v, want 80", got)
+	}
+	expectStatus(t, ts.get(t, bossClient, "/supervisor/staff?id="+strconv.Itoa(alice.ID)), http.StatusOK)
+	expectStatus(t, ts.get(t, aliceClient, "/objectives/edit?id="+strconv.Itoa(aliceObjective)), http.StatusOK)
+	expectRedirect(t, rate(bossClient, aliceObjective, ""), "/supervisor/staff?id="+strconv.Itoa(alice.ID))
+	if got := rating(aliceObjective); got != nil {
+		t.Fatalf("got rating %v, want it cleared", *got)
+	}
+
+	// The API applies the same rules
+	api := func(user *store.User, method, path, body string) *http.Response {
+		t.Helper()
+		plaintext, hash, err := auth.GenerateAPIToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		apiToken := &store.APIToken{UserID: user.ID, Name: "script", Prefix: plaintext[:auth.APITokenDisplayLength], Scope: store.TokenScopeWrite}
+		if err := ts.store.CreateAPIToken(apiToken, hash); err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+plaintext)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	objectivePath := "/api/v1/objectives/" + strconv.Itoa(aliceObjective)
+	expectStatus(t, api(alice, http.MethodPatch, objectivePath, `{"manual_rating": 100}`), http.StatusUnprocessableEntity)
+	expectStatus(t, api(alice, http.MethodPost, "/api/v1/objectives", `{"title": "Mine", "manual_rating": 100}`), http.StatusUnprocessableEntity)
+	expectStatus(t, api(alice, http.MethodPut, objectivePath+"/rating", `{"manual_rating": 100}`), http.StatusForbidden)
+	expectStatus(t, api(boss, http.MethodPut, "/api/v1/objectives/"+strconv.Itoa(carolObjective)+"/rating", `{"manual_rating": 90}`), http.StatusForbidden)
+	expectStatus(t, api(boss, http.MethodPut, objectivePath+"/rating", `{"manual_rating": 101}`), http.StatusUnprocessableEntity)
+	expectStatus(t, api(boss, http.MethodPut, objectivePath+"/rating", `{"manual_rating": 65}`), http.StatusOK)
+	if got := rating(aliceObjective); got == nil || *got != 65 {
+		t.Fatalf("got rating %v, want 65", got)
+	}
+}
+
+func TestStaffReportsFromHierarchy(t *testing.T) {
+	ts := newTestServer(t)
+	boss := ts.createUser(t, "boss", store.RoleSupervisor, nil)
+	alice := ts.createUser(t, "alice", store.RoleStaff, boss)
+	bob := ts.createUser(t, "bob", store.RoleStaff, boss)
+	outcome := ts.createOutcome(t, alice)
+	activity := &store.Activity{ExpectedOutcomeID: outcome.ID, Title: "Weekly sync", Category: store.CategoryWeekly, ProgressPercentage: 50}
+	if err := ts.store.CreateActivity(activity, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	tasks := []*store.Task{
+		{UserID: alice.ID, Title: "Write notes", Priority: store.PriorityLow, Status: store.TaskStatusCompleted,
+			TaskType: store.TaskTypePersonal, DueDate: now, ExpectedOutcomeID: &outcome.ID},
+		{UserID: bob.ID, Title: "File report", Priority: store.PriorityHigh, Status: store.TaskStatusPending,
+			TaskType: store.TaskTypePersonal, DueDate: now.AddDate(0, 0, -3)},
+	}
+	for _, task := range tasks {
+		if err := ts.store.CreateTask(task, task.UserID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ts.store.CreateComment(&store.Comment{ObjectiveID: &outcome.ObjectiveID, UserID: boss.ID, CommentText: "Keep going"}); err != nil {
+		t.Fatal(err)
+	}
+
+	sheets, err := ts.app.staffReportSheets(alice, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []int{1, 1, 1, 1} {
+		if len(sheets[i].Rows) != want {
+			t.Errorf("%s sheet has %d rows, want %d", sheets[i].Name, len(sheets[i].Rows), want)
+		}
+	}
+	if row := sheets[2].Rows[0]; row[3] != "Weekly sync" || row[2] != "Outcome" {
+		t.Errorf("activity row = %v", row)
+	}
+	if row := sheets[3].Rows[0]; row[2] != "alice's objective" || row[3] != "Outcome" {
+		t.Errorf("task row = %v, want it placed under its objective and outcome", row)
+	}
+
+	summaries, err := ts.app.summarizeStaff([]store.User{*alice, *bob}, nil, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := summaries[0]; s.Objectives != 1 || s.TasksCompleted != 1 || s.TasksOpen != 0 {
+		t.Errorf("alice's summary = %+v", s)
+	}
+	if s := summaries[1]; s.Objectives != 0 || s.TasksOpen != 1 || s.TasksOverdue != 1 {
+		t.Errorf("bob's summary = %+v", s)
+	}
+
+	c := ts.login(t, "boss")
+	expectStatus(t, ts.get(t, c, "/supervisor/staff?id="+strconv.Itoa(alice.ID)), http.StatusOK)
+	expectStatus(t, ts.get(t, c, "/supervisor/staff/pdf?id="+strconv.Itoa(alice.ID)), http.StatusOK)
+}
+
+func TestTaskCRUD(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.createUser(t, "alice", store.RoleStaff, nil)
+	ts.createUser(t, "bob", store.RoleStaff, nil)
+	aliceClient := ts.login(t, "alice")
+	bobClient := ts.login(t, "bob")
+
+	expectStatus(t, ts.get(t, aliceClient, "/tasks/new"), http.StatusOK)
+	form := url.Values{
+		"title":    {"File expenses"},
+		"priority": {string(store.PriorityHigh)},
+		"status":   {string(store.TaskStatusPending)},
+		"due_date": {"2026-06-30"},
+	}
+	expectRedirect(t, ts.post(t, aliceClient, "/tasks/new", form), "/tasks")
+
+	tasks, err := ts.store.GetTasksByUserID(alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 || tasks[0].Title != "File expenses" || tasks[0].TaskType != store.TaskTypePersonal {
+		t.Fatalf("got tasks %+v, want one personal task titled File expenses", tasks)
+	}
+	path := "/tasks/edit?id=" + strconv.Itoa(tasks[0].ID)
+	expectStatus(t, ts.get(t, aliceClient, path), http.StatusOK)
+
+	form.Set("status", string(store.TaskStatusCompleted))
+	expectRedirect(t, ts.post(t, aliceClient, path, form), "/tasks")
+	task, err := ts.store.GetTaskByID(tasks[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Status != store.TaskStatusCompleted || task.CompletedAt == nil || task.CompletionPercentage != 100 {
+		t.Fatalf("completed task has status %q, completed at %v, %v%%", task.Status, task.CompletedAt, task.CompletionPercentage)
+	}
+
+	// Another staff member can neither see, edit nor delete it
+	expectStatus(t, ts.get(t, bobClient, path), http.StatusForbidden)
+	expectStatus(t, ts.post(t, bobClient, path, form), http.StatusForbidden)
+	deletePath := "/tasks/delete?id=" + strconv.Itoa(task.ID)
+	expectStatus(t, ts.post(t, bobClient, deletePath, nil), http.StatusForbidden)
+	if _, err := ts.store.GetTaskByID(task.ID); err != nil {
+		t.Fatalf("task deleted by another user: %v", err)
+	}
+
+	expectRedirect(t, ts.post(t, aliceClient, deletePath, nil), "/tasks")
+	if _, err := ts.store.GetTaskByID(task.ID); err == nil {
+		t.Fatal("task still exists after delete")
+	}
+}
+
+func TestTaskOutcomeOwnership(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.createUser(t, "alice", store.RoleStaff, nil)
+	bob := ts.createUser(t, "bob", store.RoleStaff, nil)
+	aliceOutcome := ts.createOutcome(t, alice)
+	bobOutcome := ts.createOutcome(t, bob)
+	bobClient := ts.login(t, "bob")
+
+	form := url.Values{
+		"title":               {"Count me"},
+		"priority":            {string(store.PriorityLow)},
+		"status":              {string(store.TaskStatusPending)},
+		"expected_outcome_id": {strconv.Itoa(aliceOutcome.ID)},
+	}
+	expectStatus(t, ts.post(t, bobClient, "/tasks/new", form), http.StatusForbidden)
+	form.Set("expected_outcome_id", "999")
+	expectStatus(t, ts.post(t, bobClient, "/tasks/new", form), http.StatusBadRequest)
+	form.Set("expected_outcome_id", strconv.Itoa(bobOutcome.ID))
+	expectRedirect(t, ts.post(t, bobClient, "/tasks/new", form), "/tasks")
+
+	// Nor can a task be moved onto someone else's outcome afterwards
+	tasks, err := ts.store.GetTasksByUserID(bob.ID)
+	if err != nil || len(tasks) != 1 {
+		t.Fatalf("got tasks %+v, %v, want one", tasks, err)
+	}
+	form.Set("expected_outcome_id", strconv.Itoa(aliceOutcome.ID))
+	expectStatus(t, ts.post(t, bobClient, "/tasks/edit?id="+strconv.Itoa(tasks[0].ID), form), http.StatusForbidden)
+	task, err := ts.store.GetTaskByID(tasks[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.ExpectedOutcomeID == nil || *task.ExpectedOutcomeID != bobOutcome.ID {
+		t.Fatalf("task linked to outcome %v, want %d", task.ExpectedOutcomeID, bobOutcome.ID)
+	}
+}
+
+func TestClosedCycleLocksTasks(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.createUser(t, "alice", store.RoleStaff, nil)
+	closed := ts.createOutcome(t, alice)
+	open := ts.createOutcome(t, alice)
+	cycle := &store.ReviewCycle{
+		Name:      "FY2026 H1",
+		StartDate: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC),
+		Status:    store.CycleStatusClosed,
+	}
+	if err := ts.store.CreateReviewCycle(cycle); err != nil {
+		t.Fatal(err)
+	}
+	obj, err := ts.store.GetObjectiveByID(closed.ObjectiveID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj.ReviewCycleID = &cycle.ID
+	if err := ts.store.UpdateObjective(obj, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	newTask := func(outcomeID int) *store.Task {
+		task := &store.Task{UserID: alice.ID, Title: "Counted", Priority: store.PriorityLow, Status: store.TaskStatusPending, TaskType: store.TaskTypePersonal, ExpectedOutcomeID: &outcomeID}
+		if err := ts.store.CreateTask(task, alice.ID); err != nil {
+			t.Fatal(err)
+		}
+		return task
+	}
+	locked := newTask(closed.ID)
+	unlocked := newTask(open.ID)
+
+	// Tasks of a closed cycle cannot be changed, completed or deleted in
+	// the browser, and no task can be moved into it
+	c := ts.login(t, "alice")
+	form := url.Values{
+		"title":               {"Counted"},
+		"priority":            {string(store.PriorityLow)},
+		"status":              {string(store.TaskStatusCompleted)},
+		"expected_outcome_id": {strconv.Itoa(closed.ID)},
+	}
+	expectStatus(t, ts.post(t, c, "/tasks/new", form), http.StatusConflict)
+	expectStatus(t, ts.post(t, c, "/tasks/edit?id="+strconv.Itoa(locked.ID), form), http.StatusConflict)
+	expectStatus(t, ts.post(t, c, "/tasks/edit?id="+strconv.Itoa(unlocked.ID), form), http.StatusConflict)
+	expectStatus(t, ts.post(t, c, "/tasks/delete", url.Values{"id": {strconv.Itoa(locked.ID)}}), http.StatusConflict)
+
+	// Nor through the API
+	plaintext, hash, err := auth.GenerateAPIToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiToken := &store.APIToken{UserID: alice.ID, Name: "script", Prefix: plaintext[:auth.APITokenDisplayLength], Scope: store.TokenScopeWrite}
+	if err := ts.store.CreateAPIToken(apiToken, hash); err != nil {
+		t.Fatal(err)
+	}
+	api := func(method, path, body string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+plaintext)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	link := fmt.Sprintf(`{"title": "Counted", "expected_outcome_id": %d}`, closed.ID)
+	expectStatus(t, api(http.MethodPost, "/api/v1/tasks", link), http.StatusConflict)
+	expectStatus(t, api(http.MethodPatch, "/api/v1/tasks/"+strconv.Itoa(locked.ID), `{"status": "Completed"}`), http.StatusConflict)
+	expectStatus(t, api(http.MethodPatch, "/api/v1/tasks/"+strconv.Itoa(unlocked.ID), link), http.StatusConflict)
+	expectStatus(t, api(http.MethodDelete, "/api/v1/tasks/"+strconv.Itoa(locked.ID), ""), http.StatusConflict)
+
+	for _, task := range []*store.Task{locked, unlocked} {
+		got, err := ts.store.GetTaskByID(task.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != store.TaskStatusPending || *got.ExpectedOutcomeID != *task.ExpectedOutcomeID {
+			t.Fatalf("task %d changed in a closed cycle: %+v", task.ID, got)
+		}
+	}
+	tasks, err := ts.store.GetTasksByUserID(alice.ID)
+	if err != nil || len(tasks) != 2 {
+		t.Fatalf("got tasks %+v, %v, want the two created before", tasks, err)
+	}
+
+	// Tasks outside the cycle can still be completed
+	form.Set("expected_outcome_id", strconv.Itoa(open.ID))
+	expectRedirect(t, ts.post(t, c, "/tasks/edit?id="+strconv.Itoa(unlocked.ID), form), "/tasks")
+}
+
+func TestSupervisorAccess(t *testing.T) {
+	ts := newTestServer(t)
+	sam := ts.createUser(t, "sam", store.RoleSupervisor, nil)
+	ts.createUser(t, "sue", store.RoleSupervisor, nil)
+	alice := ts.createUser(t, "alice", store.RoleStaff, sam)
+	bob := ts.createUser(t, "bob", store.RoleStaff, nil)
+	samClient := ts.login(t, "sam")
+	sueClient := ts.login(t, "sue")
+	aliceClient := ts.login(t, "alice")
+
+	obj := &store.Objective{
+		UserID:     alice.ID,
+		Title:      "Ship the release",
+		Visibility: store.VisibilityPublic,
+		Status:     store.StatusOnTrack,
+		Category:   store.CategoryPeople,
+	}
+	if err := ts.store.CreateObjective(obj, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	aliceReport := "/supervisor/staff?id=" + strconv.Itoa(alice.ID)
+
+	// Supervisors see their own staff and nobody else's
+	expectStatus(t, ts.get(t, samClient, "/supervisor/dashboard"), http.StatusOK)
+	expectStatus(t, ts.get(t, samClient, aliceReport), http.StatusOK)
+	expectStatus(t, ts.get(t, samClient, "/supervisor/staff?id="+strconv.Itoa(bob.ID)), http.StatusForbidden)
+	expectStatus(t, ts.get(t, sueClient, aliceReport), http.StatusForbidden)
+
+	// Staff have no supervisor pages, not even their own report
+	expectStatus(t, ts.get(t, aliceClient, "/supervisor/dashboard"), http.StatusForbidden)
+	expectStatus(t, ts.get(t, aliceClient, aliceReport), http.StatusForbidden)
+
+	// A supervisor comments on their staff's objectives but cannot edit them
+	comment := url.Values{
+		"objective_id": {strconv.Itoa(obj.ID)},
+		"staff_id":     {strconv.Itoa(alice.ID)},
+		"comment_text": {"Good progress"},
+	}
+	expectRedirect(t, ts.post(t, samClient, "/comments/new", comment), aliceReport)
+	expectStatus(t, ts.post(t, sueClient, "/comments/new", comment), http.StatusForbidden)
+	comments, err := ts.store.GetCommentsByObjective(obj.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 1 || comments[0].UserID != sam.ID {
+		t.Fatalf("got comments %+v, want one from the supervisor", comments)
+	}
+
+	edit := url.Values{"title": {"Rewritten"}, "visibility": {string(store.VisibilityPublic)}}
+	expectStatus(t, ts.post(t, samClient, "/objectives/edit?id="+strconv.Itoa(obj.ID), edit), http.StatusForbidden)
+	if got, err := ts.store.GetObjectiveByID(obj.ID); err != nil || got.Title != "Ship the release" {
+		t.Fatalf("objective changed by supervisor: %+v, %v", got, err)
+	}
+}
+
+func TestCSRF(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.createUser(t, "alice", store.RoleStaff, nil)
+	c := ts.login(t, "alice")
+	task := &store.Task{UserID: alice.ID, Title: "Keep me", Priority: store.PriorityLow, Status: store.TaskStatusPending, TaskType: store.TaskTypePersonal}
+	if err := ts.store.CreateTask(task, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	deletePath := "/tasks/delete?id=" + strconv.Itoa(task.ID)
+
+	// Forms without the session's token are refused, as is a token from
+	// another session
+	resp, err := c.PostForm(ts.URL+deletePath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	expectStatus(t, resp, http.StatusForbidden)
+	other := ts.client(t)
+	resp, err = c.PostForm(ts.URL+deletePath, url.Values{auth.CSRFField: {other.csrfToken}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	expectStatus(t, resp, http.StatusForbidden)
+
+	// The token from before signing in no longer works after
+	signedOut := ts.client(t)
+	staleToken := signedOut.csrfToken
+	resp = ts.post(t, signedOut, "/login", url.Values{"username": {"alice"}, "password": {testPassword}})
+	expectRedirect(t, resp, "/dashboard")
+	signedOut.csrfToken = staleToken
+	expectStatus(t, ts.post(t, signedOut, deletePath, nil), http.StatusForbidden)
+
+	// Deletes need POST
+	expectStatus(t, ts.get(t, c, deletePath), http.StatusMethodNotAllowed)
+	if _, err := ts.store.GetTaskByID(task.ID); err != nil {
+		t.Fatalf("task deleted without a valid token: %v", err)
+	}
+
+	// Scripts may send the token in a header instead
+	req, err := http.NewRequest(http.MethodPost, ts.URL+deletePath, strings.NewReader(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(auth.CSRFHeader, c.csrfToken)
+	resp, err = c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	expectRedirect(t, resp, "/tasks")
+	if _, err := ts.store.GetTaskByID(task.ID); err == nil {
+		t.Fatal("task still exists after delete")
+	}
+
+	// API requests with a bearer token carry no cookies and need no token
+	if err := ts.store.CreateTask(task, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	plaintext, hash, err := auth.GenerateAPIToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiToken := &store.APIToken{UserID: alice.ID, Name: "script", Prefix: plaintext[:auth.APITokenDisplayLength], Scope: store.TokenScopeWrite}
+	if err := ts.store.CreateAPIToken(apiToken, hash); err != nil {
+		t.Fatal(err)
+	}
+	req, err = http.NewRequest(http.MethodDelete, ts.URL+"/api/v1/tasks/"+strconv.Itoa(task.ID), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+plaintext)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	expectStatus(t, resp, http.StatusNoContent)
+}
+
+// signIn posts the login form with a password
+func (ts *testServer) signIn(t *testing.T, c *testClient, username, password string) *http.Response {
+	t.Helper()
+	return ts.post(t, c, "/login", url.Values{"username": {username}, "password": {password}})
+}
+
+func TestLoginThrottle(t *testing.T) {
+	ts := newTestServer(t)
+	ts.createUser(t, "alice", store.RoleStaff, nil)
+	c := ts.client(t)
+
+	// The first failures are free; the next attempt must wait, even with the
+	// right password
+	for range loginFreeAttempts {
+		expectStatus(t, ts.signIn(t, c, "alice", "wrong"), http.StatusUnauthorized)
+	}
+	resp := ts.signIn(t, c, "alice", testPassword)
+	expectStatus(t, resp, http.StatusTooManyRequests)
+	if got := resp.Header.Get("Retry-After"); got != "1" {
+		t.Fatalf("got Retry-After %q, want 1", got)
+	}
+
+	// Guessing many usernames from one address is throttled too
+	ts = newTestServer(t)
+	c = ts.client(t)
+	for i := range loginFreeAttemptsIP {
+		expectStatus(t, ts.signIn(t, c, "nobody"+strconv.Itoa(i), "wrong"), http.StatusUnauthorized)
+	}
+	expectStatus(t, ts.signIn(t, c, "someone", "wrong"), http.StatusTooManyRequests)
+
+	// Signing in to one's own account in between does not clear them
+	ts = newTestServer(t)
+	ts.createUser(t, "mallory", store.RoleStaff, nil)
+	c = ts.client(t)
+	for i := range loginFreeAttemptsIP - 1 {
+		expectStatus(t, ts.signIn(t, c, "nobody"+strconv.Itoa(i), "wrong"), http.StatusUnauthorized)
+	}
+	expectRedirect(t, ts.signIn(t, ts.client(t), "mallory", testPassword), "/dashboard")
+	expectStatus(t, ts.signIn(t, c, "victim", "wrong"), http.StatusUnauthorized)
+	expectStatus(t, ts.signIn(t, c, "victim", "wrong"), http.StatusTooManyRequests)
+}
+
+func TestLoginHidesUnknownUsers(t *testing.T) {
+	ts := newTestServer(t)
+	ts.createUser(t, "alice", store.RoleStaff, nil)
+
+	// Unknown usernames and wrong passwords get the same answer
+	unknown := ts.signIn(t, ts.client(t), "nobody", testPassword)
+	wrong := ts.signIn(t, ts.client(t), "alice", "wrong")
+	expectStatus(t, unknown, http.StatusUnauthorized)
+	expectStatus(t, wrong, http.StatusUnauthorized)
+
+	events, err := ts.store.GetRecentLoginEvents("nobody", "", time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].UserID != nil || events[0].Failure != store.LoginUnknownUser {
+		t.Fatalf("got sign-ins %+v, want one failure for an unknown user", events)
+	}
+}
+
+func TestLoginThrottleParallel(t *testing.T) {
+	ts := newTestServer(t)
+	ts.createUser(t, "alice", store.RoleStaff, nil)
+	c := ts.client(t)
+
+	// Attempts sent together still only get the free attempts through; the
+	// rest wait for the backoff
+	const attempts = 10
+	statuses := make(chan int, attempts)
+	for range attempts {
+		go func() {
+			form := url.Values{"username": {"alice"}, "password": {"wrong"}, auth.CSRFField: {c.csrfToken}}
+			resp, err := c.PostForm(ts.URL+"/login", form)
+			if err != nil {
+				statuses <- 0
+				return
+			}
+			resp.Body.Close()
+			statuses <- resp.StatusCode
+		}()
+	}
+	counts := make(map[int]int)
+	for range attempts {
+		counts[<-statuses]++
+	}
+	if counts[http.StatusUnauthorized] != loginFreeAttempts || counts[http.StatusTooManyRequests] != attempts-loginFreeAttempts {
+		t.Fatalf("got statuses %v, want %d failures and the rest throttled", counts, loginFreeAttempts)
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	ts := newTestServerWith(t, Options{Login: LoginPolicy{MaxFailures: 2, Lockout: time.Hour}})
+	alice := ts.createUser(t, "alice", store.RoleStaff, nil)
+	ts.createUser(t, "root", store.RoleAdmin, nil)
+	c := ts.client(t)
+
+	expectStatus(t, ts.signIn(t, c, "alice", "wrong"), http.StatusUnauthorized)
+	expectStatus(t, ts.signIn(t, c, "alice", "wrong"), http.StatusUnauthorized)
+
+	// A locked account is refused like a wrong password, so the answer does
+	// not reveal the lock; the history keeps the reason
+	expectStatus(t, ts.signIn(t, c, "alice", testPassword), http.StatusUnauthorized)
+	expectStatus(t, ts.signIn(t, c, "alice", testPassword), http.StatusTooManyRequests)
+	events, err := ts.store.GetLoginEventsByUserID(alice.ID, signInsShown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 || events[0].Success || events[0].Failure != store.LoginAccountLocked || events[1].Failure != store.LoginWrongPassword {
+		t.Fatalf("got sign-ins %+v, want two wrong passwords then a refusal of the locked account", events)
+	}
+
+	// Only admins can unlock the account
+	admin := ts.login(t, "root")
+	expectStatus(t, ts.get(t, admin, "/staff"), http.StatusOK)
+	expectStatus(t, ts.get(t, admin, "/account/signins?user_id="+strconv.Itoa(alice.ID)), http.StatusOK)
+	unlock := url.Values{"id": {strconv.Itoa(alice.ID)}}
+	expectRedirect(t, ts.post(t, admin, "/staff/unlock", unlock), "/staff")
+	got, err := ts.store.GetUserByID(alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Locked() {
+		t.Fatal("account still locked after unlock")
+	}
+	aliceClient := ts.login(t, "alice")
+	expectStatus(t, ts.post(t, aliceClient, "/staff/unlock", unlock), http.StatusForbidden)
+}
+
+func TestSignIns(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.createUser(t, "alice", store.RoleStaff, nil)
+	bob := ts.createUser(t, "bob", store.RoleStaff, nil)
+	ts.createUser(t, "root", store.RoleAdmin, nil)
+
+	expectStatus(t, ts.signIn(t, ts.client(t), "alice", "wrong"), http.StatusUnauthorized)
+	aliceClient := ts.login(t, "alice")
+	ts.login(t, "bob")
+
+	events, err := ts.store.GetLoginEventsByUserID(alice.ID, signInsShown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || !events[0].Success || events[1].Success || events[0].IP != "127.0.0.1" {
+		t.Fatalf("got sign-ins %+v, want a success after a failure from 127.0.0.1", events)
+	}
+
+	// Users see their own history; only admins see anyone else's
+	expectStatus(t, ts.get(t, aliceClient, "/account/signins"), http.StatusOK)
+	bobHistory := "/account/signins?user_id=" + strconv.Itoa(bob.ID)
+	expectStatus(t, ts.get(t, aliceClient, bobHistory), http.StatusForbidden)
+	expectStatus(t, ts.get(t, ts.login(t, "root"), bobHistory), http.StatusOK)
+}
+
+func TestSignInUserAgentTruncated(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.createUser(t, "alice", store.RoleStaff, nil)
+	c := ts.client(t)
+
+	// A user agent cut at the length limit keeps whole characters
+	agent := "a" + strings.Repeat("é", maxUserAgentLength)
+	form := url.Values{"username": {"alice"}, "password": {"wrong"}, auth.CSRFField: {c.csrfToken}}
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/login", strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", agent)
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	expectStatus(t, resp, http.StatusUnauthorized)
+
+	events, err := ts.store.GetLoginEventsByUserID(alice.ID, signInsShown)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("got sign-ins %+v, %v; want one", events, err)
+	}
+	if got := events[0].UserAgent; got != agent[:maxUserAgentLength-1] {
+		t.Errorf("stored user agent of %d bytes, valid UTF-8 %v; want the first %d bytes", len(got), utf8.ValidString(got), maxUserAgentLength-1)
+	}
+
+	for _, tt := range []struct{ in, want string }{
+		{"short", "short"},
+		{"abcdé", "abcd"},
+		{"abcé", "abcé"},
+		{"ab\xffcd", "ab\uFFFD"},
+	} {
+		if got := truncateUTF8(tt.in, 5); got != tt.want {
+			t.Errorf("truncateUTF8(%q, 5) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSchedulerRetriesFailedJob(t *testing.T) {
+	ts := newTestServer(t)
+	calls := 0
+	ts.app.jobs = []Job{{
+		Name: "flaky",
+		Run: func(time.Time) (string, error) {
+			calls++
+			if calls == 1 {
+				return "", errors.New("database is locked")
+			}
+			return "done", nil
+		},
+	}}
+
+	// A failed run is retried a minute later; a successful one is not
+	// repeated the same day
+	now := time.Now().UTC()
+	for _, after := range []time.Duration{0, time.Second, 2 * time.Minute, 3 * time.Minute} {
+		ts.app.runDueJobs(now.Add(after))
+	}
+	if calls != 2 {
+		t.Fatalf("job ran %d times, want 2", calls)
+	}
+	last, err := ts.store.GetLastJobRun("flaky")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last == nil || last.Status != store.JobSucceeded {
+		t.Fatalf("got last run %+v, want a success", last)
+	}
+}
+
+func TestSchedulerBacksOffFailingJob(t *testing.T) {
+	ts := newTestServer(t)
+	calls := 0
+	ts.app.jobs = []Job{{
+		Name: "broken",
+		Run: func(time.Time) (string, error) {
+			calls++
+			return "", errors.New("mail server unreachable")
+		},
+	}}
+
+	// Each failure doubles the wait before the next attempt: 1, 2 then 4
+	// minutes. Runs record the wall clock, so every run here starts at about
+	// now and the waits count from there.
+	now := time.Now().UTC()
+	checks := []struct {
+		after time.Duration
+		calls int
+	}{
+		{0, 1},
+		{30 * time.Second, 1},
+		{90 * time.Second, 2},
+		{110 * time.Second, 2},
+		{150 * time.Second, 3},
+		{230 * time.Second, 3},
+		{250 * time.Second, 4},
+	}
+	for _, check := range checks {
+		ts.app.runDueJobs(now.Add(check.after))
+		if calls != check.calls {
+			t.Fatalf("after %v the job ran %d times, want %d", check.after, calls, check.calls)
+		}
+	}
+
+	for failures, want := range map[int]time.Duration{1: time.Minute, 2: 2 * time.Minute, 6: 32 * time.Minute, 7: time.Hour, 100: time.Hour} {
+		if got := jobRetryDelay(failures); got != want {
+			t.Errorf("jobRetryDelay(%d) = %v, want %v", failures, got, want)
+		}
+	}
+}
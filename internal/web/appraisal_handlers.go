@@ -0,0 +1,305 @@
+package web
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"staffperformance/internal/auth"
+	"staffperformance/internal/store"
+)
+
+// errAppraisalLockedMessage is returned when changing a part of an appraisal
+// that is signed off, belongs to the other party or has not started yet
+const errAppraisalLockedMessage = "This part of the appraisal cannot be changed by you at this stage"
+
+// errAppraisalLocked reports an action on a part of the appraisal the current
+// user may not change
+var errAppraisalLocked = errors.New("appraisal part locked")
+
+// appraisalURL links to the appraisal of a staff member for a review cycle
+func appraisalURL(userID, cycleID int) string {
+	return fmt.Sprintf("/appraisals/view?user_id=%d&cycle_id=%d", userID, cycleID)
+}
+
+// Appraisal list handler - the user's own appraisals and, for supervisors and
+// admins, those of the staff they appraise
+func (app *App) appraisalsHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	appraisals, err := app.store.GetAppraisalsByUserID(user.ID)
+	if err != nil {
+		log.Println("Error fetching appraisals:", err)
+		http.Error(w, "Error loading appraisals", http.StatusInternalServerError)
+		return
+	}
+
+	var team []store.Appraisal
+	var staff []store.User
+	switch auth.PermissionScope(user.Role, auth.ResourceAppraisals, auth.ActionWrite) {
+	case auth.ScopeAll:
+		team, err = app.store.GetAllAppraisals(user.ID)
+		if err == nil {
+			staff, err = app.store.GetAllUsers()
+		}
+	case auth.ScopeSupervised:
+		team, err = app.store.GetAppraisalsBySupervisor(user.ID)
+		if err == nil {
+			staff, err = app.store.GetStaffBySupervisor(user.ID)
+		}
+	}
+	if err != nil {
+		log.Println("Error fetching team appraisals:", err)
+		http.Error(w, "Error loading appraisals", http.StatusInternalServerError)
+		return
+	}
+
+	cycles, err := app.store.GetAllReviewCycles()
+	if err != nil {
+		log.Println("Error fetching review cycles:", err)
+		http.Error(w, "Error loading appraisals", http.StatusInternalServerError)
+		return
+	}
+	var started []store.ReviewCycle
+	for _, c := range cycles {
+		if c.Status != store.CycleStatusPlanned {
+			started = append(started, c)
+		}
+	}
+
+	data := AppraisalListData{
+		User:       *user,
+		Appraisals: appraisals,
+		Team:       team,
+		Cycles:     started,
+		Staff:      staff,
+	}
+
+	err = app.render(w, r, "appraisals.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// loadAppraisal reads the user_id and cycle_id parameters and loads the
+// appraisal they name, checking that the user may read it. It writes an error
+// response and returns nil when the appraisal cannot be shown.
+func (app *App) loadAppraisal(w http.ResponseWriter, r *http.Request, user *store.User) *AppraisalData {
+	staffID, err := strconv.Atoi(r.FormValue("user_id"))
+	if err != nil {
+		http.Error(w, "Invalid staff ID", http.StatusBadRequest)
+		return nil
+	}
+	cycleID, err := strconv.Atoi(r.FormValue("cycle_id"))
+	if err != nil {
+		http.Error(w, "Invalid review cycle ID", http.StatusBadRequest)
+		return nil
+	}
+
+	if !app.auth.Authorize(w, user, auth.ResourceAppraisals, auth.ActionRead, staffID) {
+		return nil
+	}
+
+	staff, err := app.store.GetUserByID(staffID)
+	if err != nil {
+		http.Error(w, "Staff member not found", http.StatusNotFound)
+		return nil
+	}
+	cycle, err := app.store.GetReviewCycleByID(cycleID)
+	if err != nil {
+		http.Error(w, "Review cycle not found", http.StatusNotFound)
+		return nil
+	}
+
+	appraisal, err := app.store.GetAppraisal(staffID, cycleID)
+	if errors.Is(err, sql.ErrNoRows) {
+		appraisal, err = nil, nil
+	}
+	if err != nil {
+		log.Println("Error fetching appraisal:", err)
+		http.Error(w, "Error loading appraisal", http.StatusInternalServerError)
+		return nil
+	}
+
+	objectives, err := app.store.GetObjectivesByUserIDInCycle(staffID, cycleID)
+	if err != nil {
+		log.Println("Error fetching objectives:", err)
+		http.Error(w, "Error loading appraisal", http.StatusInternalServerError)
+		return nil
+	}
+
+	ratings := make(map[int]store.AppraisalRating)
+	if appraisal != nil {
+		list, err := app.store.GetAppraisalRatings(appraisal.ID)
+		if err != nil {
+			log.Println("Error fetching appraisal ratings:", err)
+			http.Error(w, "Error loading appraisal", http.StatusInternalServerError)
+			return nil
+		}
+		for _, rating := range list {
+			ratings[rating.ObjectiveID] = rating
+		}
+	}
+
+	data := &AppraisalData{
+		User:         *user,
+		Staff:        staff,
+		Cycle:        cycle,
+		Appraisal:    appraisal,
+		Scale:        app.ratings.Options(),
+		OverallScore: OverallScore(objectives),
+	}
+	for _, obj := range objectives {
+		rating := ratings[obj.ID]
+		rating.ObjectiveID = obj.ID
+		data.Objectives = append(data.Objectives, AppraisalObjective{Objective: obj, Rating: rating})
+	}
+
+	// Staff complete their own appraisal; anyone else allowed to write it
+	// reviews it as the supervisor. Appraisals start once the cycle opens.
+	canWrite := app.auth.CanAccess(user, auth.ResourceAppraisals, auth.ActionWrite, staffID) &&
+		(appraisal != nil || cycle.Status != store.CycleStatusPlanned)
+	isSelf := user.ID == staffID
+	supervisorSigned := appraisal != nil && appraisal.SupervisorSignedAt != nil
+	staffSigned := appraisal != nil && appraisal.StaffSignedAt != nil
+
+	data.CanEditSelf = canWrite && isSelf && !supervisorSigned && !staffSigned
+	data.CanEditSupervisor = canWrite && !isSelf && !supervisorSigned
+	data.CanSignSupervisor = data.CanEditSupervisor && appraisal != nil
+	data.CanSignStaff = canWrite && isSelf && supervisorSigned && !staffSigned
+	return data
+}
+
+// Appraisal handler - shows an appraisal and saves or signs off the part of it
+// that belongs to the current user
+func (app *App) appraisalHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	data := app.loadAppraisal(w, r, user)
+	if data == nil {
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		if !app.auth.Authorize(w, user, auth.ResourceAppraisals, auth.ActionWrite, data.Staff.ID) {
+			return
+		}
+
+		err := app.saveAppraisal(r, user, data)
+		if isValidationError(err) {
+			data.Error = "Cannot save: " + err.Error()
+			w.WriteHeader(http.StatusBadRequest)
+			app.renderAppraisal(w, r, "appraisal.html", data)
+			return
+		}
+		if errors.Is(err, errAppraisalLocked) {
+			http.Error(w, errAppraisalLockedMessage, http.StatusConflict)
+			return
+		}
+		if err != nil {
+			log.Println("Error saving appraisal:", err)
+			http.Error(w, "Error saving appraisal", http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, appraisalURL(data.Staff.ID, data.Cycle.ID), http.StatusSeeOther)
+		return
+	}
+
+	app.renderAppraisal(w, r, "appraisal.html", data)
+}
+
+// saveAppraisal applies the posted action to the appraisal, starting it if
+// nobody has saved it yet
+func (app *App) saveAppraisal(r *http.Request, user *store.User, data *AppraisalData) error {
+	action := r.FormValue("action")
+	allowed := map[string]bool{
+		"self":            data.CanEditSelf,
+		"supervisor":      data.CanEditSupervisor,
+		"sign_supervisor": data.CanSignSupervisor,
+		"sign_staff":      data.CanSignStaff,
+	}
+	ok, known := allowed[action]
+	if !known {
+		return validationErrorf("unknown appraisal action %q", action)
+	}
+	if !ok {
+		return errAppraisalLocked
+	}
+
+	a := data.Appraisal
+	if a == nil {
+		a = &store.Appraisal{UserID: data.Staff.ID, ReviewCycleID: data.Cycle.ID}
+		if err := app.store.CreateAppraisal(a); err != nil {
+			return err
+		}
+	}
+
+	switch action {
+	case "self":
+		a.SelfAssessment = strings.TrimSpace(r.FormValue("self_assessment"))
+		var ratings []store.AppraisalRating
+		for _, o := range data.Objectives {
+			ratings = append(ratings, store.AppraisalRating{
+				ObjectiveID: o.Objective.ID,
+				SelfRating:  app.ratings.Parse(r.FormValue(fmt.Sprintf("self_rating_%d", o.Objective.ID))),
+				SelfComment: strings.TrimSpace(r.FormValue(fmt.Sprintf("self_comment_%d", o.Objective.ID))),
+			})
+		}
+		return app.store.SaveSelfAssessment(a, ratings)
+
+	case "supervisor":
+		a.SupervisorID = &user.ID
+		a.SupervisorSummary = strings.TrimSpace(r.FormValue("supervisor_summary"))
+		a.FinalRating = app.ratings.Parse(r.FormValue("final_rating"))
+		var ratings []store.AppraisalRating
+		for _, o := range data.Objectives {
+			ratings = append(ratings, store.AppraisalRating{
+				ObjectiveID:       o.Objective.ID,
+				SupervisorRating:  app.ratings.Parse(r.FormValue(fmt.Sprintf("supervisor_rating_%d", o.Objective.ID))),
+				SupervisorComment: strings.TrimSpace(r.FormValue(fmt.Sprintf("supervisor_comment_%d", o.Objective.ID))),
+			})
+		}
+		return app.store.SaveSupervisorReview(a, ratings)
+
+	case "sign_supervisor":
+		if err := checkSupervisorSignOff(a, data.Objectives); err != nil {
+			return err
+		}
+		log.Printf("Appraisal of %s for %s signed off by supervisor %s", data.Staff.Username, data.Cycle.Name, user.Username)
+		return app.store.SignAppraisalAsSupervisor(a.ID, user.ID)
+
+	default: // sign_staff
+		log.Printf("Appraisal of %s for %s signed off by staff member", data.Staff.Username, data.Cycle.Name)
+		return app.store.SignAppraisalAsStaff(a.ID)
+	}
+}
+
+// Appraisal print handler - a printable summary of a started appraisal
+func (app *App) appraisalPrintHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	data := app.loadAppraisal(w, r, user)
+	if data == nil {
+		return
+	}
+	if data.Appraisal == nil {
+		http.Error(w, "Appraisal not started", http.StatusNotFound)
+		return
+	}
+
+	app.renderAppraisal(w, r, "appraisal_print.html", data)
+}
+
+func (app *App) renderAppraisal(w http.ResponseWriter, r *http.Request, name string, data *AppraisalData) {
+	err := app.render(w, r, name, data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,67 @@
+package web
+
+import (
+	"fmt"
+	"strconv"
+
+	"staffperformance/internal/store"
+)
+
+// RatingScale holds the labels of an appraisal rating scale, lowest first. A
+// rating is stored as the 1-based position of its label.
+type RatingScale []string
+
+// DefaultRatingScale is the appraisal rating scale used when none is configured
+var DefaultRatingScale = RatingScale{"Unsatisfactory", "Needs improvement", "Meets expectations", "Exceeds expectations", "Outstanding"}
+
+// RatingOption is one point on the rating scale
+type RatingOption struct {
+	Value int
+	Label string
+}
+
+// Options lists the points of the scale, lowest first
+func (s RatingScale) Options() []RatingOption {
+	options := make([]RatingOption, len(s))
+	for i, label := range s {
+		options[i] = RatingOption{Value: i + 1, Label: label}
+	}
+	return options
+}
+
+// Label describes a rating for display, e.g. "3 - Meets expectations".
+// Ratings given on an earlier, longer scale are shown by number only.
+func (s RatingScale) Label(rating *int) string {
+	switch {
+	case rating == nil:
+		return "Not rated"
+	case *rating >= 1 && *rating <= len(s):
+		return fmt.Sprintf("%d - %s", *rating, s[*rating-1])
+	}
+	return strconv.Itoa(*rating)
+}
+
+// Parse reads an optional rating from a form value; values outside the scale
+// are treated as not rated
+func (s RatingScale) Parse(value string) *int {
+	rating, err := strconv.Atoi(value)
+	if err != nil || rating < 1 || rating > len(s) {
+		return nil
+	}
+	return &rating
+}
+
+// checkSupervisorSignOff returns a validation error when the supervisor part of
+// an appraisal is incomplete: every objective needs a supervisor rating and
+// the appraisal needs a final rating before the supervisor can sign it off.
+func checkSupervisorSignOff(a *store.Appraisal, objectives []AppraisalObjective) error {
+	for _, o := range objectives {
+		if o.Rating.SupervisorRating == nil {
+			return validationErrorf("rate every objective before signing off; %q has no supervisor rating", o.Objective.Title)
+		}
+	}
+	if a.FinalRating == nil {
+		return validationErrorf("set a final rating before signing off")
+	}
+	return nil
+}
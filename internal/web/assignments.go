@@ -0,0 +1,172 @@
+package web
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"staffperformance/internal/auth"
+	"staffperformance/internal/store"
+)
+
+// Task assignment workflow. A task belongs to the user who created it (its
+// requester). It can be assigned to someone else, who accepts or declines it
+// or passes it on to a colleague. Declined tasks go back to the requester,
+// who can assign them again.
+
+// assigneeTaskTypes are the task types that go to another user
+var assigneeTaskTypes = []store.TaskType{store.TaskTypeStaffAssignment, store.TaskTypeServiceRequest}
+
+// canAccessTask extends the permission matrix for tasks: besides the usual
+// owner-based access, assignees can work on tasks assigned to them and anyone
+// who can read the assignee's tasks can read them too
+func (app *App) canAccessTask(user *store.User, task *store.Task, action auth.Action) bool {
+	if app.auth.CanAccess(user, auth.ResourceTasks, action, task.UserID) {
+		return true
+	}
+	if !task.IsAssigned() {
+		return false
+	}
+	if action == auth.ActionWrite {
+		return *task.AssignedToID == user.ID
+	}
+	return app.auth.CanAccess(user, auth.ResourceTasks, auth.ActionRead, *task.AssignedToID)
+}
+
+// authorizeTask is Authorize for a task, writing a 403 response when denied
+func (app *App) authorizeTask(w http.ResponseWriter, user *store.User, task *store.Task, action auth.Action) bool {
+	if !app.canAccessTask(user, task, action) {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// assignTask validates who a task created by user goes to and updates its
+// assignment. Choosing a new assignee, or the same one again after they
+// declined, starts a new request that waits for them to accept. Personal
+// tasks and responses stay with their creator.
+func (app *App) assignTask(user *store.User, task *store.Task, assigneeID *int) error {
+	if assigneeID == nil {
+		if oneOf(task.TaskType, assigneeTaskTypes) {
+			return validationErrorf("choose who the %s is for", task.TaskType)
+		}
+		if task.AssignedToID != nil {
+			app.releaseAssigneeOutcome(task)
+		}
+		task.AssignedToID = nil
+		task.AssignmentStatus = store.AssignmentNone
+		task.AssignmentNote = ""
+		return nil
+	}
+
+	if !oneOf(task.TaskType, assigneeTaskTypes) {
+		return validationErrorf("%s tasks cannot be assigned to someone else", task.TaskType)
+	}
+	if *assigneeID == task.UserID {
+		return validationErrorf("a task cannot be assigned to the user who created it")
+	}
+	assignee, err := app.store.GetUserByID(*assigneeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return validationErrorf("the selected assignee does not exist")
+	} else if err != nil {
+		return err
+	}
+	// Staff assignments follow the reporting line; service requests can go
+	// to anyone
+	if task.TaskType == store.TaskTypeStaffAssignment && user.Role != store.RoleAdmin &&
+		(assignee.SupervisorID == nil || *assignee.SupervisorID != user.ID) {
+		return validationErrorf("staff assignments can only go to your own staff; send a service request instead")
+	}
+
+	if task.IsAssignedTo(assignee.ID) {
+		return nil // Unchanged
+	}
+	if task.AssignedToID != nil {
+		app.releaseAssigneeOutcome(task)
+	}
+	task.AssignedToID = &assignee.ID
+	task.AssignmentStatus = store.AssignmentPending
+	task.AssignmentNote = ""
+	return nil
+}
+
+// Errors from the assignee's side of the workflow
+var (
+	errNotAssignee      = errors.New("this task is not assigned to you")
+	errNotPending       = errors.New("this task has already been accepted or declined")
+	errNotAccepted      = errors.New("accept this task before working on it")
+	errDeclineReason    = errors.New("give a reason for declining")
+	errReassignTarget   = errors.New("choose someone else to pass this task on to")
+	errReassignNotFound = errors.New("the selected user does not exist")
+)
+
+// acceptTask records the assignee accepting a pending task
+func acceptTask(user *store.User, task *store.Task) error {
+	if !task.IsAssignedTo(user.ID) {
+		return errNotAssignee
+	}
+	if task.AssignmentStatus != store.AssignmentPending {
+		return errNotPending
+	}
+	task.AssignmentStatus = store.AssignmentAccepted
+	task.AssignmentNote = ""
+	return nil
+}
+
+// declineTask hands a pending task back to its creator with a reason
+func declineTask(user *store.User, task *store.Task, reason string) error {
+	if !task.IsAssignedTo(user.ID) {
+		return errNotAssignee
+	}
+	if task.AssignmentStatus != store.AssignmentPending {
+		return errNotPending
+	}
+	if reason == "" {
+		return errDeclineReason
+	}
+	task.AssignmentStatus = store.AssignmentDeclined
+	task.AssignmentNote = reason
+	return nil
+}
+
+// reassignTask passes a task on from its assignee to a colleague, who then
+// has to accept it
+func (app *App) reassignTask(user *store.User, task *store.Task, assigneeID int, reason string) error {
+	if !task.IsAssignedTo(user.ID) {
+		return errNotAssignee
+	}
+	if assigneeID == user.ID || assigneeID == task.UserID {
+		return errReassignTarget
+	}
+	if _, err := app.store.GetUserByID(assigneeID); errors.Is(err, sql.ErrNoRows) {
+		return errReassignNotFound
+	} else if err != nil {
+		return err
+	}
+	app.releaseAssigneeOutcome(task)
+	task.AssignedToID = &assigneeID
+	task.AssignmentStatus = store.AssignmentPending
+	task.AssignmentNote = reason
+	return nil
+}
+
+// releaseAssigneeOutcome unlinks a task from an expected outcome of its
+// previous assignee, so it stops counting towards their objectives
+func (app *App) releaseAssigneeOutcome(task *store.Task) {
+	if task.ExpectedOutcomeID == nil {
+		return
+	}
+	if _, obj, err := app.loadOutcome(*task.ExpectedOutcomeID); err != nil || obj.UserID != task.UserID {
+		task.ExpectedOutcomeID = nil
+	}
+}
+
+// isAssignmentError reports whether err is a workflow error to show the user
+func isAssignmentError(err error) bool {
+	switch err {
+	case errNotAssignee, errNotPending, errNotAccepted, errDeclineReason, errReassignTarget, errReassignNotFound:
+		return true
+	}
+	return isValidationError(err)
+}
@@ -0,0 +1,203 @@
+package web
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"staffperformance/internal/auth"
+	"staffperformance/internal/store"
+)
+
+// auditPageSize is the number of events per audit log page
+const auditPageSize = 50
+
+// auditEntities are the kinds of record the audit log can be filtered by
+var auditEntities = []store.AuditEntity{store.AuditObjective, store.AuditOutcome, store.AuditActivity, store.AuditTask}
+
+// Audit log handler - admins browse every recorded change, filtered by record,
+// actor, staff member and date
+func (app *App) auditLogHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	q := r.URL.Query()
+
+	filter := store.AuditFilter{Entity: store.AuditEntity(q.Get("entity")), Limit: auditPageSize}
+	if !oneOf(filter.Entity, auditEntities) {
+		filter.Entity = ""
+	}
+	filter.EntityID, _ = strconv.Atoi(q.Get("entity_id"))
+	filter.ActorID, _ = strconv.Atoi(q.Get("actor_id"))
+	filter.OwnerID, _ = strconv.Atoi(q.Get("owner_id"))
+
+	fromDate, toDate := q.Get("from"), q.Get("to")
+	if t, err := time.Parse("2006-01-02", fromDate); err == nil {
+		filter.From = t
+	} else {
+		fromDate = ""
+	}
+	if t, err := time.Parse("2006-01-02", toDate); err == nil {
+		filter.To = t.AddDate(0, 0, 1) // Include the whole day
+	} else {
+		toDate = ""
+	}
+
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	filter.Offset = (page - 1) * auditPageSize
+
+	total, err := app.store.CountAuditEvents(filter)
+	if err != nil {
+		log.Println("Error counting audit events:", err)
+		http.Error(w, "Error loading audit log", http.StatusInternalServerError)
+		return
+	}
+	events, err := app.store.GetAuditEvents(filter)
+	if err != nil {
+		log.Println("Error fetching audit events:", err)
+		http.Error(w, "Error loading audit log", http.StatusInternalServerError)
+		return
+	}
+	users, err := app.store.GetAllUsers()
+	if err != nil {
+		log.Println("Error fetching users:", err)
+	}
+
+	data := AuditLogData{
+		User:     *user,
+		Events:   events,
+		Entities: auditEntities,
+		Users:    users,
+		Filter:   filter,
+		FromDate: fromDate,
+		ToDate:   toDate,
+		Total:    total,
+		Page:     page,
+	}
+	if page > 1 {
+		data.PrevURL = auditPageURL(q, page-1)
+	}
+	if page*auditPageSize < total {
+		data.NextURL = auditPageURL(q, page+1)
+	}
+
+	err = app.render(w, r, "audit_log.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// auditPageURL links to another page of the audit log with the same filters
+func auditPageURL(q url.Values, page int) string {
+	params := url.Values{}
+	for key, values := range q {
+		params[key] = values
+	}
+	params.Set("page", strconv.Itoa(page))
+	return "/admin/audit?" + params.Encode()
+}
+
+// Audit history handler - every recorded change to one record, for anyone
+// who can read the record
+func (app *App) auditHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	entity := store.AuditEntity(r.URL.Query().Get("entity"))
+	if !oneOf(entity, auditEntities) {
+		http.Error(w, "Invalid record type", http.StatusBadRequest)
+		return
+	}
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid record ID", http.StatusBadRequest)
+		return
+	}
+
+	events, err := app.store.GetAuditEvents(store.AuditFilter{Entity: entity, EntityID: id})
+	if err != nil {
+		log.Println("Error fetching audit events:", err)
+		http.Error(w, "Error loading history", http.StatusInternalServerError)
+		return
+	}
+
+	// The events remember the owner, so history outlives the record. Records
+	// last changed before the audit log existed are looked up instead.
+	var ownerID int
+	var label string
+	if len(events) > 0 {
+		ownerID, label = events[0].OwnerID, events[0].Label
+	} else if ownerID, label, err = app.auditRecordOwner(entity, id); err != nil {
+		http.Error(w, "Record not found", http.StatusNotFound)
+		return
+	}
+
+	resource := auth.ResourceObjectives
+	if entity == store.AuditTask {
+		resource = auth.ResourceTasks
+	}
+	allowed := app.auth.CanAccess(user, resource, auth.ActionRead, ownerID)
+	if !allowed && entity == store.AuditTask {
+		// Assignees can follow the history of tasks they work on
+		task, err := app.store.GetTaskByID(id)
+		allowed = err == nil && app.canAccessTask(user, task, auth.ActionRead)
+	}
+	if !allowed {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	data := AuditHistoryData{
+		User:     *user,
+		Entity:   entity,
+		EntityID: id,
+		Label:    label,
+		Deleted:  len(events) > 0 && events[0].Action == store.AuditDelete,
+		Events:   events,
+	}
+
+	err = app.render(w, r, "audit_history.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// auditRecordOwner returns the owner and title of an existing record
+func (app *App) auditRecordOwner(entity store.AuditEntity, id int) (int, string, error) {
+	switch entity {
+	case store.AuditTask:
+		task, err := app.store.GetTaskByID(id)
+		if err != nil {
+			return 0, "", err
+		}
+		return task.UserID, task.Title, nil
+
+	case store.AuditActivity:
+		activity, err := app.store.GetActivityByID(id)
+		if err != nil {
+			return 0, "", err
+		}
+		_, obj, err := app.loadOutcome(activity.ExpectedOutcomeID)
+		if err != nil {
+			return 0, "", err
+		}
+		return obj.UserID, activity.Title, nil
+
+	case store.AuditOutcome:
+		outcome, obj, err := app.loadOutcome(id)
+		if err != nil {
+			return 0, "", err
+		}
+		return obj.UserID, outcome.Title, nil
+	}
+
+	obj, err := app.store.GetObjectiveByID(id)
+	if err != nil {
+		return 0, "", err
+	}
+	return obj.UserID, obj.Title, nil
+}
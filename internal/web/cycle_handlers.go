@@ -0,0 +1,350 @@
+package web
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"staffperformance/internal/auth"
+	"staffperformance/internal/store"
+)
+
+// errCycleClosedMessage is returned when changing work in a closed review cycle
+const errCycleClosedMessage = "This objective's review cycle is closed; it can no longer be changed"
+
+// requireUnlocked writes a 409 response and reports false when an objective
+// belongs to a closed review cycle. Closing a cycle freezes its objectives
+// together with their outcomes and activities.
+func requireUnlocked(w http.ResponseWriter, obj *store.Objective) bool {
+	if obj.Locked {
+		http.Error(w, errCycleClosedMessage, http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+// outcomeObjective returns the objective of the expected outcome a task counts
+// towards, or nil when the task is linked to no outcome
+func (app *App) outcomeObjective(outcomeID *int) (*store.Objective, error) {
+	if outcomeID == nil {
+		return nil, nil
+	}
+	_, obj, err := app.loadOutcome(*outcomeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return obj, err
+}
+
+// requireTaskUnlocked is requireUnlocked for the objective a task linked to
+// outcomeID counts towards. Tasks add to their objective's score, so they are
+// frozen with it.
+func (app *App) requireTaskUnlocked(w http.ResponseWriter, outcomeID *int) bool {
+	obj, err := app.outcomeObjective(outcomeID)
+	if err != nil {
+		log.Println("Error fetching objective:", err)
+		http.Error(w, "Error loading objective", http.StatusInternalServerError)
+		return false
+	}
+	return obj == nil || requireUnlocked(w, obj)
+}
+
+// assignableCycles returns the review cycles new work can be added to
+func (app *App) assignableCycles() ([]store.ReviewCycle, error) {
+	cycles, err := app.store.GetAllReviewCycles()
+	if err != nil {
+		return nil, err
+	}
+	var open []store.ReviewCycle
+	for _, c := range cycles {
+		if c.Status != store.CycleStatusClosed {
+			open = append(open, c)
+		}
+	}
+	return open, nil
+}
+
+// assignableCycleID parses an optional review cycle ID from a form value,
+// accepting it only if the cycle exists and is not closed
+func (app *App) assignableCycleID(value string) *int {
+	cycleID := parseOptionalID(value)
+	if cycleID == nil {
+		return nil
+	}
+	cycle, err := app.store.GetReviewCycleByID(*cycleID)
+	if err != nil || cycle.Status == store.CycleStatusClosed {
+		return nil
+	}
+	return cycleID
+}
+
+// selectedCycle reads the ?cycle= report filter. It returns the selected
+// cycle, or nil for all periods, together with every cycle for the filter list.
+func (app *App) selectedCycle(r *http.Request) (*store.ReviewCycle, []store.ReviewCycle, error) {
+	cycles, err := app.store.GetAllReviewCycles()
+	if err != nil {
+		return nil, nil, err
+	}
+	id, err := strconv.Atoi(r.URL.Query().Get("cycle"))
+	if err != nil {
+		return nil, cycles, nil
+	}
+	for i := range cycles {
+		if cycles[i].ID == id {
+			return &cycles[i], cycles, nil
+		}
+	}
+	return nil, cycles, nil
+}
+
+// hierarchyForCycle returns a user's objectives with their expected outcomes,
+// activities and tasks, limited to a cycle when one is selected
+func (app *App) hierarchyForCycle(userID int, cycle *store.ReviewCycle) ([]store.ObjectiveWithOutcomes, error) {
+	if cycle == nil {
+		return app.store.GetObjectivesWithOutcomes(userID)
+	}
+	return app.store.GetObjectivesWithOutcomesInCycle(userID, cycle.ID)
+}
+
+// teamObjectivesForCycle returns the objectives of several users by user ID,
+// limited to a cycle when one is selected, loading them all at once
+func (app *App) teamObjectivesForCycle(userIDs []int, cycle *store.ReviewCycle) (map[int][]store.Objective, error) {
+	var team map[int][]store.ObjectiveWithOutcomes
+	var err error
+	if cycle == nil {
+		team, err = app.store.GetTeamObjectivesWithOutcomes(userIDs)
+	} else {
+		team, err = app.store.GetTeamObjectivesWithOutcomesInCycle(userIDs, cycle.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	objectives := make(map[int][]store.Objective, len(team))
+	for userID, hierarchy := range team {
+		objectives[userID] = hierarchyObjectives(hierarchy)
+	}
+	return objectives, nil
+}
+
+// hierarchyObjectives lists the objectives of a hierarchy
+func hierarchyObjectives(hierarchy []store.ObjectiveWithOutcomes) []store.Objective {
+	objectives := make([]store.Objective, len(hierarchy))
+	for i, item := range hierarchy {
+		objectives[i] = item.Objective
+	}
+	return objectives
+}
+
+// tasksInCycle keeps the tasks due within a cycle's period. Tasks are not
+// scoped to cycles directly, so their due date places them.
+func tasksInCycle(tasks []store.Task, cycle *store.ReviewCycle) []store.Task {
+	if cycle == nil {
+		return tasks
+	}
+	end := cycle.EndDate.AddDate(0, 0, 1)
+	var filtered []store.Task
+	for _, t := range tasks {
+		if !t.DueDate.Before(cycle.StartDate) && t.DueDate.Before(end) {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+// Review cycle list handler - everyone can see the cycles; admins manage them
+func (app *App) reviewCyclesHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	cycles, err := app.store.GetAllReviewCycles()
+	if err != nil {
+		log.Println("Error fetching review cycles:", err)
+		http.Error(w, "Error loading review cycles", http.StatusInternalServerError)
+		return
+	}
+
+	data := ReviewCycleListData{
+		User:      *user,
+		Cycles:    cycles,
+		CanManage: auth.Can(user, auth.ResourceCycles, auth.ActionWrite),
+	}
+
+	err = app.render(w, r, "review_cycles.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// parseReviewCycleForm reads the cycle form into cycle and returns a
+// validation message, or "" when the input is valid
+func (app *App) parseReviewCycleForm(r *http.Request, cycle *store.ReviewCycle) string {
+	cycle.Name = strings.TrimSpace(r.FormValue("name"))
+	start, startErr := time.Parse("2006-01-02", r.FormValue("start_date"))
+	end, endErr := time.Parse("2006-01-02", r.FormValue("end_date"))
+	cycle.StartDate, cycle.EndDate = start, end
+
+	switch {
+	case cycle.Name == "":
+		return "Cycle name is required"
+	case startErr != nil || endErr != nil:
+		return "Start and end dates are required"
+	case end.Before(start):
+		return "End date must not be before the start date"
+	}
+
+	cycles, err := app.store.GetAllReviewCycles()
+	if err != nil {
+		log.Println("Error fetching review cycles:", err)
+		return ""
+	}
+	for _, c := range cycles {
+		if c.ID != cycle.ID && strings.EqualFold(c.Name, cycle.Name) {
+			return "A review cycle with this name already exists"
+		}
+	}
+	return ""
+}
+
+// New review cycle handler - cycles start out Planned
+func (app *App) newReviewCycleHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	data := ReviewCycleFormData{User: *user}
+
+	if r.Method == http.MethodPost {
+		cycle := &store.ReviewCycle{Status: store.CycleStatusPlanned}
+		data.Cycle = cycle
+		data.Error = app.parseReviewCycleForm(r, cycle)
+
+		if data.Error == "" {
+			if err := app.store.CreateReviewCycle(cycle); err != nil {
+				log.Println("Error creating review cycle:", err)
+				http.Error(w, "Error creating review cycle", http.StatusInternalServerError)
+				return
+			}
+			http.Redirect(w, r, "/cycles", http.StatusSeeOther)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+	}
+
+	app.renderReviewCycleForm(w, r, data)
+}
+
+// Edit review cycle handler - renames a cycle or moves its dates
+func (app *App) editReviewCycleHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid review cycle ID", http.StatusBadRequest)
+		return
+	}
+
+	cycle, err := app.store.GetReviewCycleByID(id)
+	if err != nil {
+		http.Error(w, "Review cycle not found", http.StatusNotFound)
+		return
+	}
+
+	data := ReviewCycleFormData{User: *user, Cycle: cycle, IsEdit: true}
+
+	if r.Method == http.MethodPost {
+		data.Error = app.parseReviewCycleForm(r, cycle)
+
+		if data.Error == "" {
+			if err := app.store.UpdateReviewCycle(cycle); err != nil {
+				log.Println("Error updating review cycle:", err)
+				http.Error(w, "Error updating review cycle", http.StatusInternalServerError)
+				return
+			}
+			http.Redirect(w, r, "/cycles", http.StatusSeeOther)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+	}
+
+	app.renderReviewCycleForm(w, r, data)
+}
+
+func (app *App) renderReviewCycleForm(w http.ResponseWriter, r *http.Request, data ReviewCycleFormData) {
+	err := app.render(w, r, "review_cycle_form.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// Review cycle status handler - opens or closes a cycle. Closing locks the
+// cycle's objectives; reopening unlocks them.
+func (app *App) reviewCycleStatusHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid review cycle ID", http.StatusBadRequest)
+		return
+	}
+
+	cycle, err := app.store.GetReviewCycleByID(id)
+	if err != nil {
+		http.Error(w, "Review cycle not found", http.StatusNotFound)
+		return
+	}
+
+	status := store.ReviewCycleStatus(r.FormValue("status"))
+	if status != store.CycleStatusOpen && status != store.CycleStatusClosed {
+		http.Error(w, "Invalid review cycle status", http.StatusBadRequest)
+		return
+	}
+
+	if err := app.store.SetReviewCycleStatus(cycle.ID, status); err != nil {
+		log.Println("Error changing review cycle status:", err)
+		http.Error(w, "Error changing review cycle status", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Review cycle %q changed from %s to %s by %s", cycle.Name, cycle.Status, status, user.Username)
+	http.Redirect(w, r, "/cycles", http.StatusSeeOther)
+}
+
+// Delete review cycle handler - closed cycles are kept as the appraisal record
+func (app *App) deleteReviewCycleHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid review cycle ID", http.StatusBadRequest)
+		return
+	}
+
+	cycle, err := app.store.GetReviewCycleByID(id)
+	if err != nil {
+		http.Error(w, "Review cycle not found", http.StatusNotFound)
+		return
+	}
+
+	if cycle.Status == store.CycleStatusClosed {
+		http.Error(w, "Closed review cycles cannot be deleted", http.StatusConflict)
+		return
+	}
+
+	if err := app.store.DeleteReviewCycle(cycle.ID); err != nil {
+		log.Println("Error deleting review cycle:", err)
+		http.Error(w, "Error deleting review cycle", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/cycles", http.StatusSeeOther)
+}
@@ -0,0 +1,229 @@
+package web
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"staffperformance/internal/auth"
+	"staffperformance/internal/store"
+)
+
+// Department list handler - display all departments with their heads
+func (app *App) departmentListHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := auth.CurrentUser(r)
+
+	departments, err := app.store.GetAllDepartments()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := DepartmentListData{
+		Username:    currentUser.Username,
+		Departments: departments,
+	}
+
+	err = app.render(w, r, "departments.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// New department handler - show and process the department form
+func (app *App) newDepartmentHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := auth.CurrentUser(r)
+
+	if r.Method == http.MethodPost {
+		dept := &store.Department{
+			Name:        strings.TrimSpace(r.FormValue("name")),
+			Description: r.FormValue("description"),
+			HeadID:      parseOptionalID(r.FormValue("head_id")),
+		}
+		if dept.Name == "" {
+			http.Error(w, "Department name is required", http.StatusBadRequest)
+			return
+		}
+
+		if err := app.store.CreateDepartment(dept); err != nil {
+			log.Println("Error creating department:", err)
+			http.Error(w, "Error creating department", http.StatusInternalServerError)
+			return
+		}
+
+		// A department head is always a member of the department they lead
+		if dept.HeadID != nil {
+			if err := app.store.SetUserDepartment(*dept.HeadID, &dept.ID); err != nil {
+				log.Println("Error assigning department head:", err)
+			}
+		}
+
+		http.Redirect(w, r, "/departments", http.StatusSeeOther)
+		return
+	}
+
+	users, err := app.store.GetAllUsers()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := DepartmentFormData{
+		Username: currentUser.Username,
+		Users:    users,
+		IsEdit:   false,
+	}
+
+	err = app.render(w, r, "department_form.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// Edit department handler - modify a department and manage its members
+func (app *App) editDepartmentHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := auth.CurrentUser(r)
+
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid department ID", http.StatusBadRequest)
+		return
+	}
+
+	dept, err := app.store.GetDepartmentByID(id)
+	if err != nil {
+		http.Error(w, "Department not found", http.StatusNotFound)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		dept.Name = strings.TrimSpace(r.FormValue("name"))
+		dept.Description = r.FormValue("description")
+		dept.HeadID = parseOptionalID(r.FormValue("head_id"))
+		if dept.Name == "" {
+			http.Error(w, "Department name is required", http.StatusBadRequest)
+			return
+		}
+
+		if err := app.store.UpdateDepartment(dept); err != nil {
+			log.Println("Error updating department:", err)
+			http.Error(w, "Error updating department", http.StatusInternalServerError)
+			return
+		}
+
+		if dept.HeadID != nil {
+			if err := app.store.SetUserDepartment(*dept.HeadID, &dept.ID); err != nil {
+				log.Println("Error assigning department head:", err)
+			}
+		}
+
+		http.Redirect(w, r, "/departments/edit?id="+strconv.Itoa(dept.ID), http.StatusSeeOther)
+		return
+	}
+
+	users, err := app.store.GetAllUsers()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	members, err := app.store.GetUsersByDepartment(dept.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	departments, err := app.store.GetAllDepartments()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := DepartmentFormData{
+		Username:    currentUser.Username,
+		Department:  dept,
+		Users:       users,
+		Members:     members,
+		Departments: departments,
+		IsEdit:      true,
+	}
+
+	err = app.render(w, r, "department_form.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// Delete department handler - members are left without a department
+func (app *App) deleteDepartmentHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid department ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := app.store.DeleteDepartment(id); err != nil {
+		log.Println("Error deleting department:", err)
+		http.Error(w, "Error deleting department", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/departments", http.StatusSeeOther)
+}
+
+// Move department member handler - moves a user into another department,
+// or removes them from their department when no target is given
+func (app *App) moveDepartmentMemberHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, err := strconv.Atoi(r.FormValue("user_id"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	targetID := parseOptionalID(r.FormValue("department_id"))
+	if targetID != nil {
+		if _, err := app.store.GetDepartmentByID(*targetID); err != nil {
+			http.Error(w, "Department not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	if err := app.store.SetUserDepartment(userID, targetID); err != nil {
+		log.Println("Error moving user between departments:", err)
+		http.Error(w, "Error updating department membership", http.StatusInternalServerError)
+		return
+	}
+
+	// Return to the department the change was made from
+	if returnID := parseOptionalID(r.FormValue("return_id")); returnID != nil {
+		http.Redirect(w, r, "/departments/edit?id="+strconv.Itoa(*returnID), http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, "/departments", http.StatusSeeOther)
+}
+
+// parseOptionalID parses an optional ID form value; empty or invalid values yield nil
+func parseOptionalID(value string) *int {
+	if value == "" {
+		return nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return nil
+	}
+	return &id
+}
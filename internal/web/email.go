@@ -0,0 +1,236 @@
+package web
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"staffperformance/internal/mail"
+	"staffperformance/internal/store"
+)
+
+// Outbound email. Notifications are emailed to users who have an email
+// address, either one by one or as a daily digest. Messages go through the
+// email_outbox table, so a mail server that is down only delays them: the
+// mailer retries with a growing delay until outboxMaxAttempts is reached.
+
+var emailFrequencies = []store.EmailFrequency{store.EmailImmediate, store.EmailDaily, store.EmailOff}
+
+const (
+	outboxMaxAttempts = 5
+	outboxBatchSize   = 50
+	mailInterval      = time.Minute
+
+	// Reminders are sent this many days before a task's due date and the
+	// end of a review cycle
+	dueSoonDays       = 2
+	cycleDeadlineDays = 7
+)
+
+type emailData struct {
+	Name          string // Of the recipient
+	Notification  *store.Notification
+	Notifications []store.Notification // For the digest
+	URL           string               // Absolute link of the notification
+	BaseURL       string
+}
+
+// renderEmail executes an email template, returning the subject and body
+func (app *App) renderEmail(name string, data emailData) (string, string, error) {
+	var b strings.Builder
+	if err := app.emailTemplates.ExecuteTemplate(&b, name+".txt", data); err != nil {
+		return "", "", err
+	}
+	subject, body, _ := strings.Cut(b.String(), "\n")
+	return strings.TrimSpace(subject), strings.TrimLeft(body, "\n"), nil
+}
+
+// emailNotification queues an email about a new notification for users who
+// want one per notification
+func (app *App) emailNotification(n *store.Notification) error {
+	user, err := app.store.GetUserByID(n.UserID)
+	if err != nil {
+		return err
+	}
+	if user.Email == "" {
+		return nil
+	}
+	pref, err := app.store.GetEmailPreference(user.ID)
+	if err != nil {
+		return err
+	}
+	if pref.Frequency != store.EmailImmediate {
+		return nil
+	}
+
+	subject, body, err := app.renderEmail(string(n.Kind), emailData{
+		Name:         displayName(user),
+		Notification: n,
+		URL:          app.baseURL + n.Link,
+		BaseURL:      app.baseURL,
+	})
+	if err != nil {
+		return err
+	}
+	return app.store.QueueEmail(&store.OutboxEmail{UserID: &user.ID, To: user.Email, Subject: subject, Body: body})
+}
+
+// sendDailyDigests queues a digest of the past day's notifications for each
+// user on the daily digest who has not had one today
+func (app *App) sendDailyDigests(now time.Time) (string, error) {
+	prefs, err := app.store.GetDigestSubscribers()
+	if err != nil {
+		return "", err
+	}
+
+	today := startOfDay(now)
+	sent := 0
+	for _, pref := range prefs {
+		if pref.LastDigestAt != nil && !pref.LastDigestAt.Before(today) {
+			continue
+		}
+		since := now.AddDate(0, 0, -1)
+		if pref.LastDigestAt != nil {
+			since = *pref.LastDigestAt
+		}
+		queued, err := app.sendDigest(pref.UserID, since, now)
+		if err != nil {
+			return "", err
+		}
+		if queued {
+			sent++
+		}
+	}
+	return fmt.Sprintf("Queued %d digest(s)", sent), nil
+}
+
+// sendDigest queues a user's digest, reporting whether there was anything to send
+func (app *App) sendDigest(userID int, since, now time.Time) (bool, error) {
+	user, err := app.store.GetUserByID(userID)
+	if err != nil {
+		return false, err
+	}
+	notifications, err := app.store.GetNotificationsSince(userID, since)
+	if err != nil {
+		return false, err
+	}
+
+	queued := false
+	if user.Email != "" && len(notifications) > 0 {
+		subject, body, err := app.renderEmail("digest", emailData{
+			Name:          displayName(user),
+			Notifications: notifications,
+			URL:           app.baseURL + "/notifications",
+			BaseURL:       app.baseURL,
+		})
+		if err != nil {
+			return false, err
+		}
+		if err := app.store.QueueEmail(&store.OutboxEmail{UserID: &user.ID, To: user.Email, Subject: subject, Body: body}); err != nil {
+			return false, err
+		}
+		queued = true
+	}
+	return queued, app.store.SetLastDigestAt(userID, now)
+}
+
+// sendDeadlineReminders notifies the people working on tasks that are due
+// soon, and staff whose review cycle is about to end before their appraisal
+// is complete. Each reminder is sent once per due date or cycle end.
+func (app *App) sendDeadlineReminders(now time.Time) (string, error) {
+	today := startOfDay(now)
+	reminders := 0
+
+	tasks, err := app.store.GetOpenTasksDueBetween(today, today.AddDate(0, 0, dueSoonDays))
+	if err != nil {
+		return "", err
+	}
+	for _, task := range tasks {
+		workerID := task.WorkerID()
+		sent, err := app.store.HasNotificationSince(workerID, store.NotifyTaskDueSoon, task.ID, task.DueDate.AddDate(0, 0, -dueSoonDays-1))
+		if err != nil {
+			return "", err
+		}
+		if sent {
+			continue
+		}
+		link := "/tasks"
+		if workerID != task.UserID {
+			link = "/tasks?view=assigned"
+		}
+		app.notify(workerID, 0, store.NotifyTaskDueSoon, task.ID, link,
+			"Task %q is due on %s", task.Title, task.DueDate.Format("Jan 02, 2006"))
+		reminders++
+	}
+
+	cycles, err := app.store.GetAllReviewCycles()
+	if err != nil {
+		return "", err
+	}
+	for _, cycle := range cycles {
+		if cycle.Status != store.CycleStatusOpen || cycle.EndDate.Before(today) || cycle.EndDate.After(today.AddDate(0, 0, cycleDeadlineDays)) {
+			continue
+		}
+		userIDs, err := app.store.GetUsersWithOpenAppraisal(cycle.ID)
+		if err != nil {
+			return "", err
+		}
+		for _, userID := range userIDs {
+			sent, err := app.store.HasNotificationSince(userID, store.NotifyCycleDeadline, cycle.ID, cycle.EndDate.AddDate(0, 0, -cycleDeadlineDays-1))
+			if err != nil {
+				return "", err
+			}
+			if sent {
+				continue
+			}
+			app.notify(userID, 0, store.NotifyCycleDeadline, cycle.ID, "/appraisals",
+				"Review cycle %q ends on %s; bring your objectives up to date and complete your appraisal", cycle.Name, cycle.EndDate.Format("Jan 02, 2006"))
+			reminders++
+		}
+	}
+	return fmt.Sprintf("Sent %d reminder(s)", reminders), nil
+}
+
+// deliverOutbox sends the emails that are due. Failed sends are retried
+// after 1, 4, 16 and 64 minutes before the email is marked failed.
+func (app *App) deliverOutbox(sender mail.Sender, now time.Time) error {
+	emails, err := app.store.GetDueOutboxEmails(now, outboxBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range emails {
+		err := sender.Send(mail.Message{To: e.To, Subject: e.Subject, Body: e.Body})
+		if err == nil {
+			if err := app.store.MarkEmailSent(e.ID, now); err != nil {
+				return err
+			}
+			continue
+		}
+
+		e.Attempts++
+		e.LastError = err.Error()
+		e.NextAttemptAt = now.Add(time.Minute << (2 * (e.Attempts - 1)))
+		if e.Attempts >= outboxMaxAttempts {
+			e.Status = store.OutboxFailed
+		}
+		log.Printf("Error sending email %d to %s (attempt %d): %v", e.ID, e.To, e.Attempts, err)
+		if err := app.store.MarkEmailAttempt(&e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunMailer delivers the outbox every mailInterval. Reminders and digests
+// are queued by scheduled jobs.
+func (app *App) RunMailer() {
+	for {
+		now := time.Now().UTC()
+		if err := app.deliverOutbox(app.mailer, now); err != nil {
+			log.Println("Error delivering email:", err)
+		}
+		time.Sleep(mailInterval)
+	}
+}
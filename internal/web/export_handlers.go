@@ -0,0 +1,358 @@
+package web
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"staffperformance/internal/auth"
+	"staffperformance/internal/export"
+	"staffperformance/internal/store"
+)
+
+// Spreadsheet downloads of the reports. Each export has the same data and
+// access rules as the page it is linked from. Excel downloads hold every
+// table as a sheet; CSV downloads hold the table named by ?table=, or the
+// first one.
+
+// writeExport sends sheets in the format named by ?format=
+func writeExport(w http.ResponseWriter, r *http.Request, filename string, sheets []export.Sheet) {
+	format := r.URL.Query().Get("format")
+	if !oneOf(format, export.Formats) {
+		http.Error(w, "Unknown export format", http.StatusBadRequest)
+		return
+	}
+
+	// Written to a buffer first so a failure can still be reported
+	var buf bytes.Buffer
+	var err error
+	if format == export.FormatXLSX {
+		err = export.WriteXLSX(&buf, sheets)
+	} else {
+		sheet := export.Find(sheets, r.URL.Query().Get("table"))
+		filename += "-" + sheet.Name
+		err = export.WriteCSV(&buf, *sheet)
+	}
+	if err != nil {
+		log.Println("Error writing export:", err)
+		http.Error(w, "Error creating export", http.StatusInternalServerError)
+		return
+	}
+
+	filename = fmt.Sprintf("%s-%s.%s", filename, time.Now().Format("2006-01-02"), format)
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Write(buf.Bytes())
+}
+
+// Report export handler - a staff member's objectives, outcomes, activities
+// and tasks. Staff export their own report; supervisors and admins can pass
+// ?user= for anyone whose objectives and tasks they can see.
+func (app *App) reportExportHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	owner := user
+	if id, err := strconv.Atoi(r.URL.Query().Get("user")); err == nil && id != user.ID {
+		if !app.auth.Authorize(w, user, auth.ResourceObjectives, auth.ActionRead, id) || !app.auth.Authorize(w, user, auth.ResourceTasks, auth.ActionRead, id) {
+			return
+		}
+		owner, err = app.store.GetUserByID(id)
+		if err != nil {
+			http.Error(w, "Staff member not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	cycle, _, err := app.selectedCycle(r)
+	if err != nil {
+		log.Println("Error fetching review cycles:", err)
+		http.Error(w, "Error creating export", http.StatusInternalServerError)
+		return
+	}
+
+	sheets, err := app.staffReportSheets(owner, cycle)
+	if err != nil {
+		log.Println("Error building report export:", err)
+		http.Error(w, "Error creating export", http.StatusInternalServerError)
+		return
+	}
+	writeExport(w, r, "report-"+owner.Username, sheets)
+}
+
+// staffReportSheets builds the Objectives, Outcomes, Activities and Tasks
+// sheets of one staff member's report
+func (app *App) staffReportSheets(owner *store.User, cycle *store.ReviewCycle) ([]export.Sheet, error) {
+	hierarchy, err := app.hierarchyForCycle(owner.ID, cycle)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := app.store.GetTasksByUserID(owner.ID)
+	if err != nil {
+		return nil, err
+	}
+	tasks = tasksInCycle(tasks, cycle)
+
+	objectiveSheet := export.Sheet{Name: "Objectives", Columns: []string{
+		"ID", "Title", "Category", "Status", "Visibility", "Review cycle", "Start date", "End date", "Weight (%)", "Performance (%)", "Locked"}}
+	outcomeSheet := export.Sheet{Name: "Outcomes", Columns: []string{
+		"ID", "Objective ID", "Objective", "Title", "Description"}}
+	activitySheet := export.Sheet{Name: "Activities", Columns: []string{
+		"ID", "Objective", "Expected outcome", "Title", "Frequency", "Progress (%)", "Implementation level", "Last updated"}}
+	taskSheet := export.Sheet{Name: "Tasks", Columns: []string{
+		"ID", "Title", "Objective", "Expected outcome", "Type", "Priority", "Status", "Progress (%)", "Due date", "Completed", "Requested by", "Assigned to", "Assignment"}}
+
+	// Tasks are linked to outcomes, so the outcome and objective titles are
+	// looked up from the objectives being exported
+	type outcomeContext struct{ objective, outcome string }
+	outcomeTitles := make(map[int]outcomeContext)
+
+	for _, item := range hierarchy {
+		obj := item.Objective
+		category := string(obj.Category)
+		if obj.Category == store.CategoryOther && obj.CategoryOther != "" {
+			category = obj.CategoryOther
+		}
+		objectiveSheet.AddRow(obj.ID, obj.Title, category, string(obj.Status), string(obj.Visibility), obj.CycleName,
+			obj.StartDate, obj.EndDate, obj.Weight, obj.Performance, yesNo(obj.Locked))
+
+		for _, entry := range item.ExpectedOutcomes {
+			outcome := entry.ExpectedOutcome
+			outcomeTitles[outcome.ID] = outcomeContext{obj.Title, outcome.Title}
+			outcomeSheet.AddRow(outcome.ID, obj.ID, obj.Title, outcome.Title, outcome.Description)
+
+			for _, a := range entry.Activities {
+				activitySheet.AddRow(a.ID, obj.Title, outcome.Title, a.Title, string(a.Category), a.ProgressPercentage, a.ImplementationLevel, a.UpdatedAt)
+			}
+		}
+	}
+
+	users, err := app.userNames()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tasks {
+		var context outcomeContext
+		if t.ExpectedOutcomeID != nil {
+			context = outcomeTitles[*t.ExpectedOutcomeID]
+		}
+		assignee := ""
+		if t.AssignedToID != nil {
+			assignee = users[*t.AssignedToID]
+		}
+		taskSheet.AddRow(t.ID, t.Title, context.objective, context.outcome, string(t.TaskType), string(t.Priority), string(t.Status),
+			store.TaskProgress(t), t.DueDate, t.CompletedAt, t.RequestedBy, assignee, string(t.AssignmentStatus))
+	}
+
+	return []export.Sheet{objectiveSheet, outcomeSheet, activitySheet, taskSheet}, nil
+}
+
+// Team export handler - the supervisor dashboard's staff list with scores and
+// task counts: a supervisor's direct reports, or everyone for admins
+func (app *App) teamExportHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	var staff []store.User
+	var err error
+	if user.Role == store.RoleAdmin {
+		staff, err = app.store.GetAllUsers()
+	} else {
+		staff, err = app.store.GetStaffBySupervisor(user.ID)
+	}
+	if err != nil {
+		log.Println("Error fetching staff:", err)
+		http.Error(w, "Error creating export", http.StatusInternalServerError)
+		return
+	}
+
+	cycle, _, err := app.selectedCycle(r)
+	if err != nil {
+		log.Println("Error fetching review cycles:", err)
+		http.Error(w, "Error creating export", http.StatusInternalServerError)
+		return
+	}
+
+	summaries, err := app.summarizeStaff(staff, cycle, time.Now().UTC())
+	if err != nil {
+		log.Println("Error building team export:", err)
+		http.Error(w, "Error creating export", http.StatusInternalServerError)
+		return
+	}
+	sheet, err := app.staffSummarySheet("Team", summaries)
+	if err != nil {
+		log.Println("Error building team export:", err)
+		http.Error(w, "Error creating export", http.StatusInternalServerError)
+		return
+	}
+	writeExport(w, r, "team", []export.Sheet{sheet})
+}
+
+// Organisation export handler - staff and department summaries across the
+// whole organisation, for admins
+func (app *App) organisationExportHandler(w http.ResponseWriter, r *http.Request) {
+	staff, err := app.store.GetAllUsers()
+	if err != nil {
+		log.Println("Error fetching staff:", err)
+		http.Error(w, "Error creating export", http.StatusInternalServerError)
+		return
+	}
+
+	cycle, _, err := app.selectedCycle(r)
+	if err != nil {
+		log.Println("Error fetching review cycles:", err)
+		http.Error(w, "Error creating export", http.StatusInternalServerError)
+		return
+	}
+
+	summaries, err := app.summarizeStaff(staff, cycle, time.Now().UTC())
+	if err != nil {
+		log.Println("Error building organisation export:", err)
+		http.Error(w, "Error creating export", http.StatusInternalServerError)
+		return
+	}
+	staffSheet, err := app.staffSummarySheet("Staff", summaries)
+	if err != nil {
+		log.Println("Error building organisation export:", err)
+		http.Error(w, "Error creating export", http.StatusInternalServerError)
+		return
+	}
+	writeExport(w, r, "organisation", []export.Sheet{departmentSummarySheet(summaries), staffSheet})
+}
+
+// StaffSummary is one staff member's figures for a review cycle, or for all
+// periods when no cycle is selected
+type StaffSummary struct {
+	User           store.User
+	Objectives     int
+	Score          float64 // Weighted overall score
+	TasksCompleted int
+	TasksOpen      int
+	TasksOverdue   int // Open and past their due date
+}
+
+func (app *App) summarizeStaff(staff []store.User, cycle *store.ReviewCycle, now time.Time) ([]StaffSummary, error) {
+	today := startOfDay(now)
+	staffIDs := make([]int, len(staff))
+	for i, u := range staff {
+		staffIDs[i] = u.ID
+	}
+	team, err := app.teamObjectivesForCycle(staffIDs, cycle)
+	if err != nil {
+		return nil, err
+	}
+	teamTasks, err := app.store.GetTasksByUserIDs(staffIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var summaries []StaffSummary
+	for _, u := range staff {
+		objectives := team[u.ID]
+		s := StaffSummary{User: u, Objectives: len(objectives), Score: OverallScore(objectives)}
+		for _, t := range tasksInCycle(teamTasks[u.ID], cycle) {
+			switch {
+			case t.Status == store.TaskStatusCompleted:
+				s.TasksCompleted++
+			case t.DueDate.Before(today):
+				s.TasksOpen++
+				s.TasksOverdue++
+			default:
+				s.TasksOpen++
+			}
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, nil
+}
+
+func (app *App) staffSummarySheet(name string, summaries []StaffSummary) (export.Sheet, error) {
+	sheet := export.Sheet{Name: name, Columns: []string{
+		"Username", "Full name", "Email", "Role", "Department", "Position", "Supervisor",
+		"Objectives", "Score (%)", "Tasks completed", "Tasks open", "Tasks overdue"}}
+
+	users, err := app.userNames()
+	if err != nil {
+		return sheet, err
+	}
+	for _, s := range summaries {
+		supervisor := ""
+		if s.User.SupervisorID != nil {
+			supervisor = users[*s.User.SupervisorID]
+		}
+		sheet.AddRow(s.User.Username, s.User.FullName, s.User.Email, string(s.User.Role), s.User.DepartmentName, s.User.Position, supervisor,
+			s.Objectives, s.Score, s.TasksCompleted, s.TasksOpen, s.TasksOverdue)
+	}
+	return sheet, nil
+}
+
+// departmentSummarySheet totals staff summaries by department. The score is
+// the mean of the scores of staff with objectives.
+func departmentSummarySheet(summaries []StaffSummary) export.Sheet {
+	type totals struct {
+		staff, scored, objectives, completed, open, overdue int
+		score                                               float64
+	}
+	byDepartment := make(map[string]*totals)
+	for _, s := range summaries {
+		name := s.User.DepartmentName
+		if name == "" {
+			name = "(No department)"
+		}
+		t := byDepartment[name]
+		if t == nil {
+			t = &totals{}
+			byDepartment[name] = t
+		}
+		t.staff++
+		t.objectives += s.Objectives
+		if s.Objectives > 0 {
+			t.scored++
+			t.score += s.Score
+		}
+		t.completed += s.TasksCompleted
+		t.open += s.TasksOpen
+		t.overdue += s.TasksOverdue
+	}
+
+	names := make([]string, 0, len(byDepartment))
+	for name := range byDepartment {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sheet := export.Sheet{Name: "Departments", Columns: []string{
+		"Department", "Staff", "Objectives", "Average score (%)", "Tasks completed", "Tasks open", "Tasks overdue"}}
+	for _, name := range names {
+		t := byDepartment[name]
+		var average *float64
+		if t.scored > 0 {
+			mean := t.score / float64(t.scored)
+			average = &mean
+		}
+		sheet.AddRow(name, t.staff, t.objectives, average, t.completed, t.open, t.overdue)
+	}
+	return sheet
+}
+
+// userNames maps user IDs to display names
+func (app *App) userNames() (map[int]string, error) {
+	users, err := app.store.GetAllUsers()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int]string, len(users))
+	for i := range users {
+		names[users[i].ID] = displayName(&users[i])
+	}
+	return names, nil
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "Yes"
+	}
+	return "No"
+}
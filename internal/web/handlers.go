@@ -0,0 +1,782 @@
+package web
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"staffperformance/internal/auth"
+	"staffperformance/internal/store"
+)
+
+// Dashboard handler
+func (app *App) dashboardHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	// Get summary statistics
+	objectives, _ := app.store.GetObjectivesByUserID(user.ID)
+	tasks, _ := app.store.GetTasksByUserID(user.ID)
+	completedTasks, pendingTasks, _ := app.store.GetTaskCountsByStatus(user.ID)
+
+	projects, err := app.store.GetProjectsForUser(user.ID)
+	if err != nil {
+		log.Println("Error fetching projects:", err)
+	}
+	for i := range projects {
+		app.loadProjectProgress(&projects[i])
+	}
+
+	// Objectives count towards the overall score in proportion to their weight
+	avgPerformance := OverallScore(objectives)
+
+	unread, err := app.store.CountUnreadNotifications(user.ID)
+	if err != nil {
+		log.Println("Error counting notifications:", err)
+	}
+
+	data := struct {
+		User                store.User
+		TotalObjectives     int
+		TotalTasks          int
+		CompletedTasks      int
+		PendingTasks        int
+		AveragePerformance  float64
+		Projects            []store.Project
+		UnreadNotifications int
+	}{
+		User:                *user,
+		TotalObjectives:     len(objectives),
+		TotalTasks:          len(tasks),
+		CompletedTasks:      completedTasks,
+		PendingTasks:        pendingTasks,
+		AveragePerformance:  avgPerformance,
+		Projects:            projects,
+		UnreadNotifications: unread,
+	}
+
+	err = app.render(w, r, "dashboard.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// Objective handlers
+func (app *App) newObjectiveHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	if r.Method == http.MethodPost {
+		title := r.FormValue("title")
+		description := r.FormValue("description")
+		startDate := r.FormValue("start_date")
+		endDate := r.FormValue("end_date")
+		visibility := store.ObjectiveVisibility(r.FormValue("visibility"))
+		status := store.ObjectiveStatus(r.FormValue("status"))
+		category := store.ObjectiveCategory(r.FormValue("category"))
+		categoryOther := r.FormValue("category_other")
+		weightStr := r.FormValue("weight")
+
+		start, _ := time.Parse("2006-01-02", startDate)
+		end, _ := time.Parse("2006-01-02", endDate)
+		weight, weightErr := parseWeight(weightStr)
+
+		obj := &store.Objective{
+			UserID:        user.ID,
+			Title:         title,
+			Description:   description,
+			StartDate:     start,
+			EndDate:       end,
+			Visibility:    visibility,
+			Status:        status,
+			Category:      category,
+			CategoryOther: categoryOther,
+			Weight:        weight,
+			ProjectID:     app.userProjectID(user.ID, r.FormValue("project_id")),
+			ReviewCycleID: app.assignableCycleID(r.FormValue("review_cycle_id")),
+		}
+
+		err := weightErr
+		if err == nil {
+			err = app.checkCycleWeight(obj)
+		}
+		if err != nil {
+			if !isValidationError(err) {
+				log.Println("Error checking objective weights:", err)
+				http.Error(w, "Error creating objective", http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusBadRequest)
+			app.renderObjectiveForm(w, r, user, obj, false, "Cannot save: "+err.Error())
+			return
+		}
+
+		err = app.store.CreateObjective(obj, user.ID)
+		if err != nil {
+			log.Println("Error creating objective:", err)
+			http.Error(w, "Error creating objective", http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+		return
+	}
+
+	app.renderObjectiveForm(w, r, user, nil, false, "")
+}
+
+// renderObjectiveForm shows the objective form with the projects and review
+// cycles the user can link the objective to
+func (app *App) renderObjectiveForm(w http.ResponseWriter, r *http.Request, user *store.User, obj *store.Objective, isEdit bool, formError string) {
+	projects, err := app.store.GetProjectsForUser(user.ID)
+	if err != nil {
+		log.Println("Error fetching projects:", err)
+	}
+	cycles, err := app.assignableCycles()
+	if err != nil {
+		log.Println("Error fetching review cycles:", err)
+	}
+
+	data := ObjectiveFormData{
+		User:      *user,
+		Objective: obj,
+		Projects:  projects,
+		Cycles:    cycles,
+		Error:     formError,
+		IsEdit:    isEdit,
+
+		ManualRating: app.manualRatingEnabled(),
+	}
+
+	err = app.render(w, r, "objective_form.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+func (app *App) editObjectiveHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	idStr := r.URL.Query().Get("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid objective ID", http.StatusBadRequest)
+		return
+	}
+
+	obj, err := app.store.GetObjectiveByID(id)
+	if err != nil {
+		http.Error(w, "Objective not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify ownership
+	if !app.auth.Authorize(w, user, auth.ResourceObjectives, auth.ActionWrite, obj.UserID) {
+		return
+	}
+	if !requireUnlocked(w, obj) {
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		obj.Title = r.FormValue("title")
+		obj.Description = r.FormValue("description")
+		startDate := r.FormValue("start_date")
+		endDate := r.FormValue("end_date")
+		obj.Visibility = store.ObjectiveVisibility(r.FormValue("visibility"))
+		obj.Status = store.ObjectiveStatus(r.FormValue("status"))
+		obj.Category = store.ObjectiveCategory(r.FormValue("category"))
+		obj.CategoryOther = r.FormValue("category_other")
+		weightStr := r.FormValue("weight")
+
+		obj.StartDate, _ = time.Parse("2006-01-02", startDate)
+		obj.EndDate, _ = time.Parse("2006-01-02", endDate)
+		var weightErr error
+		obj.Weight, weightErr = parseWeight(weightStr)
+		obj.ProjectID = app.userProjectID(user.ID, r.FormValue("project_id"))
+		obj.ReviewCycleID = app.assignableCycleID(r.FormValue("review_cycle_id"))
+
+		err = weightErr
+		if err == nil {
+			err = app.checkCycleWeight(obj)
+		}
+		if err != nil {
+			if !isValidationError(err) {
+				log.Println("Error checking objective weights:", err)
+				http.Error(w, "Error updating objective", http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusBadRequest)
+			app.renderObjectiveForm(w, r, user, obj, true, "Cannot save: "+err.Error())
+			return
+		}
+
+		err = app.store.UpdateObjective(obj, user.ID)
+		if err != nil {
+			log.Println("Error updating objective:", err)
+			http.Error(w, "Error updating objective", http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+		return
+	}
+
+	app.renderObjectiveForm(w, r, user, obj, true, "")
+}
+
+func (app *App) deleteObjectiveHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := r.FormValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid objective ID", http.StatusBadRequest)
+		return
+	}
+
+	obj, err := app.store.GetObjectiveByID(id)
+	if err != nil {
+		http.Error(w, "Objective not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify ownership
+	if !app.auth.Authorize(w, user, auth.ResourceObjectives, auth.ActionWrite, obj.UserID) {
+		return
+	}
+	if !requireUnlocked(w, obj) {
+		return
+	}
+
+	err = app.store.DeleteObjective(id, user.ID)
+	if err != nil {
+		log.Println("Error deleting objective:", err)
+		http.Error(w, "Error deleting objective", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+}
+
+// Expected Outcome handlers
+func (app *App) newExpectedOutcomeHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	objIDStr := r.URL.Query().Get("objective_id")
+	objID, err := strconv.Atoi(objIDStr)
+	if err != nil {
+		http.Error(w, "Invalid objective ID", http.StatusBadRequest)
+		return
+	}
+
+	obj, err := app.store.GetObjectiveByID(objID)
+	if err != nil {
+		http.Error(w, "Objective not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify ownership
+	if !app.auth.Authorize(w, user, auth.ResourceObjectives, auth.ActionWrite, obj.UserID) {
+		return
+	}
+	if !requireUnlocked(w, obj) {
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		title := r.FormValue("title")
+		description := r.FormValue("description")
+
+		outcome := &store.ExpectedOutcome{
+			ObjectiveID: objID,
+			Title:       title,
+			Description: description,
+		}
+
+		err = app.store.CreateExpectedOutcome(outcome, user.ID)
+		if err != nil {
+			log.Println("Error creating expected outcome:", err)
+			http.Error(w, "Error creating expected outcome", http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+		return
+	}
+
+	data := ExpectedOutcomeFormData{
+		User:      *user,
+		Objective: *obj,
+		IsEdit:    false,
+	}
+
+	err = app.render(w, r, "expected_outcome_form.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+func (app *App) editExpectedOutcomeHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	idStr := r.URL.Query().Get("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid expected outcome ID", http.StatusBadRequest)
+		return
+	}
+
+	outcome, err := app.store.GetExpectedOutcomeByID(id)
+	if err != nil {
+		http.Error(w, "Expected outcome not found", http.StatusNotFound)
+		return
+	}
+
+	obj, err := app.store.GetObjectiveByID(outcome.ObjectiveID)
+	if err != nil {
+		http.Error(w, "Objective not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify ownership
+	if !app.auth.Authorize(w, user, auth.ResourceObjectives, auth.ActionWrite, obj.UserID) {
+		return
+	}
+	if !requireUnlocked(w, obj) {
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		outcome.Title = r.FormValue("title")
+		outcome.Description = r.FormValue("description")
+
+		err = app.store.UpdateExpectedOutcome(outcome, user.ID)
+		if err != nil {
+			log.Println("Error updating expected outcome:", err)
+			http.Error(w, "Error updating expected outcome", http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+		return
+	}
+
+	data := ExpectedOutcomeFormData{
+		User:            *user,
+		Objective:       *obj,
+		ExpectedOutcome: outcome,
+		IsEdit:          true,
+	}
+
+	err = app.render(w, r, "expected_outcome_form.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+func (app *App) deleteExpectedOutcomeHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := r.FormValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid expected outcome ID", http.StatusBadRequest)
+		return
+	}
+
+	outcome, err := app.store.GetExpectedOutcomeByID(id)
+	if err != nil {
+		http.Error(w, "Expected outcome not found", http.StatusNotFound)
+		return
+	}
+
+	obj, err := app.store.GetObjectiveByID(outcome.ObjectiveID)
+	if err != nil {
+		http.Error(w, "Objective not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify ownership
+	if !app.auth.Authorize(w, user, auth.ResourceObjectives, auth.ActionWrite, obj.UserID) {
+		return
+	}
+	if !requireUnlocked(w, obj) {
+		return
+	}
+
+	err = app.store.DeleteExpectedOutcome(id, user.ID)
+	if err != nil {
+		log.Println("Error deleting expected outcome:", err)
+		http.Error(w, "Error deleting expected outcome", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+}
+
+// Activity handlers
+func (app *App) newActivityHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	outcomeIDStr := r.URL.Query().Get("outcome_id")
+	outcomeID, err := strconv.Atoi(outcomeIDStr)
+	if err != nil {
+		http.Error(w, "Invalid expected outcome ID", http.StatusBadRequest)
+		return
+	}
+
+	outcome, err := app.store.GetExpectedOutcomeByID(outcomeID)
+	if err != nil {
+		http.Error(w, "Expected outcome not found", http.StatusNotFound)
+		return
+	}
+
+	obj, err := app.store.GetObjectiveByID(outcome.ObjectiveID)
+	if err != nil {
+		http.Error(w, "Objective not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify ownership
+	if !app.auth.Authorize(w, user, auth.ResourceObjectives, auth.ActionWrite, obj.UserID) {
+		return
+	}
+	if !requireUnlocked(w, obj) {
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		title := r.FormValue("title")
+		description := r.FormValue("description")
+		category := store.ActivityCategory(r.FormValue("category"))
+		progressStr := r.FormValue("progress_percentage")
+		implementationLevel := r.FormValue("implementation_level")
+
+		progress, _ := strconv.ParseFloat(progressStr, 64)
+
+		activity := &store.Activity{
+			ExpectedOutcomeID:   outcomeID,
+			Title:               title,
+			Description:         description,
+			Category:            category,
+			ProgressPercentage:  progress,
+			ImplementationLevel: implementationLevel,
+		}
+
+		err = app.store.CreateActivity(activity, user.ID)
+		if err != nil {
+			log.Println("Error creating activity:", err)
+			http.Error(w, "Error creating activity", http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+		return
+	}
+
+	categories := []store.ActivityCategory{
+		store.CategoryDaily,
+		store.CategoryWeekly,
+		store.CategoryMonthly,
+		store.CategoryQuarterly,
+		store.CategoryBiannually,
+		store.CategoryAnnually,
+	}
+
+	data := ActivityFormData{
+		User:            *user,
+		Objective:       *obj,
+		ExpectedOutcome: *outcome,
+		Categories:      categories,
+		IsEdit:          false,
+	}
+
+	err = app.render(w, r, "activity_form.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+func (app *App) editActivityHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	idStr := r.URL.Query().Get("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid activity ID", http.StatusBadRequest)
+		return
+	}
+
+	activity, err := app.store.GetActivityByID(id)
+	if err != nil {
+		http.Error(w, "Activity not found", http.StatusNotFound)
+		return
+	}
+
+	outcome, err := app.store.GetExpectedOutcomeByID(activity.ExpectedOutcomeID)
+	if err != nil {
+		http.Error(w, "Expected outcome not found", http.StatusNotFound)
+		return
+	}
+
+	obj, err := app.store.GetObjectiveByID(outcome.ObjectiveID)
+	if err != nil {
+		http.Error(w, "Objective not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify ownership
+	if !app.auth.Authorize(w, user, auth.ResourceObjectives, auth.ActionWrite, obj.UserID) {
+		return
+	}
+	if !requireUnlocked(w, obj) {
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		activity.Title = r.FormValue("title")
+		activity.Description = r.FormValue("description")
+		activity.Category = store.ActivityCategory(r.FormValue("category"))
+		progressStr := r.FormValue("progress_percentage")
+		activity.ImplementationLevel = r.FormValue("implementation_level")
+
+		activity.ProgressPercentage, _ = strconv.ParseFloat(progressStr, 64)
+
+		err = app.store.UpdateActivity(activity, user.ID)
+		if err != nil {
+			log.Println("Error updating activity:", err)
+			http.Error(w, "Error updating activity", http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+		return
+	}
+
+	categories := []store.ActivityCategory{
+		store.CategoryDaily,
+		store.CategoryWeekly,
+		store.CategoryMonthly,
+		store.CategoryQuarterly,
+		store.CategoryBiannually,
+		store.CategoryAnnually,
+	}
+
+	data := ActivityFormData{
+		User:            *user,
+		Objective:       *obj,
+		ExpectedOutcome: *outcome,
+		Activity:        activity,
+		Categories:      categories,
+		IsEdit:          true,
+	}
+
+	err = app.render(w, r, "activity_form.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+func (app *App) deleteActivityHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := r.FormValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid activity ID", http.StatusBadRequest)
+		return
+	}
+
+	activity, err := app.store.GetActivityByID(id)
+	if err != nil {
+		http.Error(w, "Activity not found", http.StatusNotFound)
+		return
+	}
+
+	outcome, err := app.store.GetExpectedOutcomeByID(activity.ExpectedOutcomeID)
+	if err != nil {
+		http.Error(w, "Expected outcome not found", http.StatusNotFound)
+		return
+	}
+
+	obj, err := app.store.GetObjectiveByID(outcome.ObjectiveID)
+	if err != nil {
+		http.Error(w, "Objective not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify ownership
+	if !app.auth.Authorize(w, user, auth.ResourceObjectives, auth.ActionWrite, obj.UserID) {
+		return
+	}
+	if !requireUnlocked(w, obj) {
+		return
+	}
+
+	err = app.store.DeleteActivity(id, user.ID)
+	if err != nil {
+		log.Println("Error deleting activity:", err)
+		http.Error(w, "Error deleting activity", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+}
+
+// Logout handler. Only a POST with the CSRF token signs out, so another site
+// cannot sign users out with a link or an image.
+func (app *App) logoutHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := app.auth.ClearSession(w, r)
+	if err != nil {
+		log.Println("Error clearing session:", err)
+	}
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (app *App) homeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Check if user is already logged in
+	user, err := app.auth.GetSession(r)
+	if err == nil && user != nil {
+		http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+		return
+	}
+
+	err = app.render(w, r, "login.html", nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err)
+	}
+}
+
+func (app *App) loginHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	username := r.FormValue("username")
+	password := r.FormValue("password")
+	ip := app.clientIP(r)
+
+	// Attempts sharing the address or the username go one at a time, so each
+	// sees the failures of those before it
+	defer app.loginLocks.lock("ip:" + ip)()
+	defer app.loginLocks.lock("user:" + username)()
+	now := time.Now()
+
+	user, err := app.store.GetUserByUsername(username)
+	if err != nil {
+		user = nil
+	}
+
+	// Slow down guessing before looking at the password
+	var lockEnded time.Time
+	if user != nil && user.LockedUntil != nil && !user.Locked() {
+		lockEnded = *user.LockedUntil
+	}
+	wait, err := app.loginDelay(username, ip, lockEnded, now)
+	if err != nil {
+		log.Println("Error reading login history:", err)
+		http.Error(w, "Login error", http.StatusInternalServerError)
+		return
+	}
+	if wait > 0 {
+		log.Printf("Login throttled for user %s from %s", username, ip)
+		seconds := int(math.Ceil(wait.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+		http.Error(w, fmt.Sprintf("Too many failed sign-ins; try again in %d seconds", seconds), http.StatusTooManyRequests)
+		return
+	}
+
+	// Every failure takes the same password check and gets the same answer,
+	// so the response does not tell whether the username exists or is
+	// locked. Why it failed is kept in the login history.
+	var failure store.LoginFailure
+	var needsRehash bool
+	if user == nil {
+		auth.CheckDummyPassword(password)
+		failure = store.LoginUnknownUser
+	} else {
+		var ok bool
+		ok, needsRehash = auth.CheckPassword(user.Password, password)
+		if user.Locked() {
+			failure = store.LoginAccountLocked
+		} else if !ok {
+			failure = store.LoginWrongPassword
+		}
+	}
+	if failure != "" {
+		log.Printf("Login failed for user %s: %s", username, failure.Label())
+		app.recordLogin(r, user, username, ip, failure)
+		if failure == store.LoginWrongPassword {
+			app.countLoginFailure(user, now)
+		}
+		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		return
+	}
+
+	// Upgrade legacy plaintext passwords now that the password is known
+	if needsRehash {
+		hash, err := auth.HashPassword(password)
+		if err == nil {
+			err = app.store.UpdateUserPassword(user.ID, hash, user.MustChangePassword)
+		}
+		if err != nil {
+			log.Println("Error upgrading password hash:", err)
+		} else {
+			log.Printf("Upgraded password hash for user: %s", username)
+		}
+	}
+
+	// Set session
+	err = app.auth.SetSession(w, r, user)
+	if err != nil {
+		log.Println("Error setting session:", err)
+		http.Error(w, "Login error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Login successful for user: %s", username)
+	app.recordLogin(r, user, username, ip, "")
+	if err := app.store.UnlockUser(user.ID); err != nil {
+		log.Println("Error resetting failed logins:", err)
+	}
+	if user.MustChangePassword {
+		http.Redirect(w, r, "/account/password", http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+}
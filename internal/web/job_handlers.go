@@ -0,0 +1,64 @@
+package web
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"staffperformance/internal/auth"
+	"staffperformance/internal/store"
+)
+
+// jobRunsShown is how many job runs the jobs page lists
+const jobRunsShown = 100
+
+// Jobs handler - the scheduled jobs, their last run and the run history, for admins
+func (app *App) jobsHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	data := JobsData{User: *user}
+	for _, job := range app.jobs {
+		last, err := app.store.GetLastJobRun(job.Name)
+		if err != nil {
+			log.Println("Error fetching job run:", err)
+			http.Error(w, "Error loading jobs", http.StatusInternalServerError)
+			return
+		}
+		data.Jobs = append(data.Jobs, JobStatus{Job: job, LastRun: last})
+	}
+
+	runs, err := app.store.GetJobRuns(jobRunsShown)
+	if err != nil {
+		log.Println("Error fetching job runs:", err)
+		http.Error(w, "Error loading jobs", http.StatusInternalServerError)
+		return
+	}
+	data.Runs = runs
+
+	err = app.render(w, r, "jobs.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// Run job handler - runs a scheduled job straight away
+func (app *App) runJobHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := auth.CurrentUser(r)
+
+	job := app.findJob(r.FormValue("job"))
+	if job == nil {
+		http.Error(w, "Unknown job", http.StatusBadRequest)
+		return
+	}
+
+	// A failed run is recorded and shown in the history
+	if _, err := app.runJob(job, store.TriggerManual, time.Now().UTC()); err != nil {
+		log.Printf("Job %s run by %s failed: %v", job.Name, user.Username, err)
+	}
+	http.Redirect(w, r, "/admin/jobs", http.StatusSeeOther)
+}
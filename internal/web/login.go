@@ -0,0 +1,221 @@
+package web
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"staffperformance/internal/store"
+)
+
+// LoginPolicy is how many failed sign-ins lock an account, and for how long
+type LoginPolicy struct {
+	MaxFailures int           // Consecutive failures that lock the account
+	Lockout     time.Duration // How long the lock lasts
+}
+
+// DefaultLoginPolicy is used when the configuration sets no policy
+var DefaultLoginPolicy = LoginPolicy{MaxFailures: 10, Lockout: 15 * time.Minute}
+
+// Failed sign-ins are slowed down by exponential backoff, counted both per
+// username and per client address. Once the free attempts are used up, each
+// attempt must wait twice as long after the previous failure as the one
+// before, up to loginBackoffMax. Only failures within loginFailureWindow
+// count, and not those followed by a successful sign-in with the same
+// username, so signing in to one account does not clear an address's failures
+// against others.
+const (
+	loginFreeAttempts   = 3  // Per username
+	loginFreeAttemptsIP = 20 // Per address, which a whole office may share
+	loginBackoffBase    = time.Second
+	loginBackoffMax     = 5 * time.Minute
+	loginFailureWindow  = time.Hour
+)
+
+// loginLocks makes sign-in attempts that share a key wait for each other.
+// The throttle reads the login history before the attempt is recorded, so
+// without it parallel attempts would all see the same earlier failures.
+type loginLocks struct {
+	mu    sync.Mutex
+	locks map[string]*loginLock
+}
+
+type loginLock struct {
+	sync.Mutex
+	waiting int // Attempts holding or waiting for the lock
+}
+
+// lock waits until no other attempt holds key, and returns the function that
+// releases it
+func (l *loginLocks) lock(key string) (unlock func()) {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*loginLock)
+	}
+	k := l.locks[key]
+	if k == nil {
+		k = &loginLock{}
+		l.locks[key] = k
+	}
+	k.waiting++
+	l.mu.Unlock()
+
+	k.Lock()
+	return func() {
+		k.Unlock()
+		l.mu.Lock()
+		if k.waiting--; k.waiting == 0 {
+			delete(l.locks, key)
+		}
+		l.mu.Unlock()
+	}
+}
+
+// loginHistoryRetention is how long sign-in attempts are kept
+const loginHistoryRetention = 90 * 24 * time.Hour
+
+// maxUserAgentLength bounds the user agent stored with a sign-in attempt
+const maxUserAgentLength = 256
+
+// failureRun counts failed sign-ins, added newest first, that no later
+// success with the same username has cleared
+type failureRun struct {
+	count     int
+	last      time.Time       // Time of the newest failure
+	succeeded map[string]bool // Usernames with a success after the events so far
+}
+
+func (f *failureRun) add(e store.LoginEvent) {
+	if e.Success {
+		if f.succeeded == nil {
+			f.succeeded = make(map[string]bool)
+		}
+		f.succeeded[e.Username] = true
+		return
+	}
+	if f.succeeded[e.Username] {
+		return
+	}
+	if f.count == 0 {
+		f.last = e.CreatedAt
+	}
+	f.count++
+}
+
+// wait is how long after now the next attempt must wait, given the number of
+// failures allowed before backoff starts
+func (f *failureRun) wait(now time.Time, free int) time.Duration {
+	if f.count < free {
+		return 0
+	}
+	delay := loginBackoffMax
+	if n := f.count - free; n < 16 {
+		delay = min(loginBackoffBase<<n, loginBackoffMax)
+	}
+	return max(f.last.Add(delay).Sub(now), 0)
+}
+
+// loginDelay is how long a sign-in with the username from the address must
+// wait because of earlier failures; zero when it may go ahead. Failures with
+// the username before lockEnded, when the account's last lock ended, have
+// been dealt with by the lock and do not count.
+func (app *App) loginDelay(username, ip string, lockEnded, now time.Time) (time.Duration, error) {
+	events, err := app.store.GetRecentLoginEvents(username, ip, now.Add(-loginFailureWindow))
+	if err != nil {
+		return 0, err
+	}
+	var byUsername, byIP failureRun
+	for _, e := range events {
+		if e.Username == username && e.CreatedAt.After(lockEnded) {
+			byUsername.add(e)
+		}
+		if e.IP == ip {
+			byIP.add(e)
+		}
+	}
+	return max(byUsername.wait(now, loginFreeAttempts), byIP.wait(now, loginFreeAttemptsIP)), nil
+}
+
+// recordLogin keeps a sign-in attempt in the login history. user is nil when
+// the username matched no account; failure is empty for a success.
+func (app *App) recordLogin(r *http.Request, user *store.User, username, ip string, failure store.LoginFailure) {
+	event := &store.LoginEvent{
+		Username:  username,
+		IP:        ip,
+		UserAgent: r.UserAgent(),
+		Success:   failure == "",
+		Failure:   failure,
+		CreatedAt: time.Now(),
+	}
+	event.UserAgent = truncateUTF8(event.UserAgent, maxUserAgentLength)
+	if user != nil {
+		event.UserID = &user.ID
+	}
+	if err := app.store.CreateLoginEvent(event); err != nil {
+		log.Println("Error recording login:", err)
+	}
+}
+
+// truncateUTF8 shortens s to at most n bytes without splitting a character.
+// Invalid UTF-8, which a client can send in any header, is replaced first.
+func truncateUTF8(s string, n int) string {
+	s = strings.ToValidUTF8(s, "\uFFFD")
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// countLoginFailure adds a failed sign-in to the account's count and locks
+// the account when the count reaches the policy's limit
+func (app *App) countLoginFailure(user *store.User, now time.Time) {
+	failures, err := app.store.IncrementFailedLogins(user.ID)
+	if err != nil {
+		log.Println("Error counting failed login:", err)
+		return
+	}
+	if failures < app.login.MaxFailures {
+		return
+	}
+	until := now.Add(app.login.Lockout)
+	if err := app.store.LockUser(user.ID, until); err != nil {
+		log.Println("Error locking user:", err)
+		return
+	}
+	log.Printf("Locked user %s until %s after %d failed logins", user.Username, until.Format(time.RFC3339), failures)
+}
+
+// clientIP is the address a request came from. Behind a trusted reverse
+// proxy it is the address the proxy appended to X-Forwarded-For.
+func (app *App) clientIP(r *http.Request) string {
+	if app.trustProxy {
+		if values := r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+			addrs := strings.Split(values[len(values)-1], ",")
+			if addr := strings.TrimSpace(addrs[len(addrs)-1]); addr != "" {
+				return addr
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// pruneLoginHistory deletes sign-in attempts older than loginHistoryRetention
+func (app *App) pruneLoginHistory(now time.Time) (string, error) {
+	n, err := app.store.DeleteLoginEventsBefore(now.Add(-loginHistoryRetention))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Deleted %d sign-in record(s)", n), nil
+}
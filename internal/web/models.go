@@ -0,0 +1,298 @@
+package web
+
+import (
+	"time"
+
+	"staffperformance/internal/store"
+)
+
+// ViewModels for templates
+
+type DashboardData struct {
+	User           store.User
+	Objectives     []store.ObjectiveWithOutcomes
+	WeightWarnings []WeightWarning // Review cycles whose weights do not add up to 100%
+}
+
+type ObjectiveFormData struct {
+	User      store.User
+	Objective *store.Objective
+	Projects  []store.Project     // For optionally linking to a project
+	Cycles    []store.ReviewCycle // Review cycles still accepting objectives
+	Error     string
+	IsEdit    bool
+	// ManualRating shows the supervisor's rating when objectives are scored
+	// by hand; owners cannot change it
+	ManualRating bool
+}
+
+type ExpectedOutcomeFormData struct {
+	User            store.User
+	Objective       store.Objective
+	ExpectedOutcome *store.ExpectedOutcome
+	IsEdit          bool
+}
+
+type ActivityFormData struct {
+	User            store.User
+	Objective       store.Objective
+	ExpectedOutcome store.ExpectedOutcome
+	Activity        *store.Activity
+	Categories      []store.ActivityCategory
+	IsEdit          bool
+}
+
+type TaskListData struct {
+	User         store.User
+	Tasks        []store.Task
+	Priorities   []store.TaskPriority
+	Statuses     []store.TaskStatus
+	View         string       // "created" or "assigned"
+	PendingCount int          // Assignments waiting for the user to accept or decline
+	Colleagues   []store.User // Users a task assigned to the user can be passed on to
+	Error        string
+}
+
+type TaskFormData struct {
+	User       store.User
+	Task       *store.Task
+	Objectives []store.ObjectiveWithOutcomes // For selecting expected outcome
+	Priorities []store.TaskPriority
+	Statuses   []store.TaskStatus
+	TaskTypes  []store.TaskType
+	Projects   []store.Project // For optionally linking to a project
+	Assignees  []store.User    // Users the task can be assigned to
+	IsEdit     bool
+	IsAssignee bool // Editing a task assigned by someone else; only progress can change
+	Error      string
+}
+
+// TaskWithContext includes task details with associated objective and expected outcome
+type TaskWithContext struct {
+	Task            store.Task
+	Objective       *store.Objective
+	ExpectedOutcome *store.ExpectedOutcome
+}
+
+type ReportData struct {
+	User               store.User
+	Cycles             []store.ReviewCycle // For the cycle filter
+	Cycle              *store.ReviewCycle  // Selected cycle; nil shows all periods
+	Objectives         []store.ObjectiveWithOutcomes
+	Tasks              []store.Task
+	TotalObjectives    int
+	CompletedTasks     int
+	PendingTasks       int
+	AveragePerformance float64
+	AsOf               *time.Time // Past date the objective scores are shown for; nil for now
+	Velocity           []VelocityWeek
+	AverageVelocity    float64
+}
+type StaffListData struct {
+	Username string
+	Staff    []store.User
+}
+
+type StaffFormData struct {
+	Username    string
+	Staff       *store.User
+	Supervisors []store.User
+	Departments []store.Department
+	IsEdit      bool
+}
+
+type DepartmentListData struct {
+	Username    string
+	Departments []store.Department
+}
+
+type DepartmentFormData struct {
+	Username    string
+	Department  *store.Department
+	Users       []store.User       // Candidates for department head and new members
+	Members     []store.User       // Current members (edit only)
+	Departments []store.Department // Move targets for members (edit only)
+	IsEdit      bool
+}
+
+type SupervisorDashboardData struct {
+	Username string
+	Role     string
+	Staff    []store.User
+	Cycles   []store.ReviewCycle // For the cycle filter
+	Cycle    *store.ReviewCycle  // Selected cycle; nil shows all periods
+}
+
+type ObjectiveWithComments struct {
+	Objective  store.Objective
+	Outcomes   []store.ExpectedOutcome
+	Activities []store.Activity
+	Comments   []store.Comment
+}
+
+type CommentFormData struct {
+	Username  string
+	Objective *store.Objective
+	Activity  *store.Activity
+	StaffID   string
+}
+
+type ProjectListData struct {
+	User      store.User
+	Projects  []store.Project
+	CanCreate bool
+}
+
+type ProjectFormData struct {
+	User     store.User
+	Project  *store.Project
+	Managers []store.User
+	Statuses []store.ProjectStatus
+	IsEdit   bool
+}
+
+type ProjectDetailData struct {
+	User        store.User
+	Project     *store.Project
+	Assignments []store.ProjectAssignment
+	History     []store.ProjectAssignmentEvent
+	Objectives  []store.Objective
+	Tasks       []store.Task
+	Users       []store.User // Candidates for assignment
+	CanManage   bool
+}
+
+type ChangePasswordData struct {
+	User      store.User
+	Error     string
+	Success   bool
+	MinLength int
+}
+
+type SignInsData struct {
+	User    store.User
+	Account store.User // Whose sign-ins are shown
+	Events  []store.LoginEvent
+	Limit   int
+}
+
+type TokenListData struct {
+	User       store.User
+	Tokens     []store.APIToken
+	Scopes     []store.TokenScope
+	ExpiryDays []int  // Lifetimes offered for new tokens; 0 never expires
+	NewToken   string // Plaintext of a token just created, shown only once
+	Error      string
+	AllUsers   bool // Admin view of every user's tokens
+}
+
+type ReviewCycleListData struct {
+	User      store.User
+	Cycles    []store.ReviewCycle
+	CanManage bool
+}
+
+type AppraisalListData struct {
+	User       store.User
+	Appraisals []store.Appraisal   // Own appraisals
+	Team       []store.Appraisal   // Appraisals of the user's staff
+	Cycles     []store.ReviewCycle // For starting an appraisal
+	Staff      []store.User        // Staff the user can appraise; empty for staff
+}
+
+// AppraisalObjective pairs an objective in the appraised cycle with its ratings
+type AppraisalObjective struct {
+	Objective store.Objective
+	Rating    store.AppraisalRating
+}
+
+type AppraisalData struct {
+	User         store.User
+	Staff        *store.User
+	Cycle        *store.ReviewCycle
+	Appraisal    *store.Appraisal // Nil until someone saves the appraisal
+	Objectives   []AppraisalObjective
+	Scale        []RatingOption
+	OverallScore float64
+	Error        string
+
+	// What the current user may do, as the staff member or their supervisor
+	CanEditSelf       bool
+	CanEditSupervisor bool
+	CanSignStaff      bool
+	CanSignSupervisor bool
+}
+
+type ObjectiveProgressData struct {
+	User      store.User
+	Objective store.Objective
+	Points    []ProgressPoint
+	Chart     BurnUpChart
+}
+
+type AuditLogData struct {
+	User     store.User
+	Events   []store.AuditEvent
+	Entities []store.AuditEntity
+	Users    []store.User // For the actor and staff filters
+	Filter   store.AuditFilter
+	FromDate string // Filter dates as entered, YYYY-MM-DD
+	ToDate   string
+	Total    int
+	Page     int
+	PrevURL  string
+	NextURL  string
+}
+
+type AuditHistoryData struct {
+	User     store.User
+	Entity   store.AuditEntity
+	EntityID int
+	Label    string // Latest title of the record
+	Deleted  bool
+	Events   []store.AuditEvent
+}
+
+type ReviewCycleFormData struct {
+	User   store.User
+	Cycle  *store.ReviewCycle
+	Error  string
+	IsEdit bool
+}
+
+type NotificationsData struct {
+	User          store.User
+	Notifications []store.Notification
+	ShowAll       bool // Include notifications already read
+	Unread        int
+}
+
+type NotificationPreference struct {
+	Kind    store.NotificationKind
+	Enabled bool
+}
+
+type NotificationPreferencesData struct {
+	User             store.User
+	Preferences      []NotificationPreference
+	EmailFrequency   store.EmailFrequency
+	EmailFrequencies []store.EmailFrequency
+	Saved            bool
+}
+
+type OutboxData struct {
+	User   store.User
+	Emails []store.OutboxEmail
+}
+
+// JobStatus is a scheduled job with its most recent run
+type JobStatus struct {
+	Job     Job
+	LastRun *store.JobRun
+}
+
+type JobsData struct {
+	User store.User
+	Jobs []JobStatus
+	Runs []store.JobRun
+}
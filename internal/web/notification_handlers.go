@@ -0,0 +1,192 @@
+package web
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"staffperformance/internal/auth"
+	"staffperformance/internal/store"
+)
+
+// notificationsShown is how many notifications the inbox lists
+const notificationsShown = 100
+
+// Notifications handler - the user's inbox, unread first by default
+func (app *App) notificationsHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	showAll := r.URL.Query().Get("all") == "1"
+
+	notifications, err := app.store.GetNotifications(user.ID, !showAll, notificationsShown)
+	if err != nil {
+		log.Println("Error fetching notifications:", err)
+		http.Error(w, "Error loading notifications", http.StatusInternalServerError)
+		return
+	}
+	unread, err := app.store.CountUnreadNotifications(user.ID)
+	if err != nil {
+		log.Println("Error counting notifications:", err)
+	}
+
+	data := NotificationsData{
+		User:          *user,
+		Notifications: notifications,
+		ShowAll:       showAll,
+		Unread:        unread,
+	}
+
+	err = app.render(w, r, "notifications.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// Open notification handler - marks a notification read and follows its link
+func (app *App) openNotificationHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid notification ID", http.StatusBadRequest)
+		return
+	}
+	n, err := app.store.GetNotificationByID(id)
+	if err != nil || n.UserID != user.ID {
+		http.Error(w, "Notification not found", http.StatusNotFound)
+		return
+	}
+
+	if err := app.store.MarkNotificationRead(n.ID, user.ID); err != nil {
+		log.Println("Error marking notification read:", err)
+	}
+
+	link := n.Link
+	if link == "" {
+		link = "/notifications"
+	}
+	http.Redirect(w, r, link, http.StatusSeeOther)
+}
+
+// Mark read handler - marks one notification (id) or all of them (all=1) read
+func (app *App) markNotificationsReadHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var err error
+	if r.FormValue("all") == "1" {
+		err = app.store.MarkAllNotificationsRead(user.ID)
+	} else {
+		id, convErr := strconv.Atoi(r.FormValue("id"))
+		if convErr != nil {
+			http.Error(w, "Invalid notification ID", http.StatusBadRequest)
+			return
+		}
+		err = app.store.MarkNotificationRead(id, user.ID)
+	}
+	if err != nil {
+		log.Println("Error marking notifications read:", err)
+		http.Error(w, "Error updating notifications", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/notifications", http.StatusSeeOther)
+}
+
+// Notification preferences handler - which kinds of notification the user
+// receives and how often they are emailed
+func (app *App) notificationPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	saved := false
+	if r.Method == http.MethodPost {
+		prefs := make(map[store.NotificationKind]bool, len(store.NotificationKinds))
+		for _, kind := range store.NotificationKinds {
+			prefs[kind] = r.FormValue(string(kind)) == "on"
+		}
+		err := app.store.SaveNotificationPreferences(user.ID, prefs)
+		if frequency := store.EmailFrequency(r.FormValue("email_frequency")); err == nil && oneOf(frequency, emailFrequencies) {
+			err = app.store.SaveEmailFrequency(user.ID, frequency)
+		}
+		if err != nil {
+			log.Println("Error saving notification preferences:", err)
+			http.Error(w, "Error saving preferences", http.StatusInternalServerError)
+			return
+		}
+		saved = true
+	}
+
+	prefs, err := app.store.GetNotificationPreferences(user.ID)
+	if err != nil {
+		log.Println("Error fetching notification preferences:", err)
+		http.Error(w, "Error loading preferences", http.StatusInternalServerError)
+		return
+	}
+
+	emailPref, err := app.store.GetEmailPreference(user.ID)
+	if err != nil {
+		log.Println("Error fetching email preference:", err)
+		http.Error(w, "Error loading preferences", http.StatusInternalServerError)
+		return
+	}
+
+	data := NotificationPreferencesData{
+		User:             *user,
+		EmailFrequency:   emailPref.Frequency,
+		EmailFrequencies: emailFrequencies,
+		Saved:            saved,
+	}
+	for _, kind := range store.NotificationKinds {
+		data.Preferences = append(data.Preferences, NotificationPreference{Kind: kind, Enabled: prefs[kind]})
+	}
+
+	err = app.render(w, r, "notification_preferences.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// outboxShown is how many emails the outbox page lists
+const outboxShown = 200
+
+// Outbox handler - recent outgoing email and its delivery status, for admins
+func (app *App) outboxHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	emails, err := app.store.GetOutboxEmails(outboxShown)
+	if err != nil {
+		log.Println("Error fetching outbox:", err)
+		http.Error(w, "Error loading outbox", http.StatusInternalServerError)
+		return
+	}
+
+	err = app.render(w, r, "outbox.html", OutboxData{User: *user, Emails: emails})
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// Retry email handler - queues a failed email again
+func (app *App) retryEmailHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid email ID", http.StatusBadRequest)
+		return
+	}
+	if err := app.store.RetryEmail(id); err != nil {
+		log.Println("Error retrying email:", err)
+		http.Error(w, "Error retrying email", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/admin/outbox", http.StatusSeeOther)
+}
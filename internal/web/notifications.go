@@ -0,0 +1,120 @@
+package web
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"staffperformance/internal/store"
+)
+
+// notify sends a notification unless the user caused the event themselves or
+// has turned the kind off, and emails it to users who want every notification
+// by email. Failures are logged rather than failing the change that caused
+// them. An actorID of 0 is the system.
+func (app *App) notify(userID, actorID int, kind store.NotificationKind, entityID int, link, format string, args ...interface{}) {
+	if userID == actorID {
+		return
+	}
+	n := &store.Notification{
+		UserID:   userID,
+		Kind:     kind,
+		EntityID: entityID,
+		Message:  fmt.Sprintf(format, args...),
+		Link:     link,
+	}
+	if actorID != 0 {
+		n.ActorID = &actorID
+	}
+	if err := app.store.CreateNotification(n); err != nil {
+		log.Println("Error creating notification:", err)
+		return
+	}
+	if n.ID == 0 {
+		return // Turned off
+	}
+	if err := app.emailNotification(n); err != nil {
+		log.Println("Error queueing notification email:", err)
+	}
+}
+
+// displayName is how a user is named in notifications and email: their full
+// name, or their username when it is not set
+func displayName(u *store.User) string {
+	if u.FullName != "" {
+		return u.FullName
+	}
+	return u.Username
+}
+
+// notifyTaskChanges tells the people on a task what the actor changed:
+// the assignee about a new assignment, the creator about the assignee's
+// answer, and the other side about status changes. before is the task as it
+// was loaded, or a zero Task for a new one.
+func (app *App) notifyTaskChanges(actor *store.User, before store.Task, task *store.Task) {
+	if task.AssignmentStatus == store.AssignmentPending &&
+		(before.AssignmentStatus != store.AssignmentPending || !before.IsAssignedTo(*task.AssignedToID)) {
+		app.notify(*task.AssignedToID, actor.ID, store.NotifyTaskAssigned, task.ID, "/tasks?view=assigned",
+			"%s assigned you the task %q", displayName(actor), task.Title)
+	}
+
+	if before.IsAssignedTo(actor.ID) {
+		switch {
+		case task.AssignmentStatus == store.AssignmentAccepted && before.AssignmentStatus == store.AssignmentPending:
+			app.notify(task.UserID, actor.ID, store.NotifyTaskStatus, task.ID, "/tasks",
+				"%s accepted your task %q", displayName(actor), task.Title)
+		case task.AssignmentStatus == store.AssignmentDeclined:
+			app.notify(task.UserID, actor.ID, store.NotifyTaskStatus, task.ID, "/tasks",
+				"%s declined your task %q: %s", displayName(actor), task.Title, task.AssignmentNote)
+		case task.IsAssigned() && !task.IsAssignedTo(actor.ID):
+			app.notify(task.UserID, actor.ID, store.NotifyTaskStatus, task.ID, "/tasks",
+				"%s passed your task %q on to someone else", displayName(actor), task.Title)
+		}
+	}
+
+	if before.Status == "" || task.Status == before.Status {
+		return
+	}
+	if actor.ID != task.UserID {
+		app.notify(task.UserID, actor.ID, store.NotifyTaskStatus, task.ID, "/tasks",
+			"%s changed the status of %q from %s to %s", displayName(actor), task.Title, before.Status, task.Status)
+	} else if task.IsAssigned() {
+		app.notify(*task.AssignedToID, actor.ID, store.NotifyTaskStatus, task.ID, "/tasks?view=assigned",
+			"%s changed the status of %q from %s to %s", displayName(actor), task.Title, before.Status, task.Status)
+	}
+}
+
+// notifyOverdueTasks notifies a user once about each task they work on that
+// is past its due date and not completed. Moving the due date and missing it
+// again sends a new notification.
+func (app *App) notifyOverdueTasks(userID int, now time.Time) error {
+	created, err := app.store.GetTasksByUserID(userID)
+	if err != nil {
+		return err
+	}
+	assigned, err := app.store.GetTasksAssignedToUser(userID)
+	if err != nil {
+		return err
+	}
+
+	today := startOfDay(now)
+	for _, task := range append(created, assigned...) {
+		if task.WorkerID() != userID || task.Status == store.TaskStatusCompleted || !task.DueDate.Before(today) {
+			continue
+		}
+		sent, err := app.store.HasNotificationSince(userID, store.NotifyTaskOverdue, task.ID, task.DueDate)
+		if err != nil {
+			return err
+		}
+		if sent {
+			continue
+		}
+		link := "/tasks"
+		if task.UserID != userID {
+			link = "/tasks?view=assigned"
+		}
+		app.notify(userID, 0, store.NotifyTaskOverdue, task.ID, link,
+			"Task %q was due on %s", task.Title, task.DueDate.Format("Jan 02, 2006"))
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package web
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"staffperformance/internal/scoring"
+	"staffperformance/internal/store"
+)
+
+type progressKey struct {
+	entity store.AuditEntity
+	id     int
+}
+
+// progressHistory holds the snapshots of a set of records, oldest first
+type progressHistory map[progressKey][]store.ProgressSnapshot
+
+func newProgressHistory(snapshots []store.ProgressSnapshot) progressHistory {
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].RecordedAt.Before(snapshots[j].RecordedAt)
+	})
+	h := make(progressHistory)
+	for _, s := range snapshots {
+		key := progressKey{s.Entity, s.EntityID}
+		h[key] = append(h[key], s)
+	}
+	return h
+}
+
+// at returns the progress a record had just before the instant at, and
+// whether the record existed then. Records created before progress history
+// was kept have no snapshot until their first change and count as 0.
+func (h progressHistory) at(entity store.AuditEntity, id int, createdAt, at time.Time) (float64, bool) {
+	progress, exists := 0.0, createdAt.Before(at)
+	for _, s := range h[progressKey{entity, id}] {
+		if !s.RecordedAt.Before(at) {
+			break
+		}
+		progress, exists = s.Progress, true
+	}
+	return progress, exists
+}
+
+// activitiesAt returns the activities that existed just before at, with the
+// progress they had then
+func (h progressHistory) activitiesAt(activities []store.Activity, at time.Time) []store.Activity {
+	var result []store.Activity
+	for _, a := range activities {
+		if progress, ok := h.at(store.AuditActivity, a.ID, a.CreatedAt, at); ok {
+			a.ProgressPercentage = progress
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
+// scoreAt scores an objective with strategy as it stood just before at. The
+// objective's current activities and tasks are used, so records that were
+// deleted since no longer count. Manual ratings have no history; the current
+// one is used.
+func (h progressHistory) scoreAt(strategy scoring.Strategy, obj *store.Objective, activities []store.Activity, tasks []store.Task, at time.Time) float64 {
+	input := store.ScoringInputWith(obj, h.activitiesAt(activities, at), tasks, func(t store.Task) (float64, bool) {
+		return h.at(store.AuditTask, t.ID, t.CreatedAt, at)
+	})
+	return strategy.Score(input)
+}
+
+// objectiveHistory loads what is needed to score an objective at past dates
+func (app *App) objectiveHistory(obj *store.Objective) ([]store.Activity, []store.Task, progressHistory, error) {
+	activities, err := app.store.GetActivitiesByObjective(obj.ID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	tasks, err := app.store.GetTasksByObjective(obj.ID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	snapshots, err := app.store.GetObjectiveProgressSnapshots(obj.ID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return activities, tasks, newProgressHistory(snapshots), nil
+}
+
+// startOfDay is midnight UTC at the start of t's date
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// endOfDay is the instant a date ends, which is when "as of" a date is measured
+func endOfDay(date time.Time) time.Time {
+	return startOfDay(date).AddDate(0, 0, 1)
+}
+
+// ProgressPoint is one point of an objective's burn-up
+type ProgressPoint struct {
+	Date   time.Time `json:"date"`
+	Actual float64   `json:"actual"` // Objective performance at the end of the day
+	Ideal  float64   `json:"ideal"`  // Straight line from 0 at the start date to 100 at the end date
+}
+
+// BurnUp scores an objective at the end of each week from its start date
+// until today or its end date, whichever is first
+func (app *App) BurnUp(obj *store.Objective, today time.Time) ([]ProgressPoint, error) {
+	activities, tasks, history, err := app.objectiveHistory(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	last := obj.EndDate
+	if today = startOfDay(today); today.Before(last) {
+		last = today
+	}
+	var dates []time.Time
+	for d := obj.StartDate; d.Before(last); d = d.AddDate(0, 0, 7) {
+		dates = append(dates, d)
+	}
+	if !last.Before(obj.StartDate) {
+		dates = append(dates, last)
+	}
+
+	points := make([]ProgressPoint, len(dates))
+	for i, d := range dates {
+		points[i] = ProgressPoint{
+			Date:   d,
+			Actual: history.scoreAt(app.scoring, obj, activities, tasks, endOfDay(d)),
+			Ideal:  idealProgress(obj, d),
+		}
+	}
+	return points, nil
+}
+
+// idealProgress is where an objective would be on date at an even pace
+func idealProgress(obj *store.Objective, date time.Time) float64 {
+	total := obj.EndDate.Sub(obj.StartDate)
+	if total <= 0 {
+		return 100
+	}
+	elapsed := date.Sub(obj.StartDate)
+	return min(max(100*float64(elapsed)/float64(total), 0), 100)
+}
+
+// BurnUpChart holds the SVG coordinates of a burn-up, drawn in a
+// burnUpWidth by burnUpHeight box with the dates running from the objective's
+// start date to its end date
+type BurnUpChart struct {
+	Actual  string // Points of the actual line, "x,y x,y ..."
+	Ideal   string
+	Width   float64
+	Height  float64
+	ViewBox string // Leaves room for the axis labels
+}
+
+const (
+	burnUpWidth  = 600.0
+	burnUpHeight = 240.0
+)
+
+func newBurnUpChart(obj *store.Objective, points []ProgressPoint) BurnUpChart {
+	span := obj.EndDate.Sub(obj.StartDate)
+	x := func(d time.Time) float64 {
+		if span <= 0 {
+			return burnUpWidth
+		}
+		return burnUpWidth * float64(d.Sub(obj.StartDate)) / float64(span)
+	}
+	y := func(v float64) float64 { return burnUpHeight * (1 - v/100) }
+
+	var actual []string
+	for _, p := range points {
+		actual = append(actual, fmt.Sprintf("%.1f,%.1f", x(p.Date), y(p.Actual)))
+	}
+	return BurnUpChart{
+		Actual:  strings.Join(actual, " "),
+		Ideal:   fmt.Sprintf("0,%.1f %.1f,0", burnUpHeight, burnUpWidth),
+		Width:   burnUpWidth,
+		Height:  burnUpHeight,
+		ViewBox: fmt.Sprintf("-45 -10 %.0f %.0f", burnUpWidth+55, burnUpHeight+20),
+	}
+}
+
+// VelocityWeek is the progress a staff member made in one week, Monday to Sunday
+type VelocityWeek struct {
+	Start  time.Time
+	Gained float64 // Percentage points of progress gained across all records
+	Items  int     // Activities and tasks that moved forward
+}
+
+// Velocity measures progress gained per week over the weeks up to and
+// including the one containing end, oldest first. Progress that goes down is
+// not subtracted.
+func (app *App) Velocity(userID int, end time.Time, weeks int) ([]VelocityWeek, error) {
+	snapshots, err := app.store.GetUserProgressSnapshots(userID)
+	if err != nil {
+		return nil, err
+	}
+	history := newProgressHistory(snapshots)
+
+	monday := startOfDay(end)
+	monday = monday.AddDate(0, 0, -(int(monday.Weekday())+6)%7)
+	first := monday.AddDate(0, 0, -7*(weeks-1))
+
+	result := make([]VelocityWeek, weeks)
+	for i := range result {
+		result[i].Start = first.AddDate(0, 0, 7*i)
+	}
+	limit := monday.AddDate(0, 0, 7)
+
+	for _, records := range history {
+		previous := 0.0
+		advanced := make(map[int]bool)
+		for _, s := range records {
+			gained := s.Progress - previous
+			previous = s.Progress
+			if gained <= 0 || s.RecordedAt.Before(first) || !s.RecordedAt.Before(limit) {
+				continue
+			}
+			week := int(s.RecordedAt.Sub(first).Hours() / (24 * 7))
+			result[week].Gained += gained
+			if !advanced[week] {
+				advanced[week] = true
+				result[week].Items++
+			}
+		}
+	}
+	return result, nil
+}
+
+// averageVelocity is the mean progress gained per week
+func averageVelocity(weeks []VelocityWeek) float64 {
+	if len(weeks) == 0 {
+		return 0
+	}
+	var total float64
+	for _, w := range weeks {
+		total += w.Gained
+	}
+	return total / float64(len(weeks))
+}
@@ -0,0 +1,104 @@
+package web
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"staffperformance/internal/auth"
+	"staffperformance/internal/store"
+)
+
+// velocityWeeks is how many weeks of velocity the reports show
+const velocityWeeks = 8
+
+// Objective progress handler - the weekly burn-up of one objective
+func (app *App) objectiveProgressHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid objective ID", http.StatusBadRequest)
+		return
+	}
+
+	obj, err := app.store.GetObjectiveByID(id)
+	if err != nil {
+		http.Error(w, "Objective not found", http.StatusNotFound)
+		return
+	}
+	if !app.auth.Authorize(w, user, auth.ResourceObjectives, auth.ActionRead, obj.UserID) {
+		return
+	}
+
+	points, err := app.BurnUp(obj, time.Now().UTC())
+	if err != nil {
+		log.Println("Error calculating burn-up:", err)
+		http.Error(w, "Error loading progress", http.StatusInternalServerError)
+		return
+	}
+
+	data := ObjectiveProgressData{
+		User:      *user,
+		Objective: *obj,
+		Points:    points,
+		Chart:     newBurnUpChart(obj, points),
+	}
+
+	err = app.render(w, r, "objective_progress.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// parseAsOf reads the as_of report date. Today and later dates mean the
+// current figures and give nil.
+func parseAsOf(r *http.Request) *time.Time {
+	date, err := time.Parse("2006-01-02", r.URL.Query().Get("as_of"))
+	if err != nil || !endOfDay(date).Before(endOfDay(time.Now().UTC())) {
+		return nil
+	}
+	return &date
+}
+
+// objectivesAsOf turns objectives and their activities into what they were at
+// the end of date. Objectives created later are left out.
+func (app *App) objectivesAsOf(objectives []store.ObjectiveWithOutcomes, date time.Time) ([]store.ObjectiveWithOutcomes, []store.Objective, error) {
+	at := endOfDay(date)
+	var result []store.ObjectiveWithOutcomes
+	var scored []store.Objective
+	for _, item := range objectives {
+		obj := item.Objective
+		if !obj.CreatedAt.Before(at) {
+			continue
+		}
+		// The hierarchy already holds the objective's activities and tasks
+		var activities []store.Activity
+		var tasks []store.Task
+		for _, outcome := range item.ExpectedOutcomes {
+			activities = append(activities, outcome.Activities...)
+			tasks = append(tasks, outcome.Tasks...)
+		}
+		snapshots, err := app.store.GetObjectiveProgressSnapshots(obj.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		history := newProgressHistory(snapshots)
+		obj.Performance = history.scoreAt(app.scoring, &obj, activities, tasks, at)
+
+		var outcomes []store.ExpectedOutcomeWithActivities
+		for _, outcome := range item.ExpectedOutcomes {
+			if !outcome.ExpectedOutcome.CreatedAt.Before(at) {
+				continue
+			}
+			outcome.Activities = history.activitiesAt(outcome.Activities, at)
+			outcomes = append(outcomes, outcome)
+		}
+
+		result = append(result, store.ObjectiveWithOutcomes{Objective: obj, ExpectedOutcomes: outcomes})
+		scored = append(scored, obj)
+	}
+	return result, scored, nil
+}
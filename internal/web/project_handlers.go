@@ -0,0 +1,397 @@
+package web
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"staffperformance/internal/auth"
+	"staffperformance/internal/store"
+)
+
+// canManageProject reports whether a user may edit a project and its team
+func canManageProject(user *store.User, proj *store.Project) bool {
+	if user.Role == store.RoleAdmin {
+		return true
+	}
+	return proj.ManagerID != nil && *proj.ManagerID == user.ID
+}
+
+// canViewProject reports whether a user may see a project's details
+func (app *App) canViewProject(user *store.User, proj *store.Project) bool {
+	if canManageProject(user, proj) || user.Role == store.RoleSupervisor {
+		return true
+	}
+	assigned, err := app.store.IsUserAssignedToProject(proj.ID, user.ID)
+	return err == nil && assigned
+}
+
+// userProjectID parses an optional project ID from a form value, accepting
+// it only if the user manages or is assigned to that project
+func (app *App) userProjectID(userID int, value string) *int {
+	projectID := parseOptionalID(value)
+	if projectID == nil {
+		return nil
+	}
+	projects, err := app.store.GetProjectsForUser(userID)
+	if err != nil {
+		log.Println("Error fetching projects:", err)
+		return nil
+	}
+	for _, proj := range projects {
+		if proj.ID == *projectID {
+			return projectID
+		}
+	}
+	return nil
+}
+
+// loadProjectProgress fills in the rolled-up progress of a project
+func (app *App) loadProjectProgress(proj *store.Project) ([]store.Objective, []store.Task) {
+	objectives, err := app.store.GetObjectivesByProject(proj.ID)
+	if err != nil {
+		log.Println("Error fetching project objectives:", err)
+	}
+	tasks, err := app.store.GetTasksByProject(proj.ID)
+	if err != nil {
+		log.Println("Error fetching project tasks:", err)
+	}
+	proj.Progress = store.CalculateProjectProgress(objectives, tasks)
+	return objectives, tasks
+}
+
+// Project list handler - supervisors and admins see every project, staff see their own
+func (app *App) projectListHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	var projects []store.Project
+	var err error
+	if user.Role == store.RoleAdmin || user.Role == store.RoleSupervisor {
+		projects, err = app.store.GetAllProjects()
+	} else {
+		projects, err = app.store.GetProjectsForUser(user.ID)
+	}
+	if err != nil {
+		log.Println("Error fetching projects:", err)
+		http.Error(w, "Error loading projects", http.StatusInternalServerError)
+		return
+	}
+
+	for i := range projects {
+		app.loadProjectProgress(&projects[i])
+	}
+
+	data := ProjectListData{
+		User:      *user,
+		Projects:  projects,
+		CanCreate: user.Role == store.RoleAdmin || user.Role == store.RoleSupervisor,
+	}
+
+	err = app.render(w, r, "projects.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// parseProjectForm reads the shared project form fields into proj
+func parseProjectForm(r *http.Request, proj *store.Project) {
+	proj.Name = strings.TrimSpace(r.FormValue("name"))
+	proj.Description = r.FormValue("description")
+	proj.StartDate, _ = time.Parse("2006-01-02", r.FormValue("start_date"))
+	proj.EndDate, _ = time.Parse("2006-01-02", r.FormValue("end_date"))
+	proj.Status = store.ProjectStatus(r.FormValue("status"))
+	if proj.Status == "" {
+		proj.Status = store.ProjectStatusActive
+	}
+}
+
+// projectFormData builds the view model for the project form
+func (app *App) projectFormData(user *store.User, proj *store.Project, isEdit bool) (ProjectFormData, error) {
+	supervisors, err := app.store.GetUsersByRole(store.RoleSupervisor)
+	if err != nil {
+		return ProjectFormData{}, err
+	}
+	admins, err := app.store.GetUsersByRole(store.RoleAdmin)
+	if err != nil {
+		return ProjectFormData{}, err
+	}
+
+	return ProjectFormData{
+		User:     *user,
+		Project:  proj,
+		Managers: append(supervisors, admins...),
+		Statuses: []store.ProjectStatus{store.ProjectStatusPlanned, store.ProjectStatusActive, store.ProjectStatusOnHold, store.ProjectStatusCompleted},
+		IsEdit:   isEdit,
+	}, nil
+}
+
+// New project handler - supervisors and admins can start projects
+func (app *App) newProjectHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	if r.Method == http.MethodPost {
+		proj := &store.Project{}
+		parseProjectForm(r, proj)
+		if proj.Name == "" {
+			http.Error(w, "Project name is required", http.StatusBadRequest)
+			return
+		}
+
+		// Only admins may hand a project to another manager
+		proj.ManagerID = &user.ID
+		if user.Role == store.RoleAdmin {
+			if managerID := parseOptionalID(r.FormValue("manager_id")); managerID != nil {
+				proj.ManagerID = managerID
+			}
+		}
+
+		if err := app.store.CreateProject(proj); err != nil {
+			log.Println("Error creating project:", err)
+			http.Error(w, "Error creating project", http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/projects/view?id="+strconv.Itoa(proj.ID), http.StatusSeeOther)
+		return
+	}
+
+	data, err := app.projectFormData(user, nil, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = app.render(w, r, "project_form.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// Edit project handler - restricted to the project manager and admins
+func (app *App) editProjectHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		return
+	}
+
+	proj, err := app.store.GetProjectByID(id)
+	if err != nil {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+
+	if !canManageProject(user, proj) {
+		http.Error(w, "Unauthorized", http.StatusForbidden)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		parseProjectForm(r, proj)
+		if proj.Name == "" {
+			http.Error(w, "Project name is required", http.StatusBadRequest)
+			return
+		}
+		if user.Role == store.RoleAdmin {
+			proj.ManagerID = parseOptionalID(r.FormValue("manager_id"))
+		}
+
+		if err := app.store.UpdateProject(proj); err != nil {
+			log.Println("Error updating project:", err)
+			http.Error(w, "Error updating project", http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/projects/view?id="+strconv.Itoa(proj.ID), http.StatusSeeOther)
+		return
+	}
+
+	data, err := app.projectFormData(user, proj, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = app.render(w, r, "project_form.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// Delete project handler - restricted to the project manager and admins
+func (app *App) deleteProjectHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		return
+	}
+
+	proj, err := app.store.GetProjectByID(id)
+	if err != nil {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+
+	if !canManageProject(user, proj) {
+		http.Error(w, "Unauthorized", http.StatusForbidden)
+		return
+	}
+
+	if err := app.store.DeleteProject(id); err != nil {
+		log.Println("Error deleting project:", err)
+		http.Error(w, "Error deleting project", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/projects", http.StatusSeeOther)
+}
+
+// View project handler - team, assignment history and rolled-up progress
+func (app *App) viewProjectHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		return
+	}
+
+	proj, err := app.store.GetProjectByID(id)
+	if err != nil {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+
+	if !app.canViewProject(user, proj) {
+		http.Error(w, "Unauthorized", http.StatusForbidden)
+		return
+	}
+
+	objectives, tasks := app.loadProjectProgress(proj)
+
+	assignments, err := app.store.GetProjectAssignments(proj.ID)
+	if err != nil {
+		log.Println("Error fetching project assignments:", err)
+	}
+
+	history, err := app.store.GetProjectAssignmentHistory(proj.ID)
+	if err != nil {
+		log.Println("Error fetching assignment history:", err)
+	}
+
+	data := ProjectDetailData{
+		User:        *user,
+		Project:     proj,
+		Assignments: assignments,
+		History:     history,
+		Objectives:  objectives,
+		Tasks:       tasks,
+		CanManage:   canManageProject(user, proj),
+	}
+
+	if data.CanManage {
+		data.Users, err = app.store.GetAllUsers()
+		if err != nil {
+			log.Println("Error fetching users:", err)
+		}
+	}
+
+	err = app.render(w, r, "project_detail.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// Assign project member handler - adds a user to the team or changes their role
+func (app *App) assignProjectMemberHandler(w http.ResponseWriter, r *http.Request) {
+	proj, user, ok := app.projectForMemberChange(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := strconv.Atoi(r.FormValue("user_id"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	if _, err := app.store.GetUserByID(userID); err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	role := strings.TrimSpace(r.FormValue("role"))
+	if err := app.store.AssignUserToProject(proj.ID, userID, role, user.ID); err != nil {
+		log.Println("Error assigning user to project:", err)
+		http.Error(w, "Error assigning user to project", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/projects/view?id="+strconv.Itoa(proj.ID), http.StatusSeeOther)
+}
+
+// Unassign project member handler - removes a user from the team
+func (app *App) unassignProjectMemberHandler(w http.ResponseWriter, r *http.Request) {
+	proj, user, ok := app.projectForMemberChange(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := strconv.Atoi(r.FormValue("user_id"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := app.store.RemoveUserFromProject(proj.ID, userID, user.ID); err != nil {
+		log.Println("Error removing user from project:", err)
+		http.Error(w, "Error removing user from project", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/projects/view?id="+strconv.Itoa(proj.ID), http.StatusSeeOther)
+}
+
+// projectForMemberChange loads the project named in a team change form and
+// checks the current user may manage it, writing an error response if not
+func (app *App) projectForMemberChange(w http.ResponseWriter, r *http.Request) (*store.Project, *store.User, bool) {
+	user := auth.CurrentUser(r)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return nil, nil, false
+	}
+
+	projectID, err := strconv.Atoi(r.FormValue("project_id"))
+	if err != nil {
+		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		return nil, nil, false
+	}
+
+	proj, err := app.store.GetProjectByID(projectID)
+	if err != nil {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	if !canManageProject(user, proj) {
+		http.Error(w, "Unauthorized", http.StatusForbidden)
+		return nil, nil, false
+	}
+
+	return proj, user, true
+}
@@ -0,0 +1,95 @@
+package web
+
+import (
+	"fmt"
+	"time"
+
+	"staffperformance/internal/store"
+)
+
+// Recurring work. An activity's category is how often it is carried out, so
+// each period (day, week, month and so on) the owner of the objective gets a
+// task for it, due at the end of the period. Tasks are only generated while
+// the objective is running, is not complete and is not locked, and while the
+// activity is below 100%.
+
+// activityPeriod returns the calendar period a date falls in for an activity
+// category: its first day, its last day and a label for task titles. Weeks
+// start on Monday; biannual periods are January-June and July-December.
+func activityPeriod(category store.ActivityCategory, date time.Time) (start, end time.Time, label string, ok bool) {
+	day := startOfDay(date)
+	y, m, _ := day.Date()
+
+	switch category {
+	case store.CategoryDaily:
+		return day, day, day.Format("Jan 02, 2006"), true
+	case store.CategoryWeekly:
+		start = day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+		return start, start.AddDate(0, 0, 6), "week of " + start.Format("Jan 02, 2006"), true
+	case store.CategoryMonthly:
+		start = time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, -1), start.Format("January 2006"), true
+	case store.CategoryQuarterly:
+		q := (int(m) - 1) / 3
+		start = time.Date(y, time.Month(q*3+1), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 3, -1), fmt.Sprintf("Q%d %d", q+1, y), true
+	case store.CategoryBiannually:
+		h := (int(m) - 1) / 6
+		start = time.Date(y, time.Month(h*6+1), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 6, -1), fmt.Sprintf("H%d %d", h+1, y), true
+	case store.CategoryAnnually:
+		start = time.Date(y, time.January, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(1, 0, -1), fmt.Sprintf("%d", y), true
+	}
+	return time.Time{}, time.Time{}, "", false
+}
+
+// generateRecurringTasks creates the current period's task for each active
+// activity that does not have one yet. Each period is claimed before its task
+// is created, so a task the owner deletes is not created again.
+func (app *App) generateRecurringTasks(now time.Time) (string, error) {
+	activities, err := app.store.GetRecurringActivities(now)
+	if err != nil {
+		return "", err
+	}
+
+	created := 0
+	for _, a := range activities {
+		start, end, label, ok := activityPeriod(a.Category, now)
+		if !ok {
+			continue
+		}
+		claimed, err := app.store.ClaimRecurringPeriod(a.ID, start)
+		if err != nil {
+			return "", err
+		}
+		if !claimed {
+			continue
+		}
+
+		outcomeID := a.ExpectedOutcomeID
+		task := &store.Task{
+			ExpectedOutcomeID: &outcomeID,
+			UserID:            a.UserID,
+			Title:             a.Title + " - " + label,
+			Description:       a.Description,
+			Priority:          store.PriorityMedium,
+			Status:            store.TaskStatusPending,
+			TaskType:          store.TaskTypePersonal,
+			RequestedBy:       string(a.Category) + " activity",
+			DueDate:           end,
+			ProjectID:         a.ProjectID,
+		}
+		if err := app.store.CreateTask(task, 0); err != nil {
+			if releaseErr := app.store.ReleaseRecurringPeriod(a.ID, start); releaseErr != nil {
+				return "", releaseErr
+			}
+			return "", err
+		}
+		if err := app.store.SetRecurringTask(a.ID, start, task.ID); err != nil {
+			return "", err
+		}
+		created++
+	}
+	return fmt.Sprintf("Created %d task(s) from %d activities", created, len(activities)), nil
+}
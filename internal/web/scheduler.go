@@ -0,0 +1,212 @@
+package web
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"staffperformance/internal/store"
+)
+
+// In-process scheduler. Each job runs once a day, shortly after midnight UTC
+// or after the server starts if that day's run was missed. Every run is
+// recorded in job_runs, so a restart does not repeat a job that already ran
+// that day, and admins can see the history and run a job by hand. A run that
+// failed is tried again later the same day, waiting twice as long after each
+// further failure.
+
+// Job is a piece of scheduled work. Run returns a short summary of what it
+// did for the run history.
+type Job struct {
+	Name        string
+	Description string
+	Run         func(now time.Time) (string, error)
+}
+
+// schedulerInterval is how often the scheduler checks for due jobs
+const schedulerInterval = time.Minute
+
+// maxJobRetryDelay caps the wait before a failing job is tried again
+const maxJobRetryDelay = time.Hour
+
+// jobHistoryRetention is how long job runs are kept
+const jobHistoryRetention = 90 * 24 * time.Hour
+
+// scheduledJobs lists the scheduled jobs in the order they run
+func (app *App) scheduledJobs() []Job {
+	return []Job{
+		{
+			Name:        "close_review_cycles",
+			Description: "Closes open review cycles whose end date has passed, locking their objectives",
+			Run:         app.closeEndedReviewCycles,
+		},
+		{
+			Name:        "recurring_tasks",
+			Description: "Creates this period's task for each activity, by its frequency",
+			Run:         app.generateRecurringTasks,
+		},
+		{
+			Name:        "overdue_reminders",
+			Description: "Notifies staff of tasks past their due date",
+			Run:         app.sendOverdueReminders,
+		},
+		{
+			Name:        "deadline_reminders",
+			Description: "Notifies staff of tasks due soon and review cycles about to end",
+			Run:         app.sendDeadlineReminders,
+		},
+		{
+			Name:        "email_digests",
+			Description: "Emails the daily digest to users who chose it",
+			Run:         app.sendDailyDigests,
+		},
+		{
+			Name:        "prune_login_history",
+			Description: "Deletes sign-in records older than 90 days",
+			Run:         app.pruneLoginHistory,
+		},
+		{
+			Name:        "prune_job_history",
+			Description: "Deletes job run records older than 90 days",
+			Run:         app.pruneJobHistory,
+		},
+	}
+}
+
+func (app *App) findJob(name string) *Job {
+	for i := range app.jobs {
+		if app.jobs[i].Name == name {
+			return &app.jobs[i]
+		}
+	}
+	return nil
+}
+
+// runJob runs a job and records the run. A panic in the job is recorded as a
+// failure rather than stopping the server.
+func (app *App) runJob(job *Job, trigger store.JobTrigger, now time.Time) (run *store.JobRun, err error) {
+	app.jobMu.Lock()
+	defer app.jobMu.Unlock()
+
+	run = &store.JobRun{Job: job.Name, Trigger: trigger, StartedAt: time.Now().UTC(), Status: store.JobRunning}
+	if err := app.store.CreateJobRun(run); err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+		finished := time.Now().UTC()
+		run.FinishedAt = &finished
+		run.Status = store.JobSucceeded
+		if err != nil {
+			run.Status = store.JobFailed
+			run.Message = err.Error()
+			log.Printf("Job %s failed: %v", job.Name, err)
+		}
+		if saveErr := app.store.FinishJobRun(run); saveErr != nil {
+			log.Println("Error recording job run:", saveErr)
+		}
+	}()
+
+	run.Message, err = job.Run(now)
+	return run, err
+}
+
+// jobRetryDelay is how long to wait after a job's failed runs before trying
+// it again: schedulerInterval after the first failure, doubling with each
+// further one up to maxJobRetryDelay
+func jobRetryDelay(failures int) time.Duration {
+	delay := schedulerInterval
+	for i := 1; i < failures && delay < maxJobRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxJobRetryDelay)
+}
+
+// runDueJobs runs every job that has not run successfully yet on now's date
+// and is not waiting to retry a failure
+func (app *App) runDueJobs(now time.Time) {
+	today := startOfDay(now)
+	for i := range app.jobs {
+		runs, err := app.store.GetJobRunsSince(app.jobs[i].Name, today)
+		if err != nil {
+			log.Println("Error checking job runs:", err)
+			return
+		}
+		if !jobDue(runs, now) {
+			continue
+		}
+		app.runJob(&app.jobs[i], store.TriggerSchedule, now)
+	}
+}
+
+// jobDue reports whether a job whose runs today are runs, newest first, should
+// run at now
+func jobDue(runs []store.JobRun, now time.Time) bool {
+	failures := 0
+	for _, run := range runs {
+		if run.Status == store.JobSucceeded {
+			return false
+		}
+		if run.Status == store.JobFailed {
+			failures++
+		}
+	}
+	return failures == 0 || !now.Before(runs[0].StartedAt.Add(jobRetryDelay(failures)))
+}
+
+// RunScheduler runs due jobs at startup and then every schedulerInterval
+func (app *App) RunScheduler() {
+	for {
+		app.runDueJobs(time.Now().UTC())
+		time.Sleep(schedulerInterval)
+	}
+}
+
+// closeEndedReviewCycles closes the open review cycles whose end date is
+// before now's date
+func (app *App) closeEndedReviewCycles(now time.Time) (string, error) {
+	cycles, err := app.store.GetAllReviewCycles()
+	if err != nil {
+		return "", err
+	}
+
+	today := startOfDay(now)
+	closed := 0
+	for _, cycle := range cycles {
+		if cycle.Status != store.CycleStatusOpen || !cycle.EndDate.Before(today) {
+			continue
+		}
+		if err := app.store.SetReviewCycleStatus(cycle.ID, store.CycleStatusClosed); err != nil {
+			return "", err
+		}
+		log.Printf("Review cycle %q closed on its end date", cycle.Name)
+		closed++
+	}
+	return fmt.Sprintf("Closed %d review cycle(s)", closed), nil
+}
+
+// sendOverdueReminders notifies every user of their overdue tasks
+func (app *App) sendOverdueReminders(now time.Time) (string, error) {
+	users, err := app.store.GetAllUsers()
+	if err != nil {
+		return "", err
+	}
+	for _, u := range users {
+		if err := app.notifyOverdueTasks(u.ID, now); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("Checked tasks of %d user(s)", len(users)), nil
+}
+
+// pruneJobHistory deletes job runs older than jobHistoryRetention
+func (app *App) pruneJobHistory(now time.Time) (string, error) {
+	n, err := app.store.DeleteJobRunsBefore(now.Add(-jobHistoryRetention))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Deleted %d job run record(s)", n), nil
+}
@@ -0,0 +1,153 @@
+package web
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"staffperformance/internal/auth"
+	"staffperformance/internal/scoring"
+	"staffperformance/internal/store"
+)
+
+// manualRatingEnabled reports whether scores come from ratings entered on
+// objectives, in which case supervisors are asked for one
+func (app *App) manualRatingEnabled() bool {
+	return app.scoring.Name() == scoring.NameManualRating
+}
+
+// canRate reports whether the user may set the manual rating of an objective
+// owned by ownerID. Ratings come from the owner's supervisor or an admin;
+// nobody rates their own objectives.
+func (app *App) canRate(user *store.User, ownerID int) bool {
+	return user != nil && user.ID != ownerID &&
+		app.auth.CanAccess(user, auth.ResourceRatings, auth.ActionWrite, ownerID)
+}
+
+// validRating reports whether a manual rating is within 0-100, rejecting NaN
+func validRating(rating float64) bool {
+	return rating >= 0 && rating <= 100
+}
+
+// parseManualRating reads an optional 0-100 rating from a form value; empty
+// clears the rating
+func parseManualRating(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	rating, err := strconv.ParseFloat(value, 64)
+	if err != nil || !validRating(rating) {
+		return nil, validationErrorf("rating must be between 0 and 100")
+	}
+	return &rating, nil
+}
+
+// validWeight reports whether an objective weight is within 0-100. NaN fails
+// both comparisons, so it is rejected too.
+func validWeight(weight float64) bool {
+	return weight >= 0 && weight <= 100
+}
+
+// parseWeight reads an objective weight from a form value; empty means 0
+func parseWeight(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	weight, err := strconv.ParseFloat(value, 64)
+	if err != nil || !validWeight(weight) {
+		return 0, validationErrorf("weight must be between 0 and 100")
+	}
+	return weight, nil
+}
+
+// WeightTarget is what the weights of a user's objectives in one review cycle
+// should add up to
+const WeightTarget = 100.0
+
+// weightTolerance absorbs rounding in weights entered with decimals
+const weightTolerance = 0.01
+
+// WeightWarning reports a review cycle whose objective weights do not add up
+// to WeightTarget
+type WeightWarning struct {
+	CycleID   int
+	CycleName string
+	Total     float64
+}
+
+// Message describes the problem for display next to the objectives
+func (w WeightWarning) Message() string {
+	if w.Total > WeightTarget {
+		return fmt.Sprintf("Objective weights in %s add up to %.1f%%, %.1f%% over %.0f%%.", w.CycleName, w.Total, w.Total-WeightTarget, WeightTarget)
+	}
+	return fmt.Sprintf("Objective weights in %s add up to %.1f%%; %.1f%% is still unassigned.", w.CycleName, w.Total, WeightTarget-w.Total)
+}
+
+// OverallScore is the weight-normalised performance of a set of objectives;
+// see scoring.Overall
+func OverallScore(objectives []store.Objective) float64 {
+	items := make([]scoring.Weighted, len(objectives))
+	for i, obj := range objectives {
+		items[i] = scoring.Weighted{Weight: obj.Weight, Score: obj.Performance}
+	}
+	return scoring.Overall(items)
+}
+
+// cycleWeightTotals sums objective weights per review cycle. Objectives
+// outside a cycle are not included.
+func cycleWeightTotals(objectives []store.Objective) map[int]float64 {
+	totals := make(map[int]float64)
+	for _, obj := range objectives {
+		if obj.ReviewCycleID != nil {
+			totals[*obj.ReviewCycleID] += obj.Weight
+		}
+	}
+	return totals
+}
+
+// WeightWarnings lists the review cycles in which a user's objective weights
+// do not add up to WeightTarget, in the order the cycles first appear
+func WeightWarnings(objectives []store.Objective) []WeightWarning {
+	totals := cycleWeightTotals(objectives)
+
+	var warnings []WeightWarning
+	seen := make(map[int]bool)
+	for _, obj := range objectives {
+		if obj.ReviewCycleID == nil || seen[*obj.ReviewCycleID] {
+			continue
+		}
+		id := *obj.ReviewCycleID
+		seen[id] = true
+		if total := totals[id]; math.Abs(total-WeightTarget) > weightTolerance {
+			warnings = append(warnings, WeightWarning{CycleID: id, CycleName: obj.CycleName, Total: total})
+		}
+	}
+	return warnings
+}
+
+// checkCycleWeight returns an error when saving obj would push the weights of
+// its owner's objectives in the same review cycle over WeightTarget. Totals
+// below the target are allowed while objectives are still being added; they
+// are reported by WeightWarnings instead.
+func (app *App) checkCycleWeight(obj *store.Objective) error {
+	if obj.ReviewCycleID == nil {
+		return nil
+	}
+
+	objectives, err := app.store.GetObjectivesByUserIDInCycle(obj.UserID, *obj.ReviewCycleID)
+	if err != nil {
+		return err
+	}
+
+	total := obj.Weight
+	for _, other := range objectives {
+		if other.ID != obj.ID {
+			total += other.Weight
+		}
+	}
+	if total > WeightTarget+weightTolerance {
+		return validationErrorf("objective weights in a review cycle cannot add up to more than %.0f%%; this would make them %.1f%% (%.1f%% available)",
+			WeightTarget, total, WeightTarget-(total-obj.Weight))
+	}
+	return nil
+}
@@ -0,0 +1,353 @@
+package web
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"staffperformance/internal/auth"
+	"staffperformance/internal/store"
+)
+
+// Staff list handler - display all staff members
+func (app *App) staffListHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := auth.CurrentUser(r)
+
+	staff, err := app.store.GetAllUsers()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Load supervisor names for each staff member
+	for i := range staff {
+		if staff[i].SupervisorID != nil {
+			supervisor, err := app.store.GetUserByID(*staff[i].SupervisorID)
+			if err == nil {
+				staff[i].SupervisorName = supervisor.Username
+			}
+		}
+	}
+
+	data := StaffListData{
+		Username: currentUser.Username,
+		Staff:    staff,
+	}
+
+	err = app.render(w, r, "staff_list.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// New staff handler - show staff registration form
+func (app *App) newStaffHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := auth.CurrentUser(r)
+
+	if r.Method == "POST" {
+		username := r.FormValue("username")
+		password := r.FormValue("password")
+		role := r.FormValue("role")
+		departmentID := parseOptionalID(r.FormValue("department_id"))
+		position := r.FormValue("position")
+		supervisorIDStr := r.FormValue("supervisor_id")
+
+		var supervisorID *int
+		if supervisorIDStr != "" {
+			id, err := strconv.Atoi(supervisorIDStr)
+			if err == nil {
+				supervisorID = &id
+			}
+		}
+
+		// Keep the deprecated free-text department in sync with the selection
+		department := ""
+		if departmentID != nil {
+			if dept, err := app.store.GetDepartmentByID(*departmentID); err == nil {
+				department = dept.Name
+			} else {
+				departmentID = nil
+			}
+		}
+
+		hash, err := auth.HashPassword(password)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		user := &store.User{
+			Username:     username,
+			Password:     hash,
+			Role:         store.UserRole(role),
+			DepartmentID: departmentID,
+			Department:   department,
+			Position:     position,
+			SupervisorID: supervisorID,
+			CreatedAt:    time.Now(),
+		}
+
+		if err := app.store.CreateUser(user); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/staff", http.StatusSeeOther)
+		return
+	}
+
+	// Get supervisors and admins for dropdown
+	supervisors, err := app.store.GetUsersByRole(store.RoleSupervisor)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	admins, err := app.store.GetUsersByRole(store.RoleAdmin)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	allSupervisors := append(supervisors, admins...)
+
+	departments, err := app.store.GetAllDepartments()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := StaffFormData{
+		Username:    currentUser.Username,
+		IsEdit:      false,
+		Supervisors: allSupervisors,
+		Departments: departments,
+	}
+
+	if err := app.render(w, r, "staff_form.html", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Edit staff handler - modify existing staff member
+func (app *App) editStaffHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := auth.CurrentUser(r)
+
+	staffIDStr := r.URL.Query().Get("id")
+	staffID, err := strconv.Atoi(staffIDStr)
+	if err != nil {
+		http.Error(w, "Invalid staff ID", http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == "POST" {
+		username := r.FormValue("username")
+		password := r.FormValue("password")
+		role := r.FormValue("role")
+		departmentID := parseOptionalID(r.FormValue("department_id"))
+		position := r.FormValue("position")
+		supervisorIDStr := r.FormValue("supervisor_id")
+
+		var supervisorID *int
+		if supervisorIDStr != "" {
+			id, err := strconv.Atoi(supervisorIDStr)
+			if err == nil {
+				supervisorID = &id
+			}
+		}
+
+		// Keep the deprecated free-text department in sync with the selection
+		department := ""
+		if departmentID != nil {
+			if dept, err := app.store.GetDepartmentByID(*departmentID); err == nil {
+				department = dept.Name
+			} else {
+				departmentID = nil
+			}
+		}
+
+		user := &store.User{
+			ID:           staffID,
+			Username:     username,
+			Role:         store.UserRole(role),
+			DepartmentID: departmentID,
+			Department:   department,
+			Position:     position,
+			SupervisorID: supervisorID,
+		}
+
+		// Only update password if provided
+		if password != "" {
+			if user.Password, err = auth.HashPassword(password); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		if err := app.store.UpdateUser(user); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/staff", http.StatusSeeOther)
+		return
+	}
+
+	// Get staff member details
+	staff, err := app.store.GetUserByID(staffID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Get supervisors and admins for dropdown
+	supervisors, err := app.store.GetUsersByRole(store.RoleSupervisor)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	admins, err := app.store.GetUsersByRole(store.RoleAdmin)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	allSupervisors := append(supervisors, admins...)
+
+	departments, err := app.store.GetAllDepartments()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := StaffFormData{
+		Username:    currentUser.Username,
+		IsEdit:      true,
+		Staff:       staff,
+		Supervisors: allSupervisors,
+		Departments: departments,
+	}
+
+	if err := app.render(w, r, "staff_form.html", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Delete staff handler
+func (app *App) deleteStaffHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := auth.CurrentUser(r)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	staffIDStr := r.FormValue("id")
+	staffID, err := strconv.Atoi(staffIDStr)
+	if err != nil {
+		http.Error(w, "Invalid staff ID", http.StatusBadRequest)
+		return
+	}
+
+	// Prevent deleting self
+	if staffID == currentUser.ID {
+		http.Error(w, "Cannot delete your own account", http.StatusBadRequest)
+		return
+	}
+
+	if err := app.store.DeleteUser(staffID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/staff", http.StatusSeeOther)
+}
+
+// Unlock staff handler - lifts the lock placed on an account after too many
+// failed sign-ins
+func (app *App) unlockStaffHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := auth.CurrentUser(r)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	staffID, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid staff ID", http.StatusBadRequest)
+		return
+	}
+
+	staff, err := app.store.GetUserByID(staffID)
+	if err != nil {
+		http.Error(w, "Staff member not found", http.StatusNotFound)
+		return
+	}
+
+	// Ending the lock now, rather than clearing it, also stops the failures
+	// that led to it from slowing down the next sign-in
+	if err := app.store.LockUser(staff.ID, time.Now()); err != nil {
+		log.Println("Error unlocking user:", err)
+		http.Error(w, "Error unlocking account", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("User %s unlocked by %s", staff.Username, currentUser.Username)
+	http.Redirect(w, r, "/staff", http.StatusSeeOther)
+}
+
+// Public registration handler - for staff self-registration
+func (app *App) registrationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" {
+		username := r.FormValue("username")
+		password := r.FormValue("password")
+		departmentID := parseOptionalID(r.FormValue("department_id"))
+		position := r.FormValue("position")
+
+		hash, err := auth.HashPassword(password)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		user := &store.User{
+			Username:  username,
+			Password:  hash,
+			Role:      store.RoleStaff, // Default role for self-registration
+			Position:  position,
+			CreatedAt: time.Now(),
+		}
+		if departmentID != nil {
+			if dept, err := app.store.GetDepartmentByID(*departmentID); err == nil {
+				user.DepartmentID = &dept.ID
+				user.Department = dept.Name
+			}
+		}
+
+		if err := app.store.CreateUser(user); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// Auto-login after registration
+		app.auth.SetSession(w, r, user)
+		http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+		return
+	}
+
+	departments, err := app.store.GetAllDepartments()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = app.render(w, r, "registration.html", struct{ Departments []store.Department }{departments})
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
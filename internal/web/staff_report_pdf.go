@@ -0,0 +1,258 @@
+package web
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"staffperformance/internal/auth"
+	"staffperformance/internal/pdf"
+	"staffperformance/internal/store"
+)
+
+// Staff report PDF handler - the formal performance report of one staff
+// member for the appraisal file, for a review cycle or for all periods
+func (app *App) staffReportPDFHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := auth.CurrentUser(r)
+
+	staffID, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid staff ID", http.StatusBadRequest)
+		return
+	}
+	staff, err := app.store.GetUserByID(staffID)
+	if err != nil {
+		http.Error(w, "Staff member not found", http.StatusNotFound)
+		return
+	}
+
+	// Supervisors may only print their own staff
+	if !app.auth.Authorize(w, currentUser, auth.ResourceStaff, auth.ActionRead, staff.ID) {
+		return
+	}
+
+	cycle, _, err := app.selectedCycle(r)
+	if err != nil {
+		log.Println("Error fetching review cycles:", err)
+		http.Error(w, "Error creating report", http.StatusInternalServerError)
+		return
+	}
+
+	doc, err := app.staffReportPDF(staff, cycle, time.Now())
+	if err != nil {
+		log.Println("Error creating staff report PDF:", err)
+		http.Error(w, "Error creating report", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := doc.Write(&buf); err != nil {
+		log.Println("Error writing staff report PDF:", err)
+		http.Error(w, "Error creating report", http.StatusInternalServerError)
+		return
+	}
+
+	period := "all-periods"
+	if cycle != nil {
+		period = fmt.Sprintf("cycle-%d", cycle.ID)
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="performance-report-%s-%s.pdf"`, staff.Username, period))
+	w.Write(buf.Bytes())
+}
+
+// staffReportPDF lays out a staff member's performance report: the cycle
+// header, staff details, a summary, each objective with its outcomes,
+// activities, tasks and supervisor comments, all tasks, and a signature block
+func (app *App) staffReportPDF(staff *store.User, cycle *store.ReviewCycle, now time.Time) (*pdf.Document, error) {
+	hierarchy, err := app.hierarchyForCycle(staff.ID, cycle)
+	if err != nil {
+		return nil, err
+	}
+	objectives := hierarchyObjectives(hierarchy)
+	tasks, err := app.store.GetTasksByUserID(staff.ID)
+	if err != nil {
+		return nil, err
+	}
+	tasks = tasksInCycle(tasks, cycle)
+	comments, err := app.store.GetObjectiveCommentsByUserID(staff.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var appraisal *store.Appraisal
+	if cycle != nil {
+		appraisal, err = app.store.GetAppraisal(staff.ID, cycle.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			appraisal, err = nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	supervisorName := "None"
+	if staff.SupervisorID != nil {
+		if supervisor, err := app.store.GetUserByID(*staff.SupervisorID); err == nil {
+			supervisorName = displayName(supervisor)
+		}
+	}
+
+	period := "All periods"
+	if cycle != nil {
+		period = fmt.Sprintf("%s (%s to %s, %s)", cycle.Name,
+			cycle.StartDate.Format("Jan 02, 2006"), cycle.EndDate.Format("Jan 02, 2006"), cycle.Status)
+	}
+
+	doc := pdf.New("Performance Report - "+displayName(staff),
+		fmt.Sprintf("Performance report of %s - %s - generated %s", displayName(staff), period, now.Format("Jan 02, 2006 15:04")))
+
+	// Header
+	doc.Paragraph(pdf.Bold, 18, "Staff Performance Report")
+	doc.Paragraph(pdf.Regular, 11, "Review cycle: "+period)
+	doc.Rule()
+
+	doc.Label(10, "Name:", displayName(staff))
+	doc.Label(10, "Username:", staff.Username)
+	doc.Label(10, "Position:", orNone(staff.Position))
+	doc.Label(10, "Department:", orNone(staff.DepartmentName))
+	doc.Label(10, "Supervisor:", supervisorName)
+
+	// Summary
+	completed := 0
+	for _, t := range tasks {
+		if t.Status == store.TaskStatusCompleted {
+			completed++
+		}
+	}
+	totalWeight := 0.0
+	for _, obj := range objectives {
+		totalWeight += obj.Weight
+	}
+	doc.Heading("Summary", 13)
+	doc.Label(10, "Overall score:", fmt.Sprintf("%.1f%%", OverallScore(objectives)))
+	doc.Label(10, "Objectives:", fmt.Sprintf("%d, with weights totalling %.0f%%", len(objectives), totalWeight))
+	doc.Label(10, "Tasks:", fmt.Sprintf("%d completed of %d", completed, len(tasks)))
+	if appraisal != nil {
+		doc.Label(10, "Final rating:", app.ratings.Label(appraisal.FinalRating))
+		doc.Label(10, "Appraisal:", appraisal.Status())
+	}
+
+	// Objectives
+	tasksByOutcome := make(map[int][]store.Task)
+	for _, t := range tasks {
+		if t.ExpectedOutcomeID != nil {
+			tasksByOutcome[*t.ExpectedOutcomeID] = append(tasksByOutcome[*t.ExpectedOutcomeID], t)
+		}
+	}
+
+	doc.Heading("Objectives", 13)
+	if len(objectives) == 0 {
+		doc.Paragraph(pdf.Regular, 10, "No objectives in this period.")
+	}
+	for i, item := range hierarchy {
+		writeObjectivePDF(doc, i+1, item, tasksByOutcome, comments[item.Objective.ID])
+	}
+
+	// Tasks
+	doc.Heading("Tasks", 13)
+	if len(tasks) == 0 {
+		doc.Paragraph(pdf.Regular, 10, "No tasks in this period.")
+	} else {
+		var rows [][]string
+		for _, t := range tasks {
+			rows = append(rows, []string{t.Title, string(t.TaskType), string(t.Priority), string(t.Status),
+				t.DueDate.Format("Jan 02, 2006"), fmt.Sprintf("%.0f%%", store.TaskProgress(t))})
+		}
+		doc.Table(9, []float64{0.36, 0.14, 0.1, 0.14, 0.16, 0.1}, []string{"Task", "Type", "Priority", "Status", "Due", "Progress"}, rows)
+	}
+
+	writeSignatureBlockPDF(doc, staff, supervisorName, appraisal)
+	return doc, nil
+}
+
+func writeObjectivePDF(doc *pdf.Document, n int, item store.ObjectiveWithOutcomes, tasksByOutcome map[int][]store.Task, comments []store.Comment) {
+	obj := item.Objective
+
+	category := string(obj.Category)
+	if obj.Category == store.CategoryOther && obj.CategoryOther != "" {
+		category = obj.CategoryOther
+	}
+
+	doc.Heading(fmt.Sprintf("%d. %s", n, obj.Title), 11)
+	doc.Label(9, "Weight:", fmt.Sprintf("%.0f%%   Performance: %.1f%%   Status: %s   Category: %s", obj.Weight, obj.Performance, obj.Status, category))
+	doc.Label(9, "Period:", obj.StartDate.Format("Jan 02, 2006")+" to "+obj.EndDate.Format("Jan 02, 2006"))
+	if obj.Description != "" {
+		doc.Paragraph(pdf.Regular, 9, obj.Description)
+	}
+
+	for _, entry := range item.ExpectedOutcomes {
+		outcome := entry.ExpectedOutcome
+		doc.Space(4)
+		doc.Paragraph(pdf.Bold, 10, "Expected outcome: "+outcome.Title)
+
+		if len(entry.Activities) > 0 {
+			var rows [][]string
+			for _, a := range entry.Activities {
+				rows = append(rows, []string{a.Title, string(a.Category), fmt.Sprintf("%.0f%%", a.ProgressPercentage), a.ImplementationLevel})
+			}
+			doc.Table(9, []float64{0.4, 0.15, 0.12, 0.33}, []string{"Activity", "Frequency", "Progress", "Implementation level"}, rows)
+		}
+
+		if tasks := tasksByOutcome[outcome.ID]; len(tasks) > 0 {
+			var rows [][]string
+			for _, t := range tasks {
+				rows = append(rows, []string{t.Title, string(t.Status), t.DueDate.Format("Jan 02, 2006"), fmt.Sprintf("%.0f%%", store.TaskProgress(t))})
+			}
+			doc.Space(4)
+			doc.Table(9, []float64{0.5, 0.18, 0.2, 0.12}, []string{"Task", "Status", "Due", "Progress"}, rows)
+		}
+	}
+
+	if len(comments) > 0 {
+		doc.Space(4)
+		doc.Paragraph(pdf.Bold, 10, "Supervisor comments")
+		for _, c := range comments {
+			doc.Paragraph(pdf.Regular, 9, fmt.Sprintf("%s, %s: %s", c.Username, c.CreatedAt.Format("Jan 02, 2006"), c.CommentText))
+		}
+	}
+}
+
+// writeSignatureBlockPDF adds lines for the staff member and supervisor to
+// sign, noting when they signed off the appraisal online
+func writeSignatureBlockPDF(doc *pdf.Document, staff *store.User, supervisorName string, appraisal *store.Appraisal) {
+	doc.Heading("Sign-off", 13)
+	doc.Paragraph(pdf.Regular, 9, "By signing, the staff member confirms they have discussed this report with their supervisor, and the supervisor confirms the ratings and comments are theirs.")
+	if appraisal != nil && appraisal.SupervisorSignedAt != nil {
+		doc.Paragraph(pdf.Regular, 9, "Supervisor signed off the appraisal online on "+appraisal.SupervisorSignedAt.Format("Jan 02, 2006")+".")
+	}
+	if appraisal != nil && appraisal.StaffSignedAt != nil {
+		doc.Paragraph(pdf.Regular, 9, "Staff member signed off the appraisal online on "+appraisal.StaffSignedAt.Format("Jan 02, 2006")+".")
+	}
+
+	// Two columns of signature and date lines; the captions sit below them
+	const gap = 30.0
+	width := (pdf.ContentWidth - gap) / 2
+	right := pdf.Margin + width + gap
+	doc.Keep(110)
+	doc.Space(40)
+	doc.SignatureLine(pdf.Margin, width, "Staff member: "+displayName(staff))
+	doc.SignatureLine(right, width, "Supervisor: "+supervisorName)
+	doc.Space(45)
+	doc.SignatureLine(pdf.Margin, width, "Date")
+	doc.SignatureLine(right, width, "Date")
+	doc.Space(15)
+}
+
+// orNone shows an empty value as "None"
+func orNone(value string) string {
+	if value == "" {
+		return "None"
+	}
+	return value
+}
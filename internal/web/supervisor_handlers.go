@@ -0,0 +1,368 @@
+package web
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"staffperformance/internal/auth"
+	"staffperformance/internal/store"
+)
+
+// Supervisor dashboard - view all supervised staff
+func (app *App) supervisorDashboardHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := auth.CurrentUser(r)
+
+	// Get staff under this supervisor
+	var staff []store.User
+	var err error
+	if currentUser.Role == store.RoleAdmin {
+		// Admins see all staff
+		staff, err = app.store.GetAllUsers()
+	} else {
+		// Supervisors see only their staff
+		staff, err = app.store.GetStaffBySupervisor(currentUser.ID)
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	cycle, cycles, err := app.selectedCycle(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Score each staff member from their weighted objective performance
+	staffIDs := make([]int, len(staff))
+	for i := range staff {
+		staffIDs[i] = staff[i].ID
+	}
+	objectives, err := app.teamObjectivesForCycle(staffIDs, cycle)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for i := range staff {
+		staff[i].OverallPerformance = OverallScore(objectives[staff[i].ID])
+	}
+
+	data := SupervisorDashboardData{
+		Username: currentUser.Username,
+		Role:     string(currentUser.Role),
+		Staff:    staff,
+		Cycles:   cycles,
+		Cycle:    cycle,
+	}
+
+	err = app.render(w, r, "supervisor_dashboard.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// View individual staff report
+func (app *App) viewStaffReportHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := auth.CurrentUser(r)
+
+	staffIDStr := r.URL.Query().Get("id")
+	staffID, err := strconv.Atoi(staffIDStr)
+	if err != nil {
+		http.Error(w, "Invalid staff ID", http.StatusBadRequest)
+		return
+	}
+
+	staff, err := app.store.GetUserByID(staffID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Supervisors may only view their own staff
+	if !app.auth.Authorize(w, currentUser, auth.ResourceStaff, auth.ActionRead, staff.ID) {
+		return
+	}
+
+	// Objectives with their outcomes, activities and comments, read in the
+	// same few queries however many objectives there are
+	hierarchy, err := app.hierarchyForCycle(staffID, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	comments, err := app.store.GetObjectiveCommentsByUserID(staffID)
+	if err != nil {
+		comments = nil // No comments if error
+	}
+
+	var objectivesWithComments []ObjectiveWithComments
+	for _, item := range hierarchy {
+		objWithComments := ObjectiveWithComments{
+			Objective: item.Objective,
+			Comments:  comments[item.Objective.ID],
+		}
+		for _, outcome := range item.ExpectedOutcomes {
+			objWithComments.Outcomes = append(objWithComments.Outcomes, outcome.ExpectedOutcome)
+			objWithComments.Activities = append(objWithComments.Activities, outcome.Activities...)
+		}
+		objectivesWithComments = append(objectivesWithComments, objWithComments)
+	}
+
+	// Get tasks
+	tasks, err := app.store.GetTasksByUserID(staffID)
+	if err != nil {
+		tasks = []store.Task{} // Empty if error
+	}
+
+	// Appraisals, and the open or closed cycles one can still be started in
+	appraisals, err := app.store.GetAppraisalsByUserID(staffID)
+	if err != nil {
+		appraisals = []store.Appraisal{} // Empty if error
+	}
+	appraised := make(map[int]bool)
+	for _, a := range appraisals {
+		appraised[a.ReviewCycleID] = true
+	}
+	cycles, err := app.store.GetAllReviewCycles()
+	if err != nil {
+		cycles = []store.ReviewCycle{} // Empty if error
+	}
+	var appraisalCycles []store.ReviewCycle
+	if app.auth.CanAccess(currentUser, auth.ResourceAppraisals, auth.ActionWrite, staffID) {
+		for _, c := range cycles {
+			if c.Status != store.CycleStatusPlanned && !appraised[c.ID] {
+				appraisalCycles = append(appraisalCycles, c)
+			}
+		}
+	}
+
+	velocity, err := app.Velocity(staffID, time.Now().UTC(), velocityWeeks)
+	if err != nil {
+		velocity = nil // Shown as no history if error
+	}
+
+	data := struct {
+		Username        string
+		Staff           *store.User
+		Objectives      []ObjectiveWithComments
+		Tasks           []store.Task
+		Appraisals      []store.Appraisal
+		AppraisalCycles []store.ReviewCycle
+		Cycles          []store.ReviewCycle // For the PDF report
+		Velocity        []VelocityWeek
+		AverageVelocity float64
+		CanRate         bool // Manual ratings are in use and the user may set them
+	}{
+		Username:        currentUser.Username,
+		Staff:           staff,
+		Objectives:      objectivesWithComments,
+		Tasks:           tasks,
+		Appraisals:      appraisals,
+		AppraisalCycles: appraisalCycles,
+		Cycles:          cycles,
+		Velocity:        velocity,
+		AverageVelocity: averageVelocity(velocity),
+		CanRate:         app.manualRatingEnabled() && app.canRate(currentUser, staffID),
+	}
+
+	err = app.render(w, r, "staff_report.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// Add comment handler
+func (app *App) addCommentHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := auth.CurrentUser(r)
+
+	if r.Method == "POST" {
+		objectiveIDStr := r.FormValue("objective_id")
+		activityIDStr := r.FormValue("activity_id")
+		commentText := r.FormValue("comment_text")
+		staffIDStr := r.FormValue("staff_id")
+
+		objectiveID, err := strconv.Atoi(objectiveIDStr)
+		if err != nil {
+			http.Error(w, "Invalid objective ID", http.StatusBadRequest)
+			return
+		}
+
+		objective, err := app.store.GetObjectiveByID(objectiveID)
+		if err != nil {
+			http.Error(w, "Objective not found", http.StatusNotFound)
+			return
+		}
+
+		// Comments may only be added to objectives of the supervisor's staff
+		if !app.auth.Authorize(w, currentUser, auth.ResourceComments, auth.ActionWrite, objective.UserID) {
+			return
+		}
+
+		var activityID *int
+		if activityIDStr != "" {
+			id, err := strconv.Atoi(activityIDStr)
+			if err == nil {
+				activityID = &id
+			}
+		}
+
+		comment := &store.Comment{
+			ObjectiveID: &objectiveID,
+			ActivityID:  activityID,
+			UserID:      currentUser.ID,
+			CommentText: commentText,
+			CreatedAt:   time.Now(),
+		}
+
+		if err := app.store.CreateComment(comment); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		app.notify(objective.UserID, currentUser.ID, store.NotifyComment, objective.ID, "/objectives",
+			"%s commented on your objective %q: %s", displayName(currentUser), objective.Title, commentText)
+
+		// Redirect back to staff report
+		http.Redirect(w, r, "/supervisor/staff?id="+staffIDStr, http.StatusSeeOther)
+		return
+	}
+
+	// GET - show comment form
+	objectiveIDStr := r.URL.Query().Get("objective_id")
+	activityIDStr := r.URL.Query().Get("activity_id")
+	staffIDStr := r.URL.Query().Get("staff_id")
+
+	objectiveID, err := strconv.Atoi(objectiveIDStr)
+	if err != nil {
+		http.Error(w, "Invalid objective ID", http.StatusBadRequest)
+		return
+	}
+
+	objective, err := app.store.GetObjectiveByID(objectiveID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !app.auth.Authorize(w, currentUser, auth.ResourceComments, auth.ActionWrite, objective.UserID) {
+		return
+	}
+
+	var activity *store.Activity
+	if activityIDStr != "" {
+		actID, err := strconv.Atoi(activityIDStr)
+		if err == nil {
+			activity, _ = app.store.GetActivityByID(actID)
+		}
+	}
+
+	data := CommentFormData{
+		Username:  currentUser.Username,
+		Objective: objective,
+		Activity:  activity,
+		StaffID:   staffIDStr,
+	}
+
+	err = app.render(w, r, "comment_form.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// Rate objective handler. When objectives are scored by hand, the owner's
+// supervisor (or an admin) sets the rating from the staff report.
+func (app *App) rateObjectiveHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := auth.CurrentUser(r)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	objectiveID, err := strconv.Atoi(r.FormValue("objective_id"))
+	if err != nil {
+		http.Error(w, "Invalid objective ID", http.StatusBadRequest)
+		return
+	}
+
+	objective, err := app.store.GetObjectiveByID(objectiveID)
+	if err != nil {
+		http.Error(w, "Objective not found", http.StatusNotFound)
+		return
+	}
+
+	if !app.canRate(currentUser, objective.UserID) {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+	if !requireUnlocked(w, objective) {
+		return
+	}
+
+	rating, err := parseManualRating(r.FormValue("manual_rating"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	objective.ManualRating = rating
+
+	if err := app.store.UpdateObjective(objective, currentUser.ID); err != nil {
+		log.Println("Error rating objective:", err)
+		http.Error(w, "Error saving rating", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/supervisor/staff?id="+strconv.Itoa(objective.UserID), http.StatusSeeOther)
+}
+
+// Delete comment handler
+func (app *App) deleteCommentHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := auth.CurrentUser(r)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	commentIDStr := r.FormValue("id")
+	staffIDStr := r.FormValue("staff_id")
+
+	commentID, err := strconv.Atoi(commentIDStr)
+	if err != nil {
+		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+
+	comment, err := app.store.GetCommentByID(commentID)
+	if err != nil {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	}
+
+	// A comment belongs to the staff member whose objective it is on
+	if comment.ObjectiveID != nil {
+		objective, err := app.store.GetObjectiveByID(*comment.ObjectiveID)
+		if err != nil {
+			http.Error(w, "Objective not found", http.StatusNotFound)
+			return
+		}
+		if !app.auth.Authorize(w, currentUser, auth.ResourceComments, auth.ActionWrite, objective.UserID) {
+			return
+		}
+	} else if !app.auth.Authorize(w, currentUser, auth.ResourceComments, auth.ActionWrite, comment.UserID) {
+		return
+	}
+
+	if err := app.store.DeleteComment(commentID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Redirect back to staff report
+	http.Redirect(w, r, "/supervisor/staff?id="+staffIDStr, http.StatusSeeOther)
+}
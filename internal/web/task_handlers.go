@@ -0,0 +1,571 @@
+package web
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"staffperformance/internal/auth"
+	"staffperformance/internal/store"
+)
+
+// Tasks management handlers
+func (app *App) tasksHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	app.renderTasks(w, r, user, r.URL.Query().Get("view"), "", http.StatusOK)
+}
+
+// renderTasks shows the tasks the user created, or with view "assigned" the
+// tasks assigned to them, with an optional error from an assignment action
+func (app *App) renderTasks(w http.ResponseWriter, r *http.Request, user *store.User, view, errMsg string, status int) {
+	created, err := app.store.GetTasksByUserID(user.ID)
+	if err != nil {
+		log.Println("Error fetching tasks:", err)
+		http.Error(w, "Error loading tasks", http.StatusInternalServerError)
+		return
+	}
+	assigned, err := app.store.GetTasksAssignedToUser(user.ID)
+	if err != nil {
+		log.Println("Error fetching assigned tasks:", err)
+		http.Error(w, "Error loading tasks", http.StatusInternalServerError)
+		return
+	}
+
+	var assignedToMe []store.Task
+	pending := 0
+	for _, t := range assigned {
+		if t.IsAssignedTo(user.ID) {
+			assignedToMe = append(assignedToMe, t)
+			if t.AssignmentStatus == store.AssignmentPending {
+				pending++
+			}
+		}
+	}
+
+	tasks := created
+	if view == "assigned" {
+		tasks = assignedToMe
+	} else {
+		view = "created"
+	}
+
+	users, err := app.store.GetAllUsers()
+	if err != nil {
+		log.Println("Error fetching users:", err)
+	}
+	attachTaskUsers(tasks, users)
+	var colleagues []store.User
+	for _, u := range users {
+		if u.ID != user.ID {
+			colleagues = append(colleagues, u)
+		}
+	}
+
+	priorities := []store.TaskPriority{store.PriorityLow, store.PriorityMedium, store.PriorityHigh, store.PriorityUrgent}
+	statuses := []store.TaskStatus{store.TaskStatusPending, store.TaskStatusInProgress, store.TaskStatusCompleted, store.TaskStatusOnHold}
+
+	data := TaskListData{
+		User:         *user,
+		Tasks:        tasks,
+		Priorities:   priorities,
+		Statuses:     statuses,
+		View:         view,
+		PendingCount: pending,
+		Colleagues:   colleagues,
+		Error:        errMsg,
+	}
+
+	w.WriteHeader(status)
+	err = app.render(w, r, "tasks.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+	}
+}
+
+// attachTaskUsers fills in the creator and assignee of each task for display
+func attachTaskUsers(tasks []store.Task, users []store.User) {
+	byID := make(map[int]*store.User, len(users))
+	for i := range users {
+		byID[users[i].ID] = &users[i]
+	}
+	for i := range tasks {
+		tasks[i].CreatedByUser = byID[tasks[i].UserID]
+		if tasks[i].AssignedToID != nil {
+			tasks[i].AssignedToUser = byID[*tasks[i].AssignedToID]
+		}
+	}
+}
+
+func (app *App) newTaskHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	if r.Method == http.MethodPost {
+		title := r.FormValue("title")
+		description := r.FormValue("description")
+		priority := store.TaskPriority(r.FormValue("priority"))
+		status := store.TaskStatus(r.FormValue("status"))
+		dueDateStr := r.FormValue("due_date")
+		completionPercentageStr := r.FormValue("completion_percentage")
+
+		dueDate, _ := time.Parse("2006-01-02", dueDateStr)
+
+		task := &store.Task{
+			UserID:      user.ID,
+			Title:       title,
+			Description: description,
+			Priority:    priority,
+			Status:      status,
+			DueDate:     dueDate,
+			TaskType:    formTaskType(r),
+			RequestedBy: r.FormValue("requested_by"),
+			ProjectID:   app.userProjectID(user.ID, r.FormValue("project_id")),
+		}
+
+		if err := app.assignTask(user, task, parseOptionalID(r.FormValue("assigned_to_id"))); err != nil {
+			app.renderTaskForm(w, r, user, task, false, err, http.StatusBadRequest)
+			return
+		}
+
+		// Parse expected outcome ID if provided. Assigned tasks are linked
+		// by the assignee once they accept.
+		if !task.IsAssigned() {
+			var ok bool
+			if task.ExpectedOutcomeID, ok = app.formOutcomeID(w, r, user); !ok {
+				return
+			}
+		}
+		if !app.requireTaskUnlocked(w, task.ExpectedOutcomeID) {
+			return
+		}
+
+		// Parse completion percentage
+		if completionPercentageStr != "" {
+			completionPercentage, err := strconv.ParseFloat(completionPercentageStr, 64)
+			if err == nil && completionPercentage >= 0 && completionPercentage <= 100 {
+				task.CompletionPercentage = completionPercentage
+			}
+		}
+
+		// Set completed time if status is completed
+		if status == store.TaskStatusCompleted {
+			now := time.Now()
+			task.CompletedAt = &now
+			task.CompletionPercentage = 100 // Auto-set to 100% when completed
+		}
+
+		err := app.store.CreateTask(task, user.ID)
+		if err != nil {
+			log.Println("Error creating task:", err)
+			http.Error(w, "Error creating task", http.StatusInternalServerError)
+			return
+		}
+		app.notifyTaskChanges(user, store.Task{}, task)
+
+		http.Redirect(w, r, "/tasks", http.StatusSeeOther)
+		return
+	}
+
+	app.renderTaskForm(w, r, user, nil, false, nil, http.StatusOK)
+}
+
+// formTaskType reads the task type, defaulting to a personal task
+func formTaskType(r *http.Request) store.TaskType {
+	taskType := store.TaskType(r.FormValue("task_type"))
+	if !oneOf(taskType, taskTypes) {
+		return store.TaskTypePersonal
+	}
+	return taskType
+}
+
+// formOutcomeID reads the expected outcome a task form links the task to, nil
+// for none. The outcome must belong to an objective the user may change;
+// otherwise it writes a 400 or 403 response and reports false.
+func (app *App) formOutcomeID(w http.ResponseWriter, r *http.Request, user *store.User) (*int, bool) {
+	idStr := r.FormValue("expected_outcome_id")
+	if idStr == "" {
+		return nil, true
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid expected outcome ID", http.StatusBadRequest)
+		return nil, false
+	}
+	_, obj, err := app.loadOutcome(id)
+	if err != nil {
+		http.Error(w, "Expected outcome not found", http.StatusBadRequest)
+		return nil, false
+	}
+	if !app.auth.Authorize(w, user, auth.ResourceObjectives, auth.ActionWrite, obj.UserID) {
+		return nil, false
+	}
+	return &id, true
+}
+
+// renderTaskForm shows the new or edit task form. Validation errors are shown
+// above the form; other errors are logged and answered with a 500.
+func (app *App) renderTaskForm(w http.ResponseWriter, r *http.Request, user *store.User, task *store.Task, isEdit bool, formErr error, status int) {
+	if formErr != nil && !isAssignmentError(formErr) {
+		log.Println("Error saving task:", formErr)
+		http.Error(w, "Error saving task", http.StatusInternalServerError)
+		return
+	}
+
+	// Get user's objectives with expected outcomes for the dropdown
+	objectives, err := app.store.GetObjectivesWithOutcomes(user.ID)
+	if err != nil {
+		log.Println("Error fetching objectives:", err)
+		http.Error(w, "Error loading objectives", http.StatusInternalServerError)
+		return
+	}
+
+	priorities := []store.TaskPriority{store.PriorityLow, store.PriorityMedium, store.PriorityHigh, store.PriorityUrgent}
+	statuses := []store.TaskStatus{store.TaskStatusPending, store.TaskStatusInProgress, store.TaskStatusCompleted, store.TaskStatusOnHold}
+
+	projects, err := app.store.GetProjectsForUser(user.ID)
+	if err != nil {
+		log.Println("Error fetching projects:", err)
+	}
+
+	isAssignee := task != nil && task.UserID != user.ID && task.IsAssignedTo(user.ID)
+	var assignees []store.User
+	if isAssignee {
+		if task.CreatedByUser, err = app.store.GetUserByID(task.UserID); err != nil {
+			log.Println("Error fetching task creator:", err)
+			task.CreatedByUser = &store.User{}
+		}
+	} else {
+		users, err := app.store.GetAllUsers()
+		if err != nil {
+			log.Println("Error fetching users:", err)
+		}
+		for _, u := range users {
+			if u.ID != user.ID {
+				assignees = append(assignees, u)
+			}
+		}
+	}
+
+	data := TaskFormData{
+		User:       *user,
+		Task:       task,
+		Objectives: objectives,
+		Priorities: priorities,
+		Statuses:   statuses,
+		TaskTypes:  taskTypes,
+		Projects:   projects,
+		Assignees:  assignees,
+		IsEdit:     isEdit,
+		IsAssignee: isAssignee,
+	}
+	if formErr != nil {
+		data.Error = formErr.Error()
+	}
+
+	w.WriteHeader(status)
+	err = app.render(w, r, "task_form.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+	}
+}
+
+func (app *App) editTaskHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	idStr := r.URL.Query().Get("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	task, err := app.store.GetTaskByID(id)
+	if err != nil {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify ownership, or that the task is assigned to the user
+	if !app.authorizeTask(w, user, task, auth.ActionWrite) {
+		return
+	}
+	isOwner := app.auth.CanAccess(user, auth.ResourceTasks, auth.ActionWrite, task.UserID)
+	if !isOwner && task.AssignmentStatus != store.AssignmentAccepted {
+		http.Error(w, errNotAccepted.Error(), http.StatusConflict)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		if !app.requireTaskUnlocked(w, task.ExpectedOutcomeID) {
+			return
+		}
+		before := *task
+		newStatus := store.TaskStatus(r.FormValue("status"))
+		completionPercentageStr := r.FormValue("completion_percentage")
+
+		if isOwner {
+			task.Title = r.FormValue("title")
+			task.Description = r.FormValue("description")
+			task.Priority = store.TaskPriority(r.FormValue("priority"))
+			task.DueDate, _ = time.Parse("2006-01-02", r.FormValue("due_date"))
+			task.ProjectID = app.userProjectID(user.ID, r.FormValue("project_id"))
+			task.TaskType = formTaskType(r)
+			task.RequestedBy = r.FormValue("requested_by")
+
+			if err := app.assignTask(user, task, parseOptionalID(r.FormValue("assigned_to_id"))); err != nil {
+				app.renderTaskForm(w, r, user, task, true, err, http.StatusBadRequest)
+				return
+			}
+		}
+
+		// Parse expected outcome ID if provided. While a task is assigned,
+		// the assignee links it to one of their own expected outcomes.
+		if !isOwner || !task.IsAssigned() {
+			var ok bool
+			if task.ExpectedOutcomeID, ok = app.formOutcomeID(w, r, user); !ok {
+				return
+			}
+			if !app.requireTaskUnlocked(w, task.ExpectedOutcomeID) {
+				return
+			}
+		}
+
+		// Parse completion percentage
+		if completionPercentageStr != "" {
+			completionPercentage, err := strconv.ParseFloat(completionPercentageStr, 64)
+			if err == nil && completionPercentage >= 0 && completionPercentage <= 100 {
+				task.CompletionPercentage = completionPercentage
+			}
+		}
+
+		// Update completed time if status changed to completed
+		if newStatus == store.TaskStatusCompleted && task.Status != store.TaskStatusCompleted {
+			now := time.Now()
+			task.CompletedAt = &now
+			task.CompletionPercentage = 100 // Auto-set to 100% when completed
+		} else if newStatus != store.TaskStatusCompleted {
+			task.CompletedAt = nil
+		}
+
+		task.Status = newStatus
+
+		err = app.store.UpdateTask(task, user.ID)
+		if err != nil {
+			log.Println("Error updating task:", err)
+			http.Error(w, "Error updating task", http.StatusInternalServerError)
+			return
+		}
+		app.notifyTaskChanges(user, before, task)
+
+		redirect := "/tasks"
+		if !isOwner {
+			redirect = "/tasks?view=assigned"
+		}
+		http.Redirect(w, r, redirect, http.StatusSeeOther)
+		return
+	}
+
+	app.renderTaskForm(w, r, user, task, true, nil, http.StatusOK)
+}
+
+// Task assignment handler - the assignee accepts, declines or passes on a task
+func (app *App) taskAssignmentHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+	task, err := app.store.GetTaskByID(id)
+	if err != nil {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	if !app.requireTaskUnlocked(w, task.ExpectedOutcomeID) {
+		return
+	}
+
+	before := *task
+	note := strings.TrimSpace(r.FormValue("note"))
+	switch r.FormValue("action") {
+	case "accept":
+		err = acceptTask(user, task)
+	case "decline":
+		err = declineTask(user, task, note)
+	case "reassign":
+		assigneeID, _ := strconv.Atoi(r.FormValue("assigned_to_id"))
+		err = app.reassignTask(user, task, assigneeID, note)
+	default:
+		http.Error(w, "Unknown action", http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, errNotAssignee) {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+	if err == nil {
+		err = app.store.UpdateTask(task, user.ID)
+	}
+	if err != nil {
+		if isAssignmentError(err) {
+			app.renderTasks(w, r, user, "assigned", err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Println("Error updating task assignment:", err)
+		http.Error(w, "Error updating task", http.StatusInternalServerError)
+		return
+	}
+	app.notifyTaskChanges(user, before, task)
+
+	http.Redirect(w, r, "/tasks?view=assigned", http.StatusSeeOther)
+}
+
+func (app *App) deleteTaskHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := r.FormValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	task, err := app.store.GetTaskByID(id)
+	if err != nil {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify ownership
+	if !app.auth.Authorize(w, user, auth.ResourceTasks, auth.ActionWrite, task.UserID) {
+		return
+	}
+	if !app.requireTaskUnlocked(w, task.ExpectedOutcomeID) {
+		return
+	}
+
+	err = app.store.DeleteTask(id, user.ID)
+	if err != nil {
+		log.Println("Error deleting task:", err)
+		http.Error(w, "Error deleting task", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/tasks", http.StatusSeeOther)
+}
+
+// Reports handler
+func (app *App) reportsHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	cycle, cycles, err := app.selectedCycle(r)
+	if err != nil {
+		log.Println("Error fetching review cycles:", err)
+		http.Error(w, "Error loading reports", http.StatusInternalServerError)
+		return
+	}
+
+	// Get objectives with full data
+	objectivesWithOutcomes, err := app.hierarchyForCycle(user.ID, cycle)
+	if err != nil {
+		log.Println("Error fetching objectives:", err)
+		http.Error(w, "Error loading reports", http.StatusInternalServerError)
+		return
+	}
+	objectives := hierarchyObjectives(objectivesWithOutcomes)
+
+	// Get tasks
+	tasks, err := app.store.GetTasksByUserID(user.ID)
+	if err != nil {
+		log.Println("Error fetching tasks:", err)
+		tasks = []store.Task{}
+	}
+
+	// Past dates show objective scores as they stood at the end of that day
+	asOf := parseAsOf(r)
+	velocityEnd := time.Now().UTC()
+	if asOf != nil {
+		objectivesWithOutcomes, objectives, err = app.objectivesAsOf(objectivesWithOutcomes, *asOf)
+		if err != nil {
+			log.Println("Error calculating past performance:", err)
+			http.Error(w, "Error loading reports", http.StatusInternalServerError)
+			return
+		}
+		velocityEnd = *asOf
+	}
+	velocity, err := app.Velocity(user.ID, velocityEnd, velocityWeeks)
+	if err != nil {
+		log.Println("Error calculating velocity:", err)
+	}
+
+	completedTasks, pendingTasks, _ := app.store.GetTaskCountsByStatus(user.ID)
+	if cycle != nil {
+		tasks = tasksInCycle(tasks, cycle)
+		completedTasks, pendingTasks = 0, 0
+		for _, t := range tasks {
+			if t.Status == store.TaskStatusCompleted {
+				completedTasks++
+			} else {
+				pendingTasks++
+			}
+		}
+	}
+
+	data := ReportData{
+		User:               *user,
+		Cycles:             cycles,
+		Cycle:              cycle,
+		Objectives:         objectivesWithOutcomes,
+		Tasks:              tasks,
+		TotalObjectives:    len(objectives),
+		CompletedTasks:     completedTasks,
+		PendingTasks:       pendingTasks,
+		AveragePerformance: OverallScore(objectives),
+		AsOf:               asOf,
+		Velocity:           velocity,
+		AverageVelocity:    averageVelocity(velocity),
+	}
+
+	err = app.render(w, r, "reports.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// Objectives page (separate from dashboard)
+func (app *App) objectivesPageHandler(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	objectivesWithOutcomes, err := app.store.GetObjectivesWithOutcomes(user.ID)
+	if err != nil {
+		log.Println("Error fetching objectives:", err)
+		http.Error(w, "Error loading objectives", http.StatusInternalServerError)
+		return
+	}
+
+	data := DashboardData{
+		User:           *user,
+		Objectives:     objectivesWithOutcomes,
+		WeightWarnings: WeightWarnings(hierarchyObjectives(objectivesWithOutcomes)),
+	}
+
+	err = app.render(w, r, "objectives.html", data)
+	if err != nil {
+		log.Println("Template error:", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
@@ -1,126 +1,127 @@
 package main
 
 import (
-	"html/template"
+	"fmt"
 	"log"
 	"net/http"
-)
+	"os"
+	"time"
 
-var templates *template.Template
+	"github.com/gorilla/sessions"
 
-func init() {
-	templates = template.Must(template.ParseGlob("templates/*.html"))
-}
+	"staffperformance/internal/auth"
+	"staffperformance/internal/scoring"
+	"staffperformance/internal/store"
+	"staffperformance/internal/web"
+)
 
 func main() {
-	// Initialize database
-	if err := InitDB(); err != nil {
-		log.Fatal("Database initialization failed:", err)
+	cfg, args, err := LoadConfig(os.Args[1:])
+	if err != nil {
+		log.Fatal("Configuration error: ", err)
 	}
-	defer db.Close()
-
-	// Serve static files
-	fs := http.FileServer(http.Dir("static"))
-	http.Handle("/static/", http.StripPrefix("/static/", fs))
-
-	// Public routes
-	http.HandleFunc("/", homeHandler)
-	http.HandleFunc("/login", loginHandler)
-	http.HandleFunc("/logout", logoutHandler)
-	http.HandleFunc("/register", registrationHandler)
-
-	// Protected routes
-	http.HandleFunc("/dashboard", RequireAuth(dashboardHandler))
-
-	// Main menu routes
-	http.HandleFunc("/tasks", RequireAuth(tasksHandler))
-	http.HandleFunc("/reports", RequireAuth(reportsHandler))
-	http.HandleFunc("/objectives", RequireAuth(objectivesPageHandler))
-
-	// Task routes
-	http.HandleFunc("/tasks/new", RequireAuth(newTaskHandler))
-	http.HandleFunc("/tasks/edit", RequireAuth(editTaskHandler))
-	http.HandleFunc("/tasks/delete", RequireAuth(deleteTaskHandler))
-
-	// Objective routes
-	http.HandleFunc("/objectives/new", RequireAuth(newObjectiveHandler))
-	http.HandleFunc("/objectives/edit", RequireAuth(editObjectiveHandler))
-	http.HandleFunc("/objectives/delete", RequireAuth(deleteObjectiveHandler))
-
-	// Expected Outcome routes
-	http.HandleFunc("/outcomes/new", RequireAuth(newExpectedOutcomeHandler))
-	http.HandleFunc("/outcomes/edit", RequireAuth(editExpectedOutcomeHandler))
-	http.HandleFunc("/outcomes/delete", RequireAuth(deleteExpectedOutcomeHandler))
-
-	// Activity routes
-	http.HandleFunc("/activities/new", RequireAuth(newActivityHandler))
-	http.HandleFunc("/activities/edit", RequireAuth(editActivityHandler))
-	http.HandleFunc("/activities/delete", RequireAuth(deleteActivityHandler))
-
-	// Staff management routes (Admin only)
-	http.HandleFunc("/staff", RequireAuth(staffListHandler))
-	http.HandleFunc("/staff/new", RequireAuth(newStaffHandler))
-	http.HandleFunc("/staff/edit", RequireAuth(editStaffHandler))
-	http.HandleFunc("/staff/delete", RequireAuth(deleteStaffHandler))
-
-	// Supervisor routes
-	http.HandleFunc("/supervisor/dashboard", RequireAuth(supervisorDashboardHandler))
-	http.HandleFunc("/supervisor/staff", RequireAuth(viewStaffReportHandler))
-
-	// Comment routes
-	http.HandleFunc("/comments/new", RequireAuth(addCommentHandler))
-	http.HandleFunc("/comments/delete", RequireAuth(deleteCommentHandler))
-
-	log.Println("Server starting on http://localhost:8080")
-	if err := http.ListenAndServe(":8080", nil); err != nil {
-		log.Fatal(err)
+	strategy, err := scoring.Lookup(cfg.ScoringStrategy)
+	if err != nil {
+		log.Fatal("Scoring configuration error: ", err)
 	}
-}
 
-func homeHandler(w http.ResponseWriter, r *http.Request) {
-	if r.URL.Path != "/" {
-		http.NotFound(w, r)
+	// Schema management: staffperformance [flags] migrate status|up|rollback [steps]
+	if len(args) > 0 {
+		if args[0] != "migrate" {
+			log.Fatalf("Unknown command %q", args[0])
+		}
+		s, err := openStore(cfg, strategy)
+		if err != nil {
+			log.Fatal("Database open failed: ", err)
+		}
+		defer s.Close()
+		if err := runMigrateCommand(s, args[1:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
 		return
 	}
+	if !cfg.IsProduction() && len(cfg.SessionKeys) > 0 && cfg.SessionKeys[0] == defaultSessionSecret {
+		log.Println("Warning: using the default session secret; set SP_SESSION_KEYS before deploying")
+	}
 
-	// Check if user is already logged in
-	user, err := GetSession(r)
-	if err == nil && user != nil {
-		http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
-		return
+	sender, err := cfg.MailSender()
+	if err != nil {
+		log.Fatal("Mail configuration error: ", err)
 	}
 
-	err = templates.ExecuteTemplate(w, "login.html", nil)
+	// Initialize database
+	s, err := openStore(cfg, strategy)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		log.Println(err)
+		log.Fatal("Database open failed: ", err)
+	}
+	if err := s.Init(); err != nil {
+		log.Fatal("Database initialization failed:", err)
+	}
+	defer s.Close()
+	if err := auth.CreateDefaultAdmin(s); err != nil {
+		log.Println("Error creating default user:", err)
 	}
-}
 
-func loginHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Redirect(w, r, "/", http.StatusSeeOther)
-		return
+	authenticator, err := newAuthenticator(cfg, s)
+	if err != nil {
+		log.Fatal("Session store initialization failed:", err)
 	}
 
-	username := r.FormValue("username")
-	password := r.FormValue("password")
+	app, err := web.New(web.Options{
+		Store:   s,
+		Auth:    authenticator,
+		Scoring: strategy,
+		Ratings: cfg.RatingScale,
+		Mailer:  sender,
+		BaseURL: cfg.BaseURL,
+		Login: web.LoginPolicy{
+			MaxFailures: cfg.LoginMaxFailures,
+			Lockout:     time.Duration(cfg.LoginLockout) * time.Second,
+		},
+		TrustProxy: cfg.TrustProxy,
+	})
+	if err != nil {
+		log.Fatal("Template loading failed: ", err)
+	}
 
-	user, err := GetUserByUsername(username)
-	if err != nil || user.Password != password {
-		log.Printf("Login failed for user: %s", username)
-		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
-		return
+	// Deliver queued email and run scheduled jobs in the background
+	go app.RunMailer()
+	go app.RunScheduler()
+
+	log.Printf("Server starting on %s (%s)", cfg.ListenAddr, cfg.Env)
+	if err := http.ListenAndServe(cfg.ListenAddr, app.Handler()); err != nil {
+		log.Fatal(err)
 	}
+}
 
-	// Set session
-	err = SetSession(w, r, user)
+// newAuthenticator creates the session cookie store from the configured key
+// pairs and cookie options
+func newAuthenticator(cfg *Config, s store.Store) (*auth.Authenticator, error) {
+	keyPairs, err := cfg.SessionKeyPairs()
 	if err != nil {
-		log.Println("Error setting session:", err)
-		http.Error(w, "Login error", http.StatusInternalServerError)
-		return
+		return nil, err
+	}
+	sameSite, err := cfg.SameSite()
+	if err != nil {
+		return nil, err
 	}
+	return auth.NewAuthenticator(s, keyPairs, sessions.Options{
+		Path:     "/",
+		MaxAge:   cfg.SessionMaxAge,
+		HttpOnly: true,
+		Secure:   cfg.CookieSecure,
+		SameSite: sameSite,
+	}), nil
+}
 
-	log.Printf("Login successful for user: %s", username)
-	http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+// openStore connects to the database selected by the configuration without
+// changing its schema. Objectives read from it are scored with strategy.
+func openStore(cfg *Config, strategy scoring.Strategy) (store.Store, error) {
+	switch store.Dialect(cfg.DBDriver) {
+	case store.DialectSQLite:
+		return store.OpenSQLiteStore(cfg.DBPath, strategy)
+	case store.DialectPostgres:
+		return store.OpenPostgresStore(cfg.DBURL, strategy)
+	}
+	return nil, fmt.Errorf("unknown database driver %q", cfg.DBDriver)
 }
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"staffperformance/internal/store"
+)
+
+// runMigrateCommand implements "migrate status|up|rollback [steps]"
+func runMigrateCommand(m store.Migrator, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("usage: migrate status|up|rollback [steps]")
+	}
+
+	switch args[0] {
+	case "status":
+		statuses, err := m.GetMigrationStatus()
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED")
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return tw.Flush()
+
+	case "up":
+		count, err := m.MigrateUp()
+		fmt.Fprintf(out, "Applied %d migration(s)\n", count)
+		return err
+
+	case "rollback":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid rollback steps %q", args[1])
+			}
+			steps = n
+		}
+		count, err := m.MigrateDown(steps)
+		fmt.Fprintf(out, "Rolled back %d migration(s)\n", count)
+		return err
+	}
+	return fmt.Errorf("unknown migrate command %q", args[0])
+}